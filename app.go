@@ -2,35 +2,83 @@ package main
 
 import (
 	"DersDostu/internal/ai"
+	"DersDostu/internal/config"
 	"DersDostu/internal/db"
+	"DersDostu/internal/lessons"
 	"DersDostu/internal/mailer"
+	"DersDostu/internal/postprocess"
 	"DersDostu/internal/recorder"
+	"DersDostu/internal/server"
+	"DersDostu/internal/speech"
+	"DersDostu/internal/storage"
 	"DersDostu/internal/sync"
 	"context"
 	"encoding/base64"
 	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	gosync "sync"
 	"time"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
 )
 
 // App struct
 type App struct {
-	ctx      context.Context
-	recorder *recorder.RecorderService
-	sync     *sync.SyncManager
-	ai       *ai.ShapeService
-	db       *db.DBService
-	mailer   *mailer.MailerService
-	// fileServer *server.ServerService // Wrapper if needed, or just keep reference
+	ctx         context.Context
+	recorder    *recorder.RecorderService
+	sync        *sync.SyncManager
+	ai          *ai.ShapeService
+	db          *db.DBService
+	mailer      *mailer.MailerService
+	server      *server.ServerService
+	storage     *storage.StorageManager
+	config      *config.ConfigManager
+	post        *postprocess.PostProcessor
+	transcripts *speech.TranscriptionService
+	audio       *speech.AudioRecorder
+	models      *speech.ModelManager
+	speech      *speech.SpeechService
+	tts         *speech.TTSService
+	lessons     *lessons.LessonManager
+	autosave    *lessons.Autosaver
+	captions    chan db.CaptionLine // caption lines waiting for the database, see saveCaption
+
+	// recordingMu serializes the recording calls (a voice command can race a
+	// button press) and guards the two fields below
+	recordingMu   gosync.Mutex
+	active        recorder.Recorder // recorder of the recording in progress
+	recordingFile string            // file of the recording in progress
+
+	// The current lesson is read by the audio goroutine (captions) while
+	// Wails calls change it, so both fields are guarded together.
+	lessonMu    gosync.Mutex
+	lessonClass string // class of the current lesson
+	lessonID    string // recordings and submissions are filed under this lesson
 }
 
 // NewApp creates a new App application struct
-func NewApp(rec *recorder.RecorderService, syn *sync.SyncManager, ai *ai.ShapeService, db *db.DBService, mailer *mailer.MailerService) *App {
+func NewApp(rec *recorder.RecorderService, syn *sync.SyncManager, ai *ai.ShapeService, db *db.DBService, mailer *mailer.MailerService, srv *server.ServerService, sm *storage.StorageManager, cfg *config.ConfigManager, post *postprocess.PostProcessor, transcripts *speech.TranscriptionService, audio *speech.AudioRecorder, models *speech.ModelManager, speechService *speech.SpeechService, tts *speech.TTSService, lessonManager *lessons.LessonManager, autosave *lessons.Autosaver) *App {
 	return &App{
-		recorder: rec,
-		sync:     syn,
-		ai:       ai,
-		db:       db,
-		mailer:   mailer,
+		recorder:    rec,
+		sync:        syn,
+		ai:          ai,
+		db:          db,
+		mailer:      mailer,
+		server:      srv,
+		storage:     sm,
+		config:      cfg,
+		post:        post,
+		transcripts: transcripts,
+		audio:       audio,
+		models:      models,
+		speech:      speechService,
+		tts:         tts,
+		lessons:     lessonManager,
+		autosave:    autosave,
+		active:      rec,
 	}
 }
 
@@ -38,6 +86,95 @@ func NewApp(rec *recorder.RecorderService, syn *sync.SyncManager, ai *ai.ShapeSe
 // so we can call the runtime methods
 func (a *App) startup(ctx context.Context) {
 	a.ctx = ctx
+	a.recorder.Startup(ctx)
+
+	// Forward homework uploads to the frontend so the teacher can drop them on the canvas
+	a.server.OnSubmission(func(sub server.Submission) {
+		runtime.EventsEmit(a.ctx, "submission-received", sub)
+	})
+
+	// Let the frontend show the recording next to the lesson notes
+	a.post.OnProcessed(func(rec db.Recording) {
+		runtime.EventsEmit(a.ctx, "recording-processed", rec)
+		a.transcripts.Enqueue(rec.Filename)
+	})
+	a.transcripts.OnTranscribed(func(filename string, segments []db.TranscriptSegment) {
+		runtime.EventsEmit(a.ctx, "recording-transcribed", map[string]interface{}{
+			"filename": filename,
+			"lines":    len(segments),
+		})
+	})
+
+	// Live captions become the lesson's written record and, if shared, the students' screen
+	a.captions = make(chan db.CaptionLine, 256)
+	go a.storeCaptions()
+	speech.OnCaption(a.speech, a.saveCaption, a.server.PublishPartialCaption)
+
+	// Before recording is possible, so a new recording's folder is never salvaged
+	a.recoverRecordings()
+}
+
+// saveCaption files a caption line under the current lesson and shares it on the LAN.
+// It runs on the audio goroutine, so the database write is left to storeCaptions.
+func (a *App) saveCaption(line speech.CaptionLine) {
+	a.server.PublishCaption(line.Text, line.SpokenAt.Format("15:04:05"))
+	if a.db == nil {
+		return
+	}
+	className, lessonID := a.currentLesson()
+	select {
+	case a.captions <- db.CaptionLine{
+		ClassName:    className,
+		LessonID:     lessonID,
+		SessionStart: line.Session,
+		Start:        line.Start,
+		End:          line.End,
+		SpokenAt:     line.SpokenAt,
+		Text:         line.Text,
+	}:
+	default:
+		fmt.Printf("Caption not saved, database too slow: %s\n", line.Text)
+	}
+}
+
+// storeCaptions writes caption lines to the database in the order they were spoken
+func (a *App) storeCaptions() {
+	for line := range a.captions {
+		if err := a.db.SaveCaptionLine(line); err != nil {
+			fmt.Printf("Caption not saved: %v\n", err)
+		}
+	}
+}
+
+// shutdown is called when the app quits; the board so far goes to disk
+func (a *App) shutdown(ctx context.Context) {
+	if err := a.autosave.Flush(); err != nil {
+		fmt.Printf("Autosave on exit failed: %v\n", err)
+	}
+}
+
+// recoverRecordings salvages recordings interrupted by a crash or power cut
+// and tells the teacher which lessons were saved.
+func (a *App) recoverRecordings() {
+	recovered, err := a.recorder.RecoverOrphans()
+	if err != nil {
+		fmt.Printf("Recording recovery failed: %v\n", err)
+		return
+	}
+	names := []string{}
+	for _, rec := range recovered {
+		className, lessonID := rec.ClassName, rec.LessonID
+		if lessonID == "" {
+			// Recorded before lessons were kept with the parts: the board's class on that day
+			className = a.config.Get().Board.ClassName
+			lessonID = fmt.Sprintf("%s-%s", rec.RecordedAt.Format("2006-01-02"), storage.SanitizeName(className))
+		}
+		a.post.Enqueue(postprocess.Job{Filename: rec.Filename, ClassName: className, LessonID: lessonID})
+		names = append(names, rec.Filename)
+	}
+	if len(names) > 0 {
+		runtime.EventsEmit(a.ctx, "recording-recovered", names)
+	}
 }
 
 // Greet returns a greeting for the given name
@@ -46,30 +183,459 @@ func (a *App) Greet(name string) string {
 }
 
 // StartRecording wrapper
+// Records the screen through ffmpeg, or only the microphone when the board
+// is set to (or, on auto, has to fall back to) the audio-only engine.
 func (a *App) StartRecording() (string, error) {
+	a.recordingMu.Lock()
+	defer a.recordingMu.Unlock()
+
+	if a.active.IsRecording() {
+		return "", fmt.Errorf("recording already in progress")
+	}
+
+	var rec recorder.Recorder = a.recorder
+	ext := ".mp4"
+	if recorder.ResolveEngine(a.config.Get().Recording.Engine) == recorder.EngineAudio {
+		rec, ext = a.audio, a.audio.Extension()
+	}
+
 	// Generate filename
 	timestamp := time.Now().Format("2006-01-02-15-04-05")
-	filename := fmt.Sprintf("rec-%s.mp4", timestamp)
+	filename := fmt.Sprintf("rec-%s%s", timestamp, ext)
+	a.recorder.SetLesson(a.currentLesson())
 
-	if err := a.recorder.StartRecording(filename); err != nil {
+	if err := rec.StartRecording(filename); err != nil {
 		return "", err
 	}
+	a.active = rec
+	a.recordingFile = filename
 	return filename, nil
 }
 
 // StopRecording wrapper
 func (a *App) StopRecording() (string, error) {
-	if err := a.recorder.StopRecording(); err != nil {
+	a.recordingMu.Lock()
+	defer a.recordingMu.Unlock()
+
+	if err := a.active.StopRecording(); err != nil {
 		return "", err
 	}
+
+	// Thumbnails, metadata, lesson linking and upload happen in the background
+	className, lessonID := a.currentLesson()
+	a.post.Enqueue(postprocess.Job{Filename: a.recordingFile, ClassName: className, LessonID: lessonID})
 	// Return the saved file path or URL?
 	// Return generic success message for now
 	return "ok", nil
 }
 
+// GetRecordingEngine returns the configured engine ("" = automatic) and the one that will be used
+func (a *App) GetRecordingEngine() map[string]string {
+	engine := a.config.Get().Recording.Engine
+	return map[string]string{
+		"engine":   engine,
+		"resolved": recorder.ResolveEngine(engine),
+	}
+}
+
+// SetRecordingEngine selects ffmpeg, audio-only or automatic ("") for the next
+// recording; audioFormat is "wav" or "opus" for audio-only
+func (a *App) SetRecordingEngine(engine string, audioFormat string) error {
+	switch engine {
+	case recorder.EngineAuto, recorder.EngineFFmpeg, recorder.EngineAudio:
+	default:
+		return fmt.Errorf("unknown recording engine: %s", engine)
+	}
+	switch audioFormat {
+	case "", speech.AudioFormatWAV, speech.AudioFormatOpus:
+	default:
+		return fmt.Errorf("unknown audio format: %s", audioFormat)
+	}
+
+	err := a.config.Update(func(c *config.Config) {
+		c.Recording.Engine = engine
+		c.Recording.AudioFormat = audioFormat
+	})
+	if err != nil {
+		return err
+	}
+	a.audio.SetFormat(audioFormat)
+	return nil
+}
+
+// ListSpeechModels returns the installed speech models
+func (a *App) ListSpeechModels() []speech.ModelInfo {
+	return a.models.ListModels()
+}
+
+// SwitchSpeechModel loads another installed model and remembers it for the next start
+func (a *App) SwitchSpeechModel(name string) (speech.ModelInfo, error) {
+	model, err := a.models.SwitchModel(name)
+	if err != nil {
+		return model, err
+	}
+	if err := a.config.Update(func(c *config.Config) { c.Speech.Model = name }); err != nil {
+		return model, err
+	}
+	runtime.EventsEmit(a.ctx, "speech-model-changed", model)
+	return model, nil
+}
+
+// InstallSpeechModel installs a model from a zip file; an empty path asks for the file
+func (a *App) InstallSpeechModel(zipPath string) (speech.ModelInfo, error) {
+	if zipPath == "" {
+		var err error
+		zipPath, err = runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
+			Title:   "Konuşma modeli seç",
+			Filters: []runtime.FileFilter{{DisplayName: "Vosk modeli (*.zip)", Pattern: "*.zip"}},
+		})
+		if err != nil || zipPath == "" {
+			return speech.ModelInfo{}, err
+		}
+	}
+	return a.models.InstallZip(zipPath)
+}
+
+// ListSpeechMicrophones returns the microphones dictation can use
+func (a *App) ListSpeechMicrophones() ([]speech.InputDevice, error) {
+	return a.speech.ListInputDevices()
+}
+
+// SetSpeechMicrophone selects the dictation microphone by name ("" = default) and remembers it
+func (a *App) SetSpeechMicrophone(name string) error {
+	if err := a.config.Update(func(c *config.Config) { c.Speech.Microphone = name }); err != nil {
+		return err
+	}
+	a.speech.SetInputDevice(name)
+	return nil
+}
+
+// SetDictationAutoStop ends dictation after seconds of silence (0 = never) and remembers it
+func (a *App) SetDictationAutoStop(seconds int) error {
+	if seconds < 0 {
+		return fmt.Errorf("invalid silence timeout: %d", seconds)
+	}
+	if err := a.config.Update(func(c *config.Config) { c.Speech.AutoStopSeconds = seconds }); err != nil {
+		return err
+	}
+	a.speech.SetAutoStopSilence(seconds)
+	return nil
+}
+
+// SetCaptionSharing shows live captions on the LAN page or stops it, remembers
+// the choice and returns the page's address
+func (a *App) SetCaptionSharing(enabled bool) (string, error) {
+	if err := a.config.Update(func(c *config.Config) { c.Speech.ShareCaptions = enabled }); err != nil {
+		return "", err
+	}
+	a.server.SetCaptionsEnabled(enabled)
+	return a.server.BaseURL() + "/altyazi", nil
+}
+
+// GetLessonCaptions returns the live captions of a lesson as a written record of what was explained
+func (a *App) GetLessonCaptions(className string, lessonID string) ([]db.CaptionLine, error) {
+	if a.db == nil {
+		return nil, fmt.Errorf("database not available")
+	}
+	return a.db.GetLessonCaptions(className, lessonID)
+}
+
+// SetReadAloudVolume sets the text-to-speech volume in percent and remembers it
+func (a *App) SetReadAloudVolume(percent int) error {
+	if percent < 0 || percent > 100 {
+		return fmt.Errorf("invalid volume: %d", percent)
+	}
+	if err := a.config.Update(func(c *config.Config) { c.Speech.ReadAloudVolume = percent }); err != nil {
+		return err
+	}
+	a.tts.SetVolume(percent)
+	return nil
+}
+
+// CallRoll reads the class roster aloud for attendance, one name after another.
+// An empty className is the current lesson's class.
+func (a *App) CallRoll(className string) (int, error) {
+	if className == "" {
+		className, _ = a.currentLesson()
+	}
+	roster, err := a.GetRoster(className)
+	if err != nil {
+		return 0, err
+	}
+	if len(roster) == 0 {
+		return 0, fmt.Errorf("no students in %s", className)
+	}
+	return len(roster), a.tts.SpeakNames(roster)
+}
+
+// SetCurrentLesson sets the lesson that new recordings and submissions are filed under
+// and returns its ID (date-class-lesson).
+func (a *App) SetCurrentLesson(className string, lessonName string) string {
+	lessonID := fmt.Sprintf("%s-%s-%s", time.Now().Format("2006-01-02"), storage.SanitizeName(className), storage.SanitizeName(lessonName))
+	a.setLesson(className, lessonID)
+	return lessonID
+}
+
+// CreateLesson starts a new lesson document for today and makes it the current lesson
+func (a *App) CreateLesson(className string, subject string, title string) (db.Lesson, error) {
+	lesson, err := a.lessons.Create(className, subject, title)
+	if err != nil {
+		return lesson, err
+	}
+	a.setLesson(lesson.ClassName, lesson.ID)
+	return lesson, nil
+}
+
+// OpenLesson loads a lesson with its pages and makes it the current lesson
+func (a *App) OpenLesson(lessonID string) (lessons.Document, error) {
+	doc, err := a.lessons.Open(lessonID)
+	if err != nil {
+		return doc, err
+	}
+	a.setLesson(doc.Lesson.ClassName, doc.Lesson.ID)
+	return doc, nil
+}
+
+// SaveLesson stores the pages of a lesson. Layers without data keep their saved image.
+func (a *App) SaveLesson(doc lessons.Document) (lessons.Document, error) {
+	return a.lessons.Save(doc)
+}
+
+// ListLessons returns the saved lessons of a class (empty = every class), newest first
+func (a *App) ListLessons(className string) ([]db.Lesson, error) {
+	return a.lessons.List(className)
+}
+
+// DeleteLesson deletes a lesson document; its recordings and submissions are kept
+func (a *App) DeleteLesson(lessonID string) error {
+	if err := a.lessons.Delete(lessonID); err != nil {
+		return err
+	}
+	a.lessonMu.Lock()
+	if a.lessonID == lessonID {
+		a.lessonClass, a.lessonID = "", ""
+	}
+	a.lessonMu.Unlock()
+	return nil
+}
+
+// AutosavePage journals the current picture of a board page; writes are debounced
+func (a *App) AutosavePage(snapshot lessons.PageSnapshot) error {
+	className, lessonID := a.currentLesson()
+	return a.autosave.Snapshot(snapshot, className, lessonID)
+}
+
+// GetAutosaveRecovery returns the lesson left unfinished by a crash or power cut, or nil
+func (a *App) GetAutosaveRecovery() *lessons.AutosaveSession {
+	return a.autosave.Recovery()
+}
+
+// RestoreAutosave loads the unfinished lesson and continues it
+func (a *App) RestoreAutosave() (lessons.AutosaveRecovery, error) {
+	recovery, err := a.autosave.Restore()
+	if err != nil {
+		return recovery, err
+	}
+	if recovery.Session.LessonID != "" {
+		a.setLesson(recovery.Session.ClassName, recovery.Session.LessonID)
+	}
+	return recovery, nil
+}
+
+// DiscardAutosave deletes the unfinished lesson instead of restoring it
+func (a *App) DiscardAutosave() error {
+	return a.autosave.Discard()
+}
+
+// FinishAutosave ends the journal once the lesson has been shared
+func (a *App) FinishAutosave() error {
+	return a.autosave.Finish()
+}
+
+// setLesson makes a lesson the current one
+func (a *App) setLesson(className, lessonID string) {
+	a.lessonMu.Lock()
+	a.lessonClass, a.lessonID = className, lessonID
+	a.lessonMu.Unlock()
+}
+
+// currentLesson returns the current class and lesson. Without one, recordings
+// go under the board's class and today's date.
+func (a *App) currentLesson() (string, string) {
+	a.lessonMu.Lock()
+	className, lessonID := a.lessonClass, a.lessonID
+	a.lessonMu.Unlock()
+	if lessonID != "" {
+		return className, lessonID
+	}
+	className = a.config.Get().Board.ClassName
+	return className, fmt.Sprintf("%s-%s", time.Now().Format("2006-01-02"), storage.SanitizeName(className))
+}
+
+// ListRecordings returns the processed recordings of a lesson (empty lessonID = whole class)
+func (a *App) ListRecordings(className string, lessonID string) ([]db.Recording, error) {
+	if a.db == nil {
+		return nil, fmt.Errorf("database not available")
+	}
+	return a.db.ListRecordings(className, lessonID)
+}
+
+// AddRecordingChapter marks the current moment of the recording, e.g.
+// kind "page" and title "Sayfa 3". Ignored when nothing is being recorded
+// and by audio-only recordings.
+func (a *App) AddRecordingChapter(kind string, title string) error {
+	if !a.recorder.IsRecording() {
+		return nil
+	}
+	_, err := a.recorder.AddChapter(kind, title)
+	return err
+}
+
+// TranscribeRecording (re)creates the subtitles of a recording in the background
+func (a *App) TranscribeRecording(filename string) error {
+	if filename != filepath.Base(filename) {
+		return fmt.Errorf("invalid recording name")
+	}
+	if _, err := os.Stat(filepath.Join(a.storage.PublicDir, filename)); err != nil {
+		return fmt.Errorf("recording not found: %s", filename)
+	}
+	a.transcripts.Enqueue(filename)
+	return nil
+}
+
+// GetTranscript returns the transcript lines of a recording
+func (a *App) GetTranscript(filename string) ([]db.TranscriptSegment, error) {
+	if a.db == nil {
+		return nil, fmt.Errorf("database not available")
+	}
+	return a.db.GetTranscript(filename)
+}
+
+// SearchTranscripts finds where a word was said, e.g. "türev" across a term's
+// recordings of a class (empty className = every class)
+func (a *App) SearchTranscripts(query string, className string) ([]db.TranscriptHit, error) {
+	if a.db == nil {
+		return nil, fmt.Errorf("database not available")
+	}
+	return a.db.SearchTranscripts(query, className, 200)
+}
+
+// GetRecordingStatus returns state, elapsed time and ffmpeg statistics of the recorder
+func (a *App) GetRecordingStatus() recorder.RecorderStatus {
+	a.recordingMu.Lock()
+	defer a.recordingMu.Unlock()
+	return a.active.Status()
+}
+
+// PauseRecording wrapper (e.g. during breaks or attendance)
+func (a *App) PauseRecording() error {
+	a.recordingMu.Lock()
+	defer a.recordingMu.Unlock()
+	return a.active.PauseRecording()
+}
+
+// ResumeRecording wrapper
+func (a *App) ResumeRecording() error {
+	a.recordingMu.Lock()
+	defer a.recordingMu.Unlock()
+	return a.active.ResumeRecording()
+}
+
+// SetRecordingSegmentMinutes saves the segment length used by the next recordings (0 = off)
+func (a *App) SetRecordingSegmentMinutes(minutes int) error {
+	if minutes < 0 {
+		return fmt.Errorf("invalid segment length: %d", minutes)
+	}
+	err := a.config.Update(func(c *config.Config) {
+		c.Recording.SegmentMinutes = minutes
+	})
+	if err != nil {
+		return err
+	}
+	a.recorder.SetSegmentDuration(time.Duration(minutes) * time.Minute)
+	return nil
+}
+
+// ListRecordingProfiles returns the profiles the teacher can choose from
+func (a *App) ListRecordingProfiles() []recorder.Profile {
+	return a.config.Get().Recording.AvailableProfiles()
+}
+
+// GetRecordingProfile returns the active profile
+func (a *App) GetRecordingProfile() recorder.Profile {
+	return a.config.Get().Recording.ActiveProfile()
+}
+
+// SetRecordingProfile selects the profile used by the next recording
+func (a *App) SetRecordingProfile(name string) (recorder.Profile, error) {
+	var profile recorder.Profile
+	found := false
+	for _, p := range a.ListRecordingProfiles() {
+		if p.Name == name {
+			profile, found = p, true
+		}
+	}
+	if !found {
+		return recorder.Profile{}, fmt.Errorf("unknown recording profile: %s", name)
+	}
+	if err := profile.Validate(); err != nil {
+		return recorder.Profile{}, err
+	}
+
+	err := a.config.Update(func(c *config.Config) {
+		c.Recording.Profile = name
+	})
+	if err != nil {
+		return recorder.Profile{}, err
+	}
+	a.recorder.SetProfile(profile)
+	return profile, nil
+}
+
+// ListCaptureDevices returns the microphones and screens available for recording
+func (a *App) ListCaptureDevices() ([]recorder.CaptureDevice, error) {
+	return recorder.ListCaptureDevices()
+}
+
+// SetRecordingMicrophone selects the microphone used by the next recording (empty = system default)
+func (a *App) SetRecordingMicrophone(deviceID string) error {
+	err := a.config.Update(func(c *config.Config) {
+		c.Recording.Microphone = deviceID
+	})
+	if err != nil {
+		return err
+	}
+	a.recorder.SetMicrophone(deviceID)
+	return nil
+}
+
+// GetCaptureTarget returns what the recorder captures
+func (a *App) GetCaptureTarget() recorder.CaptureTarget {
+	return a.config.Get().Recording.Capture
+}
+
+// SetCaptureTarget selects the desktop, a monitor, a region or the app window for the next recording
+func (a *App) SetCaptureTarget(target recorder.CaptureTarget) error {
+	if err := target.Validate(); err != nil {
+		return err
+	}
+	err := a.config.Update(func(c *config.Config) {
+		c.Recording.Capture = target
+	})
+	if err != nil {
+		return err
+	}
+	a.recorder.SetCapture(target)
+	return nil
+}
+
 // UploadLesson wrapper
-// Takes Base64 data from frontend, saves it, and returns the URL
+// Takes Base64 data from frontend, saves it, and returns the URL.
+// Large files should use BeginLessonUpload instead, which never holds the whole file.
 func (a *App) UploadLesson(filename string, base64Data string) (string, error) {
+	if base64.StdEncoding.DecodedLen(len(base64Data)) > sync.MaxChunkSize {
+		return "", fmt.Errorf("file too large, use a chunked upload")
+	}
 	// Simple base64 decode
 	data, err := base64.StdEncoding.DecodeString(base64Data)
 	if err != nil {
@@ -82,6 +648,68 @@ func (a *App) UploadLesson(filename string, base64Data string) (string, error) {
 		return "", err
 	}
 
+	a.mailLessonNotes(filePath)
+	return url, nil
+}
+
+// BeginLessonUpload starts a chunked upload of lesson notes and returns its ID.
+// sha256 is the hex digest of the whole file; empty skips the check.
+func (a *App) BeginLessonUpload(filename string, size int64, sha256 string) (string, error) {
+	return a.sync.BeginUpload(filename, size, sha256)
+}
+
+// AppendLessonUpload adds a base64 chunk at offset and returns the bytes received so far
+func (a *App) AppendLessonUpload(uploadID string, offset int64, chunk string) (int64, error) {
+	// Refuse oversized chunks before decoding them
+	if base64.StdEncoding.DecodedLen(len(chunk)) > sync.MaxChunkSize+2 {
+		return 0, fmt.Errorf("chunk larger than %d MB", sync.MaxChunkSize/(1024*1024))
+	}
+	data, err := base64.StdEncoding.DecodeString(chunk)
+	if err != nil {
+		return 0, fmt.Errorf("base64 decode failed: %v", err)
+	}
+	return a.sync.AppendChunk(uploadID, offset, data)
+}
+
+// CommitLessonUpload verifies and publishes an upload, mails it like
+// UploadLesson and returns its URL
+func (a *App) CommitLessonUpload(uploadID string) (string, error) {
+	url, filePath, err := a.sync.CommitUpload(uploadID)
+	if err != nil {
+		return "", err
+	}
+	a.mailLessonNotes(filePath)
+	return url, nil
+}
+
+// AbortLessonUpload cancels an upload and deletes what was received
+func (a *App) AbortLessonUpload(uploadID string) error {
+	return a.sync.AbortUpload(uploadID)
+}
+
+// ExportLessonPDF renders a saved lesson as a PDF with a title page, shares it
+// like UploadLesson and returns its URL. The PDF is built here rather than in
+// the webview, so large lessons don't have to pass through base64.
+func (a *App) ExportLessonPDF(lessonID string) (string, error) {
+	if lessonID == "" || strings.ContainsAny(lessonID, `/\`) || strings.HasPrefix(lessonID, ".") {
+		return "", fmt.Errorf("invalid lesson ID %q", lessonID)
+	}
+	board := a.config.Get().Board
+	path := filepath.Join(a.storage.PublicDir, lessonID+".pdf")
+	if err := a.lessons.ExportPDF(lessonID, lessons.ExportInfo{School: board.School, Teacher: board.Teacher}, path); err != nil {
+		return "", err
+	}
+
+	url, err := a.sync.SyncFile(path)
+	if err != nil {
+		return "", err
+	}
+	a.mailLessonNotes(path)
+	return url, nil
+}
+
+// mailLessonNotes sends the lesson notes to the class in the background
+func (a *App) mailLessonNotes(filePath string) {
 	// Trigger Mock Mailer
 	students := []string{"student1@test.com", "student2@test.com"}
 
@@ -91,11 +719,129 @@ func (a *App) UploadLesson(filename string, base64Data string) (string, error) {
 			fmt.Printf("Mail error: %v\n", err)
 		}
 	}()
-
-	return url, nil
 }
 
 // DetectShape wrapper
 func (a *App) DetectShape(points []map[string]float64) string {
 	return a.ai.DetectShape(points)
 }
+
+// GetRoster returns the students of a class
+func (a *App) GetRoster(className string) ([]string, error) {
+	if a.db == nil {
+		return nil, fmt.Errorf("database not available")
+	}
+	return a.db.GetRoster(className)
+}
+
+// SetRoster saves the students of a class
+func (a *App) SetRoster(className string, students []string) error {
+	if a.db == nil {
+		return fmt.Errorf("database not available")
+	}
+	return a.db.SetRoster(className, students)
+}
+
+// OpenSubmissions starts collecting homework for a class on the LAN page
+// and returns the address students should open.
+// duplicatePolicy is one of "keep", "replace" or "reject" (empty means "keep").
+func (a *App) OpenSubmissions(className string, lessonName string, duplicatePolicy string) (string, error) {
+	roster, err := a.GetRoster(className)
+	if err != nil {
+		return "", err
+	}
+
+	lessonID := a.SetCurrentLesson(className, lessonName)
+	lessonDir, err := a.storage.GetLessonDir(lessonID)
+	if err != nil {
+		return "", err
+	}
+
+	err = a.server.OpenSubmissions(server.SubmissionSession{
+		ClassName: className,
+		LessonID:  lessonID,
+		Dir:       filepath.Join(lessonDir, "submissions"),
+		Roster:    roster,
+		Policy:    server.DuplicatePolicy(duplicatePolicy),
+	})
+	if err != nil {
+		return "", err
+	}
+	return a.server.BaseURL() + "/odev", nil
+}
+
+// CloseSubmissions stops collecting homework
+func (a *App) CloseSubmissions() {
+	a.server.CloseSubmissions()
+}
+
+// GetSubmissionDataURL loads a submitted file as a data URL so it can be placed on the canvas
+func (a *App) GetSubmissionDataURL(path string) (string, error) {
+	// Only files inside the lessons folder may be read
+	rel, err := filepath.Rel(a.storage.LessonsDir, filepath.Clean(path))
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("invalid submission path")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read submission: %v", err)
+	}
+
+	mimeType := strings.Split(http.DetectContentType(data), ";")[0]
+	return fmt.Sprintf("data:%s;base64,%s", mimeType, base64.StdEncoding.EncodeToString(data)), nil
+}
+
+// GetBoardInfo returns how this board identifies itself on the LAN
+func (a *App) GetBoardInfo() server.BoardInfo {
+	board := a.config.Get().Board
+	return server.BoardInfo{
+		School:    board.School,
+		ClassName: board.ClassName,
+		BoardName: board.BoardName,
+		ID:        server.BoardID(),
+		Host:      a.server.Host(),
+		Port:      server.Port,
+		URL:       a.server.BaseURL(),
+	}
+}
+
+// SetBoardInfo saves the board identity and re-announces it via mDNS
+func (a *App) SetBoardInfo(school string, className string, boardName string) (server.BoardInfo, error) {
+	err := a.config.Update(func(c *config.Config) {
+		c.Board.School, c.Board.ClassName, c.Board.BoardName = school, className, boardName
+	})
+	if err != nil {
+		return server.BoardInfo{}, err
+	}
+
+	if err := a.server.Advertise(server.BoardInfo{School: school, ClassName: className, BoardName: boardName}); err != nil {
+		return server.BoardInfo{}, err
+	}
+	return a.GetBoardInfo(), nil
+}
+
+// SetTeacherName saves the teacher's name printed on exported lesson notes
+func (a *App) SetTeacherName(name string) error {
+	return a.config.Update(func(c *config.Config) {
+		c.Board.Teacher = strings.TrimSpace(name)
+	})
+}
+
+// DiscoverBoards lists the other DersDostu boards in the building
+func (a *App) DiscoverBoards() ([]server.BoardInfo, error) {
+	boards, err := server.DiscoverBoards(2 * time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	// Hide ourselves from the list
+	self := server.BoardID()
+	others := []server.BoardInfo{}
+	for _, b := range boards {
+		if b.ID != self {
+			others = append(others, b)
+		}
+	}
+	return others, nil
+}
@@ -8,9 +8,13 @@ import {
 import { useState, useRef, useEffect } from 'react';
 import { Canvas, CanvasHandle } from './components/Canvas/Canvas';
 import { Sidebar } from './components/Sidebar/Sidebar';
-import { UploadLesson, StartRecording, StopRecording } from '../wailsjs/go/main/App';
-import { jsPDF } from 'jspdf';
+import { CaptionsOverlay } from './components/Captions/CaptionsOverlay';
+import { StartRecording, StopRecording, AddRecordingChapter, CallRoll, AutosavePage, GetAutosaveRecovery, RestoreAutosave, DiscardAutosave, FinishAutosave, GetBoardInfo, CreateLesson, OpenLesson, SaveLesson, ListLessons, ExportLessonPDF } from '../wailsjs/go/main/App';
+import { db } from '../wailsjs/go/models';
+import { SetPushToTalk, StartCaptions, StopDictation } from '../wailsjs/go/speech/SpeechService';
+import { EventsOn } from '../wailsjs/runtime/runtime';
 import { cn } from "@/lib/utils";
+import { BoardPage, blankPage, toDocument, fromDocument } from '@/lib/lesson';
 import { ChevronLeft, ChevronRight, Loader2, CheckCircle2 } from 'lucide-react';
 import { motion, AnimatePresence } from 'framer-motion';
 import './App.css';
@@ -21,45 +25,107 @@ function App() {
     const [isRecording, setIsRecording] = useState(false);
     const [uploadProgress, setUploadProgress] = useState(0);
     const [showEndLessonModal, setShowEndLessonModal] = useState(false);
+    const [showCaptions, setShowCaptions] = useState(false);
 
     // Drawing tool states
     const [brushColor, setBrushColor] = useState('#000000');
     const [brushSize, setBrushSize] = useState(6);
 
     // Pagination
-    const [pages, setPages] = useState<string[]>([]);
+    const [pages, setPages] = useState<BoardPage[]>([]);
     const [currentPage, setCurrentPage] = useState(0);
 
     const canvasRef = useRef<CanvasHandle>(null);
 
+    // Saves run after a render has passed, so they read the pages from a ref
+    const pagesRef = useRef<BoardPage[]>([]);
+    const commitPages = (next: BoardPage[]) => {
+        pagesRef.current = next;
+        setPages(next);
+    };
+
+    // The lesson document the pages are saved to; created on the first save
+    const lessonRef = useRef<db.Lesson | null>(null);
+    const saveQueueRef = useRef<Promise<void>>(Promise.resolve());
+
+    // saveLesson stores the pages in the lesson document. Saves run one at a
+    // time so a page never gets two IDs.
+    const saveLesson = (): Promise<void> => {
+        const run = saveQueueRef.current.then(async () => {
+            if (!lessonRef.current) {
+                const board = await GetBoardInfo();
+                lessonRef.current = await CreateLesson(board.className, 'Ders', '');
+            }
+            const sent = pagesRef.current;
+            const saved = await SaveLesson(toDocument(lessonRef.current, sent, window.innerWidth, window.innerHeight));
+            lessonRef.current = saved.lesson;
+
+            // A page drawn on while the save ran is sent again next time
+            commitPages(Array.from(pagesRef.current, (page = blankPage(), i) => {
+                if (i >= sent.length || !saved.pages[i]) return page;
+                return {
+                    ...page,
+                    id: page.id || saved.pages[i].id,
+                    saved: page.saved || page.data === (sent[i]?.data || ''),
+                };
+            }));
+        });
+        saveQueueRef.current = run.catch(() => {});
+        return run;
+    };
+
+    // Autosave: every change is journaled by the backend so a power cut loses only the last seconds
+    const pageStateRef = useRef({ current: 0, count: 0 });
+    pageStateRef.current = { current: currentPage, count: pages.length };
+    const autosaveTimerRef = useRef<ReturnType<typeof setTimeout>>();
+
+    const autosavePage = (index: number, pageCount: number, current: number, data: string) => {
+        if (!data) return;
+        AutosavePage({ index, pageCount: Math.max(pageCount, index + 1), current, data })
+            .catch((err) => console.error('Autosave failed:', err));
+    };
+
+    const scheduleAutosave = () => {
+        clearTimeout(autosaveTimerRef.current);
+        autosaveTimerRef.current = setTimeout(() => {
+            const { current, count } = pageStateRef.current;
+            autosavePage(current, count, current, canvasRef.current?.getDataUrl() || '');
+        }, 1000);
+    };
+
+    // saveCurrentPage copies the canvas into the pages and returns them
     const saveCurrentPage = () => {
-        if (canvasRef.current) {
-            const dataUrl = canvasRef.current.getDataUrl();
-            setPages(prev => {
-                const newPages = [...prev];
-                newPages[currentPage] = dataUrl;
-                return newPages;
-            });
-        }
+        const updatedPages = [...pagesRef.current];
+        const page = updatedPages[currentPage] || blankPage();
+        const data = canvasRef.current?.getDataUrl() || '';
+        updatedPages[currentPage] = { ...page, data, saved: page.saved && page.data === data };
+        commitPages(updatedPages);
+        return updatedPages;
     };
 
     const loadPage = (pageIndex: number) => {
         // Synchronously capture current page data BEFORE any state changes
         if (canvasRef.current) {
-            const currentData = canvasRef.current.getDataUrl();
-            const updatedPages = [...pages];
-            updatedPages[currentPage] = currentData;
+            const updatedPages = saveCurrentPage();
+
+            // The page being left is journaled right away
+            clearTimeout(autosaveTimerRef.current);
+            autosavePage(currentPage, Math.max(updatedPages.length, pageIndex + 1), pageIndex, updatedPages[currentPage].data);
+            saveLesson().catch((err) => console.error('Saving the lesson failed:', err));
 
-            // Update state with saved data
-            setPages(updatedPages);
             setCurrentPage(pageIndex);
 
+            // Chapter marker so students can jump to this page in the recording
+            if (isRecording) {
+                AddRecordingChapter('page', `Sayfa ${pageIndex + 1}`).catch(console.error);
+            }
+
             // Clear and load target page
             canvasRef.current.clear();
 
             setTimeout(() => {
-                if (updatedPages[pageIndex]) {
-                    canvasRef.current?.loadDataUrl(updatedPages[pageIndex]);
+                if (updatedPages[pageIndex]?.data) {
+                    canvasRef.current?.loadDataUrl(updatedPages[pageIndex].data);
                 } else {
                     canvasRef.current?.clear();
                 }
@@ -77,6 +143,11 @@ function App() {
         }
     };
 
+    // A blank page after the last one, wherever the teacher is
+    const handleAddPage = () => {
+        loadPage(Math.max(pages.length, currentPage + 1));
+    };
+
     const handleEndLesson = async () => {
         setShowEndLessonModal(true);
         setIsUploading(true);
@@ -84,32 +155,18 @@ function App() {
 
         // Save current page
         saveCurrentPage();
-        const currentData = canvasRef.current?.getDataUrl() || '';
-        const finalPages = [...pages];
-        finalPages[currentPage] = currentData;
 
         try {
-            // 1. Generate PDF
-            setUploadProgress(30);
-            const doc = new jsPDF({
-                orientation: 'landscape',
-                unit: 'px',
-                format: [window.innerWidth, window.innerHeight]
-            });
-
-            finalPages.forEach((pageData, index) => {
-                if (index > 0) doc.addPage();
-                doc.addImage(pageData, 'PNG', 0, 0, window.innerWidth, window.innerHeight);
-            });
+            // 1. Store the pages; the PDF is made from the saved lesson
+            await saveLesson();
+            setUploadProgress(40);
 
-            setUploadProgress(60);
-            const pdfBase64 = doc.output('datauristring').split(',')[1];
-            const timestamp = new Date().toISOString().replace(/[:.]/g, '-');
-            const filename = `ders-${timestamp}.pdf`;
+            // 2. Export and share it; the backend mails the notes to the class
+            await ExportLessonPDF(lessonRef.current!.id);
 
-            // 2. Call Backend Mailer
-            // We use UploadLesson for now, which we will update in Backend to trigger Mailer.
-            await UploadLesson(filename, pdfBase64);
+            // The lesson is safe on the server now, no need to offer it after a restart
+            clearTimeout(autosaveTimerRef.current);
+            FinishAutosave().catch(console.error);
 
             setUploadProgress(100);
             setTimeout(() => {
@@ -140,6 +197,7 @@ function App() {
             try {
                 await StartRecording();
                 setIsRecording(true);
+                AddRecordingChapter('page', `Sayfa ${currentPage + 1}`).catch(console.error);
             } catch (e) {
                 console.error(e);
                 alert("Kayıt başlatılamadı: " + e);
@@ -147,8 +205,9 @@ function App() {
         }
     };
 
+    // Attendance: the board reads the roster aloud, one name after another
     const handleToggleAttendance = () => {
-        alert("Yoklama Modülü (Sonraki Aşamada Eklenecek)");
+        CallRoll('').catch((err) => alert("Yoklama okunamadı: " + err));
     };
 
     const handleColorClick = () => {
@@ -168,6 +227,105 @@ function App() {
         canvasRef.current?.redo();
     };
 
+    // Voice commands (see voice-commands.json). canvas-clear is handled by the Canvas.
+    // The ref always holds the latest render's handler so page and recording state are current.
+    const voiceCommandRef = useRef<(data: any) => void>(() => {});
+    voiceCommandRef.current = (data: any) => {
+        switch (data.action) {
+            case 'tool-change':
+                setActiveTool(data.tool);
+                if (data.color) setBrushColor(data.color);
+                break;
+            case 'brush-size':
+                setBrushSize(data.size);
+                break;
+            case 'page-go':
+                if (data.page >= 1) loadPage(data.page - 1);
+                break;
+            case 'page-next':
+                handleNextPage();
+                break;
+            case 'page-prev':
+                handlePrevPage();
+                break;
+            case 'page-add':
+                handleAddPage();
+                break;
+            case 'undo':
+                handleUndo();
+                break;
+            case 'redo':
+                handleRedo();
+                break;
+            case 'recording-start':
+                if (!isRecording) handleToggleRecord();
+                break;
+            case 'recording-stop':
+                if (isRecording) handleToggleRecord();
+                break;
+            case 'attendance':
+                handleToggleAttendance();
+                break;
+        }
+    };
+
+    useEffect(() => {
+        const offVoiceCommand = EventsOn('voice-command', (data: any) => voiceCommandRef.current(data));
+        return () => offVoiceCommand();
+    }, []);
+
+    // A lesson that never ended (crash, power cut) is offered back on startup,
+    // otherwise the lesson the class already started today
+    useEffect(() => {
+        GetAutosaveRecovery().then(async (session) => {
+            if (!session) {
+                await offerTodaysLesson();
+                return;
+            }
+            const updated = new Date(session.updated).toLocaleTimeString('tr-TR', { hour: '2-digit', minute: '2-digit' });
+            if (!confirm(`Yarım kalan ders bulundu (${session.pages.length} sayfa, son kayıt ${updated}). Geri yüklensin mi?`)) {
+                await DiscardAutosave();
+                return;
+            }
+            const recovery = await RestoreAutosave();
+
+            // The journal is newer than the lesson document, which only lends its page IDs
+            let pageIds: string[] = [];
+            if (recovery.session.lessonId) {
+                try {
+                    const doc = await OpenLesson(recovery.session.lessonId);
+                    lessonRef.current = doc.lesson;
+                    pageIds = doc.pages.map((page) => page.id);
+                } catch (err) {
+                    console.error('Opening the recovered lesson failed:', err);
+                }
+            }
+            showPages(recovery.pages.map((data, i) => ({ id: pageIds[i] || '', data, saved: false })), recovery.session.current);
+        }).catch((err) => console.error('Autosave recovery failed:', err));
+    }, []);
+
+    const offerTodaysLesson = async () => {
+        const board = await GetBoardInfo();
+        const [lesson] = await ListLessons(board.className);
+        const now = new Date();
+        const today = `${now.getFullYear()}-${String(now.getMonth() + 1).padStart(2, '0')}-${String(now.getDate()).padStart(2, '0')}`;
+        if (!lesson || lesson.date !== today || lesson.pageCount === 0) return;
+        if (!confirm(`Bugün başlanan "${lesson.title}" dersi bulundu (${lesson.pageCount} sayfa). Açılsın mı?`)) return;
+
+        const doc = await OpenLesson(lesson.id);
+        lessonRef.current = doc.lesson;
+        showPages(fromDocument(doc), 0);
+    };
+
+    const showPages = (restored: BoardPage[], current: number) => {
+        current = Math.max(0, Math.min(current, restored.length - 1));
+        commitPages(restored);
+        setCurrentPage(current);
+        if (restored[current]?.data) {
+            canvasRef.current?.loadDataUrl(restored[current].data);
+        }
+    };
+
     // Keyboard shortcuts for undo/redo
     useEffect(() => {
         const handleKeyDown = (e: KeyboardEvent) => {
@@ -188,6 +346,53 @@ function App() {
         return () => window.removeEventListener('keydown', handleKeyDown);
     }, []);
 
+    // Hold F8 (or a presenter remote mapped to it) to give a voice command without the wake phrase
+    useEffect(() => {
+        const handlePushToTalk = (held: boolean) => (e: KeyboardEvent) => {
+            if (e.key !== 'F8' || (held && e.repeat)) return;
+            e.preventDefault();
+            SetPushToTalk(held).catch((err) => console.error('Push-to-talk failed:', err));
+        };
+        const onDown = handlePushToTalk(true);
+        const onUp = handlePushToTalk(false);
+
+        window.addEventListener('keydown', onDown);
+        window.addEventListener('keyup', onUp);
+        return () => {
+            window.removeEventListener('keydown', onDown);
+            window.removeEventListener('keyup', onUp);
+        };
+    }, []);
+
+    // F9 turns live captions on and off; they end with any other stop (e.g. the microphone failed)
+    const captionsRef = useRef(false);
+    useEffect(() => {
+        const setCaptions = (on: boolean) => {
+            captionsRef.current = on;
+            setShowCaptions(on);
+        };
+        const offStopped = EventsOn('speech-stopped', () => setCaptions(false));
+        const handleCaptionsKey = (e: KeyboardEvent) => {
+            if (e.key !== 'F9' || e.repeat) return;
+            e.preventDefault();
+            if (captionsRef.current) {
+                setCaptions(false);
+                StopDictation().catch((err) => console.error('Stopping captions failed:', err));
+                return;
+            }
+            setCaptions(true);
+            StartCaptions().catch((err) => {
+                console.error('Captions failed:', err);
+                setCaptions(false);
+            });
+        };
+
+        window.addEventListener('keydown', handleCaptionsKey);
+        return () => {
+            offStopped();
+            window.removeEventListener('keydown', handleCaptionsKey);
+        };
+    }, []);
 
     return (
         <TooltipProvider>
@@ -220,8 +425,11 @@ function App() {
                             activeTool={activeTool === 'color-picker' ? 'pencil' : activeTool}
                             brushColor={brushColor}
                             brushSize={brushSize}
+                            onChange={scheduleAutosave}
                         />
 
+                        <CaptionsOverlay visible={showCaptions} />
+
                         {/* Pagination Controls - Floating Bottom Left Center */}
                         <div className="absolute bottom-8 left-1/2 -translate-x-1/2 flex items-center gap-4 bg-background px-6 py-3 rounded-full shadow-md border border-border transition-all hover:scale-105">
                             <Tooltip>
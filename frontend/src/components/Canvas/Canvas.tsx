@@ -1,11 +1,14 @@
 import React, { useRef, useEffect, useState, useImperativeHandle, forwardRef } from 'react';
 import { EventsOn } from '../../../wailsjs/runtime/runtime';
 import { StartDictation, StopDictation } from '../../../wailsjs/go/speech/SpeechService';
+import { SpeakNow, Stop as StopSpeaking } from '../../../wailsjs/go/speech/TTSService';
+import { AddRecordingChapter } from '../../../wailsjs/go/main/App';
 
 interface CanvasProps {
     activeTool: string;
     brushColor?: string;
     brushSize?: number;
+    onChange?: () => void; // the board was drawn on, cleared or undone
 }
 
 interface Selection {
@@ -44,7 +47,7 @@ export interface CanvasHandle {
     loadDataUrl: (url: string) => void;
 }
 
-export const Canvas = forwardRef<CanvasHandle, CanvasProps>(({ activeTool, brushColor = '#000000', brushSize = 4 }, ref) => {
+export const Canvas = forwardRef<CanvasHandle, CanvasProps>(({ activeTool, brushColor = '#000000', brushSize = 4, onChange }, ref) => {
     const canvasRef = useRef<HTMLCanvasElement>(null);
     const overlayCanvasRef = useRef<HTMLCanvasElement>(null); // For selection UI
     const [isDrawing, setIsDrawing] = useState(false);
@@ -73,6 +76,9 @@ export const Canvas = forwardRef<CanvasHandle, CanvasProps>(({ activeTool, brush
     const [textBox, setTextBox] = useState<TextBox | null>(null);
     const textBoxRef = useRef<HTMLTextAreaElement>(null);
     const [isListening, setIsListening] = useState(false);
+    const [isReading, setIsReading] = useState(false);
+    const [micLevel, setMicLevel] = useState(0);
+    const [micSilent, setMicSilent] = useState(false);
     const recognitionRef = useRef<any>(null);
 
     // Legacy single-line text (keeping for reference, will be replaced)
@@ -108,6 +114,8 @@ export const Canvas = forwardRef<CanvasHandle, CanvasProps>(({ activeTool, brush
             const newIndex = prev + 1;
             return newIndex >= maxHistoryDepth ? maxHistoryDepth - 1 : newIndex;
         });
+
+        onChange?.();
     };
 
     useImperativeHandle(ref, () => ({
@@ -127,6 +135,7 @@ export const Canvas = forwardRef<CanvasHandle, CanvasProps>(({ activeTool, brush
                         context.fillStyle = '#ffffff';
                         context.fillRect(0, 0, canvasRef.current!.width, canvasRef.current!.height);
                         context.drawImage(img, 0, 0);
+                        onChange?.();
                     };
                 }
             }
@@ -149,6 +158,7 @@ export const Canvas = forwardRef<CanvasHandle, CanvasProps>(({ activeTool, brush
                         context.fillStyle = '#ffffff';
                         context.fillRect(0, 0, canvasRef.current!.width, canvasRef.current!.height);
                         context.drawImage(img, 0, 0);
+                        onChange?.();
                     };
                 }
             }
@@ -370,8 +380,8 @@ export const Canvas = forwardRef<CanvasHandle, CanvasProps>(({ activeTool, brush
                     return prev;
                 }
                 console.log('📝 Updating textbox text. Current length:', prev.text.length);
-                // Check if current text already ends with a space
-                const separator = prev.text.endsWith(' ') || prev.text === '' ? '' : ' ';
+                // No space at the start of a line or before punctuation the backend wrote ("nokta" -> ".")
+                const separator = prev.text === '' || /\s$/.test(prev.text) || /^[.,;:!?)\n]/.test(text) ? '' : ' ';
                 return { ...prev, text: prev.text + separator + text };
             });
         });
@@ -398,6 +408,8 @@ export const Canvas = forwardRef<CanvasHandle, CanvasProps>(({ activeTool, brush
                     ctx.clearRect(0, 0, canvas.width, canvas.height);
                     saveToHistory();
                 }
+                // Ignored by the backend when nothing is being recorded
+                AddRecordingChapter('voice', 'Tahta temizlendi').catch(console.error);
             }
         });
 
@@ -405,7 +417,20 @@ export const Canvas = forwardRef<CanvasHandle, CanvasProps>(({ activeTool, brush
         const offSpeechStopped = EventsOn('speech-stopped', () => {
             console.log('🛑 STT Backend stopped, syncing state');
             setIsListening(false);
+            setMicLevel(0);
+            setMicSilent(false);
+        });
+
+        // VU meter and muted microphone warning
+        const offSpeechLevel = EventsOn('speech-level', (level: { peak: number }) => {
+            setMicLevel(level.peak);
+            if (level.peak > 0.001) setMicSilent(false);
         });
+        const offNoSignal = EventsOn('speech-no-signal', () => setMicSilent(true));
+
+        // Read-aloud state for the textbox's speaker button
+        const offReadStarted = EventsOn('tts-started', () => setIsReading(true));
+        const offReadFinished = EventsOn('tts-finished', () => setIsReading(false));
 
         return () => {
             offSpeechText();
@@ -413,6 +438,10 @@ export const Canvas = forwardRef<CanvasHandle, CanvasProps>(({ activeTool, brush
             offVoiceCommand();
             offSpeechStopped();
             offSpeechStarted();
+            offSpeechLevel();
+            offReadStarted();
+            offReadFinished();
+            offNoSignal();
         };
     }, []); // Register once, use functional updates
 
@@ -884,6 +913,7 @@ export const Canvas = forwardRef<CanvasHandle, CanvasProps>(({ activeTool, brush
                             left: `${textBox.x}px`,
                             top: `${textBox.y - 42}px`,
                             display: 'flex',
+                            alignItems: 'center',
                             gap: '8px',
                             zIndex: 10000,
                         }}
@@ -980,6 +1010,46 @@ export const Canvas = forwardRef<CanvasHandle, CanvasProps>(({ activeTool, brush
                         >
                             🎤 {isListening ? 'Dinleniyor...' : 'Konuş'}
                         </button>
+                        <button
+                            onClick={() => {
+                                if (isReading) {
+                                    StopSpeaking().catch(console.warn);
+                                    return;
+                                }
+                                // Read the selected words, or the whole text without a selection
+                                const area = textBoxRef.current;
+                                const selected = area ? area.value.substring(area.selectionStart, area.selectionEnd) : '';
+                                const text = selected.trim() || textBox.text;
+                                if (!text.trim()) return;
+                                SpeakNow(text).catch((err) => {
+                                    console.error('❌ Read-aloud failed:', err);
+                                    alert('Sesli okuma başlatılamadı: ' + err);
+                                });
+                            }}
+                            style={{
+                                padding: '8px 16px',
+                                backgroundColor: isReading ? '#F59E0B' : '#6B7280',
+                                color: 'white',
+                                border: 'none',
+                                borderRadius: '4px',
+                                fontWeight: '600',
+                                fontSize: '14px',
+                                cursor: 'pointer',
+                                boxShadow: '0 2px 8px rgba(107, 114, 128, 0.4)',
+                                fontFamily: 'Inter, system-ui, sans-serif',
+                            }}
+                        >
+                            {isReading ? '⏹ Durdur' : '🔊 Oku'}
+                        </button>
+                        {isListening && (
+                            <div
+                                title={micSilent ? 'Mikrofondan ses gelmiyor - sessize alınmış olabilir' : 'Mikrofon seviyesi'}
+                                style={{ width: '60px', height: '6px', borderRadius: '3px', background: micSilent ? '#FCA5A5' : '#E5E7EB', overflow: 'hidden' }}
+                            >
+                                <div style={{ width: `${Math.min(100, Math.sqrt(micLevel) * 100)}%`, height: '100%', background: '#10B981', transition: 'width 80ms linear' }} />
+                            </div>
+                        )}
+                        {micSilent && <span style={{ fontSize: '12px', color: '#B91C1C' }}>Mikrofon sessiz!</span>}
                     </div>
 
                     {/* Textarea */}
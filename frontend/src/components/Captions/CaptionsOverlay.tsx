@@ -0,0 +1,52 @@
+import { useEffect, useState } from 'react';
+import { EventsOn } from '../../../wailsjs/runtime/runtime';
+
+interface CaptionLine {
+    seq: number;
+    text: string;
+}
+
+// Lines kept on screen; the full transcript is saved with the lesson
+const VISIBLE_LINES = 2;
+
+// CaptionsOverlay shows live captions at the bottom of the board for students
+// who can't follow the teacher by ear.
+export function CaptionsOverlay({ visible }: { visible: boolean }) {
+    const [lines, setLines] = useState<CaptionLine[]>([]);
+    const [partial, setPartial] = useState('');
+
+    useEffect(() => {
+        const offLine = EventsOn('caption-line', (line: CaptionLine) => {
+            setLines(prev => [...prev, line].slice(-VISIBLE_LINES));
+            setPartial('');
+        });
+        const offPartial = EventsOn('caption-partial', (text: string) => setPartial(text));
+        const offStopped = EventsOn('speech-stopped', () => setPartial(''));
+        return () => {
+            offLine();
+            offPartial();
+            offStopped();
+        };
+    }, []);
+
+    useEffect(() => {
+        if (!visible) {
+            setLines([]);
+            setPartial('');
+        }
+    }, [visible]);
+
+    if (!visible) return null;
+
+    return (
+        <div className="absolute bottom-28 left-1/2 -translate-x-1/2 w-[80%] max-w-5xl pointer-events-none z-40">
+            <div className="bg-black/75 text-white rounded-lg px-6 py-3 text-3xl leading-snug text-center shadow-lg">
+                {lines.map(line => (
+                    <p key={line.seq}>{line.text}</p>
+                ))}
+                {partial && <p className="text-white/70 italic">{partial}</p>}
+                {lines.length === 0 && !partial && <p className="text-white/60 text-xl">Altyazı açık - konuşmaya başlayın</p>}
+            </div>
+        </div>
+    );
+}
@@ -0,0 +1,38 @@
+import { db, lessons } from '../../wailsjs/go/models';
+
+// The canvas draws every page on a single raster layer
+const INK_LAYER = 'ink';
+
+// BoardPage is a page as the board keeps it. id is empty until the page is
+// first saved; saved means data is already stored and needn't be sent again.
+export interface BoardPage {
+    id: string;
+    data: string; // PNG data URL, empty for a page never drawn on
+    saved: boolean;
+}
+
+export const blankPage = (): BoardPage => ({ id: '', data: '', saved: false });
+
+// toDocument builds the document SaveLesson stores. Pages the lesson skipped
+// (e.g. "sayfa beş" on a new lesson) are saved blank.
+export function toDocument(lesson: db.Lesson, pages: BoardPage[], width: number, height: number): lessons.Document {
+    return lessons.Document.createFrom({
+        lesson,
+        pages: Array.from(pages, (page = blankPage()) => ({
+            id: page.id,
+            width,
+            height,
+            layers: page.data ? [{ name: INK_LAYER, visible: true, opacity: 1, data: page.saved ? '' : page.data }] : [],
+            objects: [],
+        })),
+    });
+}
+
+// fromDocument turns an opened lesson back into board pages
+export function fromDocument(doc: lessons.Document): BoardPage[] {
+    return doc.pages.map((page) => ({
+        id: page.id,
+        data: page.layers.find((layer) => layer.name === INK_LAYER)?.data || '',
+        saved: true,
+    }));
+}
@@ -1,12 +1,123 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
+import {lessons} from '../models';
+import {db} from '../models';
+import {server} from '../models';
+import {recorder} from '../models';
+import {speech} from '../models';
+
+export function AbortLessonUpload(arg1:string):Promise<void>;
+
+export function AddRecordingChapter(arg1:string,arg2:string):Promise<void>;
+
+export function AppendLessonUpload(arg1:string,arg2:number,arg3:string):Promise<number>;
+
+export function AutosavePage(arg1:lessons.PageSnapshot):Promise<void>;
+
+export function BeginLessonUpload(arg1:string,arg2:number,arg3:string):Promise<string>;
+
+export function CallRoll(arg1:string):Promise<number>;
+
+export function CloseSubmissions():Promise<void>;
+
+export function CommitLessonUpload(arg1:string):Promise<string>;
+
+export function CreateLesson(arg1:string,arg2:string,arg3:string):Promise<db.Lesson>;
+
+export function DeleteLesson(arg1:string):Promise<void>;
 
 export function DetectShape(arg1:Array<Record<string, number>>):Promise<string>;
 
+export function DiscardAutosave():Promise<void>;
+
+export function DiscoverBoards():Promise<Array<server.BoardInfo>>;
+
+export function ExportLessonPDF(arg1:string):Promise<string>;
+
+export function FinishAutosave():Promise<void>;
+
+export function GetAutosaveRecovery():Promise<lessons.AutosaveSession>;
+
+export function GetBoardInfo():Promise<server.BoardInfo>;
+
+export function GetCaptureTarget():Promise<recorder.CaptureTarget>;
+
+export function GetLessonCaptions(arg1:string,arg2:string):Promise<Array<db.CaptionLine>>;
+
+export function GetRecordingEngine():Promise<Record<string, string>>;
+
+export function GetRecordingProfile():Promise<recorder.Profile>;
+
+export function GetRecordingStatus():Promise<recorder.RecorderStatus>;
+
+export function GetRoster(arg1:string):Promise<Array<string>>;
+
+export function GetSubmissionDataURL(arg1:string):Promise<string>;
+
+export function GetTranscript(arg1:string):Promise<Array<db.TranscriptSegment>>;
+
 export function Greet(arg1:string):Promise<string>;
 
+export function InstallSpeechModel(arg1:string):Promise<speech.ModelInfo>;
+
+export function ListCaptureDevices():Promise<Array<recorder.CaptureDevice>>;
+
+export function ListLessons(arg1:string):Promise<Array<db.Lesson>>;
+
+export function ListRecordingProfiles():Promise<Array<recorder.Profile>>;
+
+export function ListRecordings(arg1:string,arg2:string):Promise<Array<db.Recording>>;
+
+export function ListSpeechMicrophones():Promise<Array<speech.InputDevice>>;
+
+export function ListSpeechModels():Promise<Array<speech.ModelInfo>>;
+
+export function OpenLesson(arg1:string):Promise<lessons.Document>;
+
+export function OpenSubmissions(arg1:string,arg2:string,arg3:string):Promise<string>;
+
+export function PauseRecording():Promise<void>;
+
+export function RestoreAutosave():Promise<lessons.AutosaveRecovery>;
+
+export function ResumeRecording():Promise<void>;
+
+export function SaveLesson(arg1:lessons.Document):Promise<lessons.Document>;
+
+export function SearchTranscripts(arg1:string,arg2:string):Promise<Array<db.TranscriptHit>>;
+
+export function SetBoardInfo(arg1:string,arg2:string,arg3:string):Promise<server.BoardInfo>;
+
+export function SetCaptionSharing(arg1:boolean):Promise<string>;
+
+export function SetCaptureTarget(arg1:recorder.CaptureTarget):Promise<void>;
+
+export function SetCurrentLesson(arg1:string,arg2:string):Promise<string>;
+
+export function SetDictationAutoStop(arg1:number):Promise<void>;
+
+export function SetReadAloudVolume(arg1:number):Promise<void>;
+
+export function SetRecordingEngine(arg1:string,arg2:string):Promise<void>;
+
+export function SetRecordingMicrophone(arg1:string):Promise<void>;
+
+export function SetRecordingProfile(arg1:string):Promise<recorder.Profile>;
+
+export function SetRecordingSegmentMinutes(arg1:number):Promise<void>;
+
+export function SetRoster(arg1:string,arg2:Array<string>):Promise<void>;
+
+export function SetSpeechMicrophone(arg1:string):Promise<void>;
+
+export function SetTeacherName(arg1:string):Promise<void>;
+
 export function StartRecording():Promise<string>;
 
 export function StopRecording():Promise<string>;
 
+export function SwitchSpeechModel(arg1:string):Promise<speech.ModelInfo>;
+
+export function TranscribeRecording(arg1:string):Promise<void>;
+
 export function UploadLesson(arg1:string,arg2:string):Promise<string>;
@@ -2,14 +2,218 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
 
+export function AbortLessonUpload(arg1) {
+  return window['go']['main']['App']['AbortLessonUpload'](arg1);
+}
+
+export function AddRecordingChapter(arg1, arg2) {
+  return window['go']['main']['App']['AddRecordingChapter'](arg1, arg2);
+}
+
+export function AppendLessonUpload(arg1, arg2, arg3) {
+  return window['go']['main']['App']['AppendLessonUpload'](arg1, arg2, arg3);
+}
+
+export function AutosavePage(arg1) {
+  return window['go']['main']['App']['AutosavePage'](arg1);
+}
+
+export function BeginLessonUpload(arg1, arg2, arg3) {
+  return window['go']['main']['App']['BeginLessonUpload'](arg1, arg2, arg3);
+}
+
+export function CallRoll(arg1) {
+  return window['go']['main']['App']['CallRoll'](arg1);
+}
+
+export function CloseSubmissions() {
+  return window['go']['main']['App']['CloseSubmissions']();
+}
+
+export function CommitLessonUpload(arg1) {
+  return window['go']['main']['App']['CommitLessonUpload'](arg1);
+}
+
+export function CreateLesson(arg1, arg2, arg3) {
+  return window['go']['main']['App']['CreateLesson'](arg1, arg2, arg3);
+}
+
+export function DeleteLesson(arg1) {
+  return window['go']['main']['App']['DeleteLesson'](arg1);
+}
+
 export function DetectShape(arg1) {
   return window['go']['main']['App']['DetectShape'](arg1);
 }
 
+export function DiscardAutosave() {
+  return window['go']['main']['App']['DiscardAutosave']();
+}
+
+export function DiscoverBoards() {
+  return window['go']['main']['App']['DiscoverBoards']();
+}
+
+export function ExportLessonPDF(arg1) {
+  return window['go']['main']['App']['ExportLessonPDF'](arg1);
+}
+
+export function FinishAutosave() {
+  return window['go']['main']['App']['FinishAutosave']();
+}
+
+export function GetAutosaveRecovery() {
+  return window['go']['main']['App']['GetAutosaveRecovery']();
+}
+
+export function GetBoardInfo() {
+  return window['go']['main']['App']['GetBoardInfo']();
+}
+
+export function GetCaptureTarget() {
+  return window['go']['main']['App']['GetCaptureTarget']();
+}
+
+export function GetLessonCaptions(arg1, arg2) {
+  return window['go']['main']['App']['GetLessonCaptions'](arg1, arg2);
+}
+
+export function GetRecordingEngine() {
+  return window['go']['main']['App']['GetRecordingEngine']();
+}
+
+export function GetRecordingProfile() {
+  return window['go']['main']['App']['GetRecordingProfile']();
+}
+
+export function GetRecordingStatus() {
+  return window['go']['main']['App']['GetRecordingStatus']();
+}
+
+export function GetRoster(arg1) {
+  return window['go']['main']['App']['GetRoster'](arg1);
+}
+
+export function GetSubmissionDataURL(arg1) {
+  return window['go']['main']['App']['GetSubmissionDataURL'](arg1);
+}
+
+export function GetTranscript(arg1) {
+  return window['go']['main']['App']['GetTranscript'](arg1);
+}
+
 export function Greet(arg1) {
   return window['go']['main']['App']['Greet'](arg1);
 }
 
+export function InstallSpeechModel(arg1) {
+  return window['go']['main']['App']['InstallSpeechModel'](arg1);
+}
+
+export function ListCaptureDevices() {
+  return window['go']['main']['App']['ListCaptureDevices']();
+}
+
+export function ListLessons(arg1) {
+  return window['go']['main']['App']['ListLessons'](arg1);
+}
+
+export function ListRecordingProfiles() {
+  return window['go']['main']['App']['ListRecordingProfiles']();
+}
+
+export function ListRecordings(arg1, arg2) {
+  return window['go']['main']['App']['ListRecordings'](arg1, arg2);
+}
+
+export function ListSpeechMicrophones() {
+  return window['go']['main']['App']['ListSpeechMicrophones']();
+}
+
+export function ListSpeechModels() {
+  return window['go']['main']['App']['ListSpeechModels']();
+}
+
+export function OpenLesson(arg1) {
+  return window['go']['main']['App']['OpenLesson'](arg1);
+}
+
+export function OpenSubmissions(arg1, arg2, arg3) {
+  return window['go']['main']['App']['OpenSubmissions'](arg1, arg2, arg3);
+}
+
+export function PauseRecording() {
+  return window['go']['main']['App']['PauseRecording']();
+}
+
+export function RestoreAutosave() {
+  return window['go']['main']['App']['RestoreAutosave']();
+}
+
+export function ResumeRecording() {
+  return window['go']['main']['App']['ResumeRecording']();
+}
+
+export function SaveLesson(arg1) {
+  return window['go']['main']['App']['SaveLesson'](arg1);
+}
+
+export function SearchTranscripts(arg1, arg2) {
+  return window['go']['main']['App']['SearchTranscripts'](arg1, arg2);
+}
+
+export function SetBoardInfo(arg1, arg2, arg3) {
+  return window['go']['main']['App']['SetBoardInfo'](arg1, arg2, arg3);
+}
+
+export function SetCaptionSharing(arg1) {
+  return window['go']['main']['App']['SetCaptionSharing'](arg1);
+}
+
+export function SetCaptureTarget(arg1) {
+  return window['go']['main']['App']['SetCaptureTarget'](arg1);
+}
+
+export function SetCurrentLesson(arg1, arg2) {
+  return window['go']['main']['App']['SetCurrentLesson'](arg1, arg2);
+}
+
+export function SetDictationAutoStop(arg1) {
+  return window['go']['main']['App']['SetDictationAutoStop'](arg1);
+}
+
+export function SetReadAloudVolume(arg1) {
+  return window['go']['main']['App']['SetReadAloudVolume'](arg1);
+}
+
+export function SetRecordingEngine(arg1, arg2) {
+  return window['go']['main']['App']['SetRecordingEngine'](arg1, arg2);
+}
+
+export function SetRecordingMicrophone(arg1) {
+  return window['go']['main']['App']['SetRecordingMicrophone'](arg1);
+}
+
+export function SetRecordingProfile(arg1) {
+  return window['go']['main']['App']['SetRecordingProfile'](arg1);
+}
+
+export function SetRecordingSegmentMinutes(arg1) {
+  return window['go']['main']['App']['SetRecordingSegmentMinutes'](arg1);
+}
+
+export function SetRoster(arg1, arg2) {
+  return window['go']['main']['App']['SetRoster'](arg1, arg2);
+}
+
+export function SetSpeechMicrophone(arg1) {
+  return window['go']['main']['App']['SetSpeechMicrophone'](arg1);
+}
+
+export function SetTeacherName(arg1) {
+  return window['go']['main']['App']['SetTeacherName'](arg1);
+}
+
 export function StartRecording() {
   return window['go']['main']['App']['StartRecording']();
 }
@@ -18,6 +222,14 @@ export function StopRecording() {
   return window['go']['main']['App']['StopRecording']();
 }
 
+export function SwitchSpeechModel(arg1) {
+  return window['go']['main']['App']['SwitchSpeechModel'](arg1);
+}
+
+export function TranscribeRecording(arg1) {
+  return window['go']['main']['App']['TranscribeRecording'](arg1);
+}
+
 export function UploadLesson(arg1, arg2) {
   return window['go']['main']['App']['UploadLesson'](arg1, arg2);
 }
@@ -0,0 +1,697 @@
+export namespace db {
+	
+	export class CaptionLine {
+	    className: string;
+	    lessonId: string;
+	    // Go type: time
+	    sessionStart: any;
+	    start: number;
+	    end: number;
+	    // Go type: time
+	    spokenAt: any;
+	    text: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new CaptionLine(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.className = source["className"];
+	        this.lessonId = source["lessonId"];
+	        this.sessionStart = this.convertValues(source["sessionStart"], null);
+	        this.start = source["start"];
+	        this.end = source["end"];
+	        this.spokenAt = this.convertValues(source["spokenAt"], null);
+	        this.text = source["text"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class Lesson {
+	    id: string;
+	    className: string;
+	    subject: string;
+	    title: string;
+	    date: string;
+	    pageCount: number;
+	    // Go type: time
+	    createdAt: any;
+	    // Go type: time
+	    updatedAt: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new Lesson(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.className = source["className"];
+	        this.subject = source["subject"];
+	        this.title = source["title"];
+	        this.date = source["date"];
+	        this.pageCount = source["pageCount"];
+	        this.createdAt = this.convertValues(source["createdAt"], null);
+	        this.updatedAt = this.convertValues(source["updatedAt"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class Recording {
+	    id: number;
+	    filename: string;
+	    className: string;
+	    lessonId: string;
+	    durationSec: number;
+	    width: number;
+	    height: number;
+	    sizeBytes: number;
+	    thumbnail: string;
+	    chapters: string[];
+	    url: string;
+	    // Go type: time
+	    createdAt: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new Recording(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.filename = source["filename"];
+	        this.className = source["className"];
+	        this.lessonId = source["lessonId"];
+	        this.durationSec = source["durationSec"];
+	        this.width = source["width"];
+	        this.height = source["height"];
+	        this.sizeBytes = source["sizeBytes"];
+	        this.thumbnail = source["thumbnail"];
+	        this.chapters = source["chapters"];
+	        this.url = source["url"];
+	        this.createdAt = this.convertValues(source["createdAt"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class TranscriptHit {
+	    filename: string;
+	    className: string;
+	    lessonId: string;
+	    start: number;
+	    text: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new TranscriptHit(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.filename = source["filename"];
+	        this.className = source["className"];
+	        this.lessonId = source["lessonId"];
+	        this.start = source["start"];
+	        this.text = source["text"];
+	    }
+	}
+	export class TranscriptSegment {
+	    start: number;
+	    end: number;
+	    text: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new TranscriptSegment(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.start = source["start"];
+	        this.end = source["end"];
+	        this.text = source["text"];
+	    }
+	}
+
+}
+
+export namespace lessons {
+	
+	export class AutosavedPage {
+	    file: string;
+	    width: number;
+	    height: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new AutosavedPage(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.file = source["file"];
+	        this.width = source["width"];
+	        this.height = source["height"];
+	    }
+	}
+	export class AutosaveSession {
+	    className: string;
+	    lessonId: string;
+	    // Go type: time
+	    started: any;
+	    // Go type: time
+	    updated: any;
+	    current: number;
+	    pages: AutosavedPage[];
+	
+	    static createFrom(source: any = {}) {
+	        return new AutosaveSession(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.className = source["className"];
+	        this.lessonId = source["lessonId"];
+	        this.started = this.convertValues(source["started"], null);
+	        this.updated = this.convertValues(source["updated"], null);
+	        this.current = source["current"];
+	        this.pages = this.convertValues(source["pages"], AutosavedPage);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class AutosaveRecovery {
+	    session: AutosaveSession;
+	    pages: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new AutosaveRecovery(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.session = this.convertValues(source["session"], AutosaveSession);
+	        this.pages = source["pages"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	
+	export class Point {
+	    x: number;
+	    y: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new Point(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.x = source["x"];
+	        this.y = source["y"];
+	    }
+	}
+	export class VectorObject {
+	    id: string;
+	    type: string;
+	    color?: string;
+	    width?: number;
+	    points?: Point[];
+	    shape?: string;
+	    fill?: string;
+	    text?: string;
+	    fontSize?: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new VectorObject(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.type = source["type"];
+	        this.color = source["color"];
+	        this.width = source["width"];
+	        this.points = this.convertValues(source["points"], Point);
+	        this.shape = source["shape"];
+	        this.fill = source["fill"];
+	        this.text = source["text"];
+	        this.fontSize = source["fontSize"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class Layer {
+	    name: string;
+	    visible: boolean;
+	    opacity: number;
+	    data?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new Layer(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.visible = source["visible"];
+	        this.opacity = source["opacity"];
+	        this.data = source["data"];
+	    }
+	}
+	export class Page {
+	    id: string;
+	    width: number;
+	    height: number;
+	    background?: string;
+	    layers: Layer[];
+	    objects: VectorObject[];
+	
+	    static createFrom(source: any = {}) {
+	        return new Page(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.width = source["width"];
+	        this.height = source["height"];
+	        this.background = source["background"];
+	        this.layers = this.convertValues(source["layers"], Layer);
+	        this.objects = this.convertValues(source["objects"], VectorObject);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class Document {
+	    lesson: db.Lesson;
+	    pages: Page[];
+	
+	    static createFrom(source: any = {}) {
+	        return new Document(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.lesson = this.convertValues(source["lesson"], db.Lesson);
+	        this.pages = this.convertValues(source["pages"], Page);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	
+	export class PageSnapshot {
+	    index: number;
+	    pageCount: number;
+	    current: number;
+	    data: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new PageSnapshot(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.index = source["index"];
+	        this.pageCount = source["pageCount"];
+	        this.current = source["current"];
+	        this.data = source["data"];
+	    }
+	}
+	
+
+}
+
+export namespace recorder {
+	
+	export class CaptureDevice {
+	    kind: string;
+	    id: string;
+	    name: string;
+	    default: boolean;
+	    x: number;
+	    y: number;
+	    width: number;
+	    height: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new CaptureDevice(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.kind = source["kind"];
+	        this.id = source["id"];
+	        this.name = source["name"];
+	        this.default = source["default"];
+	        this.x = source["x"];
+	        this.y = source["y"];
+	        this.width = source["width"];
+	        this.height = source["height"];
+	    }
+	}
+	export class CaptureTarget {
+	    mode: string;
+	    monitor: string;
+	    x: number;
+	    y: number;
+	    width: number;
+	    height: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new CaptureTarget(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.mode = source["mode"];
+	        this.monitor = source["monitor"];
+	        this.x = source["x"];
+	        this.y = source["y"];
+	        this.width = source["width"];
+	        this.height = source["height"];
+	    }
+	}
+	export class Profile {
+	    name: string;
+	    label: string;
+	    audioOnly: boolean;
+	    framerate: number;
+	    videoCodec: string;
+	    preset: string;
+	    crf: number;
+	    videoBitrate: string;
+	    scaleHeight: number;
+	    audioCodec: string;
+	    audioBitrate: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new Profile(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.label = source["label"];
+	        this.audioOnly = source["audioOnly"];
+	        this.framerate = source["framerate"];
+	        this.videoCodec = source["videoCodec"];
+	        this.preset = source["preset"];
+	        this.crf = source["crf"];
+	        this.videoBitrate = source["videoBitrate"];
+	        this.scaleHeight = source["scaleHeight"];
+	        this.audioCodec = source["audioCodec"];
+	        this.audioBitrate = source["audioBitrate"];
+	    }
+	}
+	export class RecorderStatus {
+	    state: string;
+	    filename: string;
+	    elapsed: number;
+	    takes: number;
+	    lastError: string;
+	    frames: number;
+	    fps: number;
+	    bitrate: string;
+	    totalSize: number;
+	    speed: string;
+	    dupFrames: number;
+	    dropFrames: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new RecorderStatus(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.state = source["state"];
+	        this.filename = source["filename"];
+	        this.elapsed = source["elapsed"];
+	        this.takes = source["takes"];
+	        this.lastError = source["lastError"];
+	        this.frames = source["frames"];
+	        this.fps = source["fps"];
+	        this.bitrate = source["bitrate"];
+	        this.totalSize = source["totalSize"];
+	        this.speed = source["speed"];
+	        this.dupFrames = source["dupFrames"];
+	        this.dropFrames = source["dropFrames"];
+	    }
+	}
+
+}
+
+export namespace server {
+	
+	export class BoardInfo {
+	    school: string;
+	    className: string;
+	    boardName: string;
+	    id: string;
+	    host: string;
+	    addr: string;
+	    port: number;
+	    url: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new BoardInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.school = source["school"];
+	        this.className = source["className"];
+	        this.boardName = source["boardName"];
+	        this.id = source["id"];
+	        this.host = source["host"];
+	        this.addr = source["addr"];
+	        this.port = source["port"];
+	        this.url = source["url"];
+	    }
+	}
+
+}
+
+export namespace speech {
+	
+	export class CaptionLine {
+	    seq: number;
+	    // Go type: time
+	    session: any;
+	    start: number;
+	    end: number;
+	    // Go type: time
+	    spokenAt: any;
+	    text: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new CaptionLine(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.seq = source["seq"];
+	        this.session = this.convertValues(source["session"], null);
+	        this.start = source["start"];
+	        this.end = source["end"];
+	        this.spokenAt = this.convertValues(source["spokenAt"], null);
+	        this.text = source["text"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class InputDevice {
+	    name: string;
+	    hostApi: string;
+	    channels: number;
+	    sampleRate: number;
+	    default: boolean;
+	    selected: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new InputDevice(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.hostApi = source["hostApi"];
+	        this.channels = source["channels"];
+	        this.sampleRate = source["sampleRate"];
+	        this.default = source["default"];
+	        this.selected = source["selected"];
+	    }
+	}
+	export class ModelInfo {
+	    name: string;
+	    path: string;
+	    language: string;
+	    sizeMB: number;
+	    bundled: boolean;
+	    valid: boolean;
+	    problem?: string;
+	    active: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new ModelInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.path = source["path"];
+	        this.language = source["language"];
+	        this.sizeMB = source["sizeMB"];
+	        this.bundled = source["bundled"];
+	        this.valid = source["valid"];
+	        this.problem = source["problem"];
+	        this.active = source["active"];
+	    }
+	}
+
+}
+
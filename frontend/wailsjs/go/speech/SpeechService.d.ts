@@ -1,13 +1,34 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
+import {speech} from '../models';
 import {context} from '../models';
 
+export function GetCaptions():Promise<Array<speech.CaptionLine>>;
+
+export function GetMode():Promise<string>;
+
 export function IsListening():Promise<boolean>;
 
+export function ListInputDevices():Promise<Array<speech.InputDevice>>;
+
 export function LoadModel(arg1:string):Promise<void>;
 
+export function ModelPath():Promise<string>;
+
+export function ReloadCommands():Promise<void>;
+
+export function SetAutoStopSilence(arg1:number):Promise<void>;
+
+export function SetInputDevice(arg1:string):Promise<void>;
+
+export function SetPushToTalk(arg1:boolean):Promise<void>;
+
 export function Shutdown():Promise<void>;
 
+export function StartCaptions():Promise<void>;
+
+export function StartCommandMode():Promise<void>;
+
 export function StartDictation():Promise<void>;
 
 export function Startup(arg1:context.Context):Promise<void>;
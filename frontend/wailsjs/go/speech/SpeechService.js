@@ -2,18 +2,58 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
 
+export function GetCaptions() {
+  return window['go']['speech']['SpeechService']['GetCaptions']();
+}
+
+export function GetMode() {
+  return window['go']['speech']['SpeechService']['GetMode']();
+}
+
 export function IsListening() {
   return window['go']['speech']['SpeechService']['IsListening']();
 }
 
+export function ListInputDevices() {
+  return window['go']['speech']['SpeechService']['ListInputDevices']();
+}
+
 export function LoadModel(arg1) {
   return window['go']['speech']['SpeechService']['LoadModel'](arg1);
 }
 
+export function ModelPath() {
+  return window['go']['speech']['SpeechService']['ModelPath']();
+}
+
+export function ReloadCommands() {
+  return window['go']['speech']['SpeechService']['ReloadCommands']();
+}
+
+export function SetAutoStopSilence(arg1) {
+  return window['go']['speech']['SpeechService']['SetAutoStopSilence'](arg1);
+}
+
+export function SetInputDevice(arg1) {
+  return window['go']['speech']['SpeechService']['SetInputDevice'](arg1);
+}
+
+export function SetPushToTalk(arg1) {
+  return window['go']['speech']['SpeechService']['SetPushToTalk'](arg1);
+}
+
 export function Shutdown() {
   return window['go']['speech']['SpeechService']['Shutdown']();
 }
 
+export function StartCaptions() {
+  return window['go']['speech']['SpeechService']['StartCaptions']();
+}
+
+export function StartCommandMode() {
+  return window['go']['speech']['SpeechService']['StartCommandMode']();
+}
+
 export function StartDictation() {
   return window['go']['speech']['SpeechService']['StartDictation']();
 }
@@ -0,0 +1,39 @@
+// @ts-check
+// Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
+// This file is automatically generated. DO NOT EDIT
+
+export function Announce(arg1, arg2) {
+  return window['go']['speech']['TTSService']['Announce'](arg1, arg2);
+}
+
+export function GetVolume() {
+  return window['go']['speech']['TTSService']['GetVolume']();
+}
+
+export function IsSpeaking() {
+  return window['go']['speech']['TTSService']['IsSpeaking']();
+}
+
+export function SetVolume(arg1) {
+  return window['go']['speech']['TTSService']['SetVolume'](arg1);
+}
+
+export function Speak(arg1) {
+  return window['go']['speech']['TTSService']['Speak'](arg1);
+}
+
+export function SpeakNames(arg1) {
+  return window['go']['speech']['TTSService']['SpeakNames'](arg1);
+}
+
+export function SpeakNow(arg1) {
+  return window['go']['speech']['TTSService']['SpeakNow'](arg1);
+}
+
+export function Stop() {
+  return window['go']['speech']['TTSService']['Stop']();
+}
+
+export function VoiceName() {
+  return window['go']['speech']['TTSService']['VoiceName']();
+}
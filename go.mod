@@ -8,6 +8,7 @@ require (
 	github.com/alphacep/vosk-api/go v0.3.50
 	github.com/gofiber/fiber/v2 v2.52.11
 	github.com/gordonklaus/portaudio v0.0.0-20260203164431-765aa7dfa631
+	github.com/hashicorp/mdns v1.0.5
 	github.com/mattn/go-sqlite3 v1.14.33
 	github.com/wailsapp/wails/v2 v2.11.0
 )
@@ -30,6 +31,7 @@ require (
 	github.com/mattn/go-colorable v0.1.13 // indirect
 	github.com/mattn/go-isatty v0.0.20 // indirect
 	github.com/mattn/go-runewidth v0.0.16 // indirect
+	github.com/miekg/dns v1.1.41 // indirect
 	github.com/pkg/browser v0.0.0-20240102092130-5ac0b6a4141c // indirect
 	github.com/pkg/errors v0.9.1 // indirect
 	github.com/rivo/uniseg v0.4.7 // indirect
@@ -0,0 +1,155 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"DersDostu/internal/recorder"
+)
+
+// Config is the persistent, teacher-editable configuration of a board.
+// It is stored as JSON next to the database so it survives reinstalls.
+type Config struct {
+	Board     BoardConfig     `json:"board"`
+	Recording RecordingConfig `json:"recording"`
+	Speech    SpeechConfig    `json:"speech"`
+}
+
+// SpeechConfig holds the offline speech recognition settings
+type SpeechConfig struct {
+	// Model is the folder name of the active Vosk model; empty picks a Turkish one
+	Model string `json:"model"`
+	// Microphone is a device name from SpeechService.ListInputDevices; empty = system default
+	Microphone string `json:"microphone"`
+	// AutoStopSeconds ends dictation after this much silence (0 = never)
+	AutoStopSeconds int `json:"autoStopSeconds"`
+	// ShareCaptions shows live captions to students on the LAN page /altyazi
+	ShareCaptions bool `json:"shareCaptions"`
+	// ReadAloudVolume is the text-to-speech volume in percent
+	ReadAloudVolume int `json:"readAloudVolume"`
+}
+
+// BoardConfig identifies the board on the LAN
+type BoardConfig struct {
+	School    string `json:"school"`
+	ClassName string `json:"className"` // e.g. "9-A"
+	BoardName string `json:"boardName"` // e.g. "Fen Laboratuvarı"
+	Teacher   string `json:"teacher"`   // printed on exported lesson notes
+}
+
+// RecordingConfig holds the screen recorder settings
+type RecordingConfig struct {
+	// Engine is recorder.EngineFFmpeg, recorder.EngineAudio or empty for automatic
+	Engine string `json:"engine"`
+	// AudioFormat is "wav" or "opus" for the audio-only engine
+	AudioFormat string `json:"audioFormat"`
+
+	// SegmentMinutes splits recordings into parts of this length (0 = off),
+	// so a power cut only loses the part being written.
+	SegmentMinutes int `json:"segmentMinutes"`
+
+	// Profile is the name of the active profile in Profiles
+	Profile string `json:"profile"`
+	// Profiles can be edited by hand; empty means the built-in defaults
+	Profiles []recorder.Profile `json:"profiles,omitempty"`
+	// Microphone is a device ID from recorder.ListCaptureDevices; empty = system default
+	Microphone string `json:"microphone"`
+	// Capture is the screen area to record
+	Capture recorder.CaptureTarget `json:"capture"`
+}
+
+// AvailableProfiles returns the configured profiles or the built-in ones
+func (c RecordingConfig) AvailableProfiles() []recorder.Profile {
+	if len(c.Profiles) == 0 {
+		return recorder.DefaultProfiles()
+	}
+	return c.Profiles
+}
+
+// ActiveProfile resolves the selected profile
+func (c RecordingConfig) ActiveProfile() recorder.Profile {
+	return recorder.FindProfile(c.AvailableProfiles(), c.Profile)
+}
+
+// ConfigManager loads, guards and saves the configuration file
+type ConfigManager struct {
+	path string
+	mu   sync.Mutex
+	cfg  Config
+}
+
+// defaultConfig holds the settings whose zero value isn't a sensible default.
+// Fields missing from an older config file keep these values.
+func defaultConfig() Config {
+	return Config{
+		Speech: SpeechConfig{ReadAloudVolume: 100},
+	}
+}
+
+// NewConfigManager loads the config at path, falling back to defaults if it doesn't exist yet
+func NewConfigManager(path string) (*ConfigManager, error) {
+	m := &ConfigManager{path: path, cfg: defaultConfig()}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %v", err)
+	}
+	if err := json.Unmarshal(data, &m.cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse config %s: %v", path, err)
+	}
+	return m, nil
+}
+
+// Get returns a copy of the current configuration
+func (m *ConfigManager) Get() Config {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.cfg
+}
+
+// Update applies fn to the configuration and writes it to disk
+func (m *ConfigManager) Update(fn func(*Config)) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	next := m.cfg
+	fn(&next)
+	if err := m.save(next); err != nil {
+		return err
+	}
+	m.cfg = next
+	return nil
+}
+
+// save writes the file atomically so a power cut never leaves half a config behind
+func (m *ConfigManager) save(cfg Config) error {
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(m.path), ".config-*")
+	if err != nil {
+		return fmt.Errorf("failed to save config: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to save config: %v", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to save config: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to save config: %v", err)
+	}
+	return os.Rename(tmp.Name(), m.path)
+}
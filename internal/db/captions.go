@@ -0,0 +1,46 @@
+package db
+
+import (
+	"fmt"
+	"time"
+)
+
+// CaptionLine is one line of a live caption session, filed under a lesson
+type CaptionLine struct {
+	ClassName    string    `json:"className"`
+	LessonID     string    `json:"lessonId"`
+	SessionStart time.Time `json:"sessionStart"`
+	Start        float64   `json:"start"` // seconds since the session started
+	End          float64   `json:"end"`
+	SpokenAt     time.Time `json:"spokenAt"`
+	Text         string    `json:"text"`
+}
+
+// SaveCaptionLine appends a caption line to the lesson transcript
+func (s *DBService) SaveCaptionLine(line CaptionLine) error {
+	_, err := s.Conn.Exec(`INSERT INTO caption_lines (class_name, lesson_id, session_start, start_sec, end_sec, spoken_at, text, search) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		line.ClassName, line.LessonID, line.SessionStart, line.Start, line.End, line.SpokenAt, line.Text, searchText(line.Text))
+	if err != nil {
+		return fmt.Errorf("failed to save caption: %v", err)
+	}
+	return nil
+}
+
+// GetLessonCaptions returns everything captioned during a lesson, in the order it was said
+func (s *DBService) GetLessonCaptions(className, lessonID string) ([]CaptionLine, error) {
+	rows, err := s.Conn.Query(`SELECT class_name, lesson_id, session_start, start_sec, end_sec, spoken_at, text FROM caption_lines WHERE class_name = ? AND lesson_id = ? ORDER BY spoken_at, id`, className, lessonID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query captions: %v", err)
+	}
+	defer rows.Close()
+
+	lines := []CaptionLine{}
+	for rows.Next() {
+		var line CaptionLine
+		if err := rows.Scan(&line.ClassName, &line.LessonID, &line.SessionStart, &line.Start, &line.End, &line.SpokenAt, &line.Text); err != nil {
+			return nil, err
+		}
+		lines = append(lines, line)
+	}
+	return lines, rows.Err()
+}
@@ -2,6 +2,7 @@ package db
 
 import (
 	"database/sql"
+	"fmt"
 	"log"
 
 	_ "github.com/mattn/go-sqlite3"
@@ -21,6 +22,88 @@ func NewDBService(dbPath string) (*DBService, error) {
 		return nil, err
 	}
 
+	s := &DBService{Conn: conn}
+	if err := s.migrate(); err != nil {
+		return nil, err
+	}
+
 	log.Println("Database connected:", dbPath)
-	return &DBService{Conn: conn}, nil
+	return s, nil
+}
+
+// migrate creates the tables used by the services if they don't exist yet
+func (s *DBService) migrate() error {
+	stmts := []string{
+		`CREATE TABLE IF NOT EXISTS students (
+			id         INTEGER PRIMARY KEY AUTOINCREMENT,
+			class_name TEXT NOT NULL,
+			name       TEXT NOT NULL,
+			UNIQUE(class_name, name)
+		)`,
+		`CREATE TABLE IF NOT EXISTS recordings (
+			id           INTEGER PRIMARY KEY AUTOINCREMENT,
+			filename     TEXT NOT NULL UNIQUE,
+			class_name   TEXT NOT NULL DEFAULT '',
+			lesson_id    TEXT NOT NULL DEFAULT '',
+			duration_sec REAL NOT NULL DEFAULT 0,
+			width        INTEGER NOT NULL DEFAULT 0,
+			height       INTEGER NOT NULL DEFAULT 0,
+			size_bytes   INTEGER NOT NULL DEFAULT 0,
+			thumbnail    TEXT NOT NULL DEFAULT '',
+			chapters     TEXT NOT NULL DEFAULT '[]',
+			url          TEXT NOT NULL DEFAULT '',
+			created_at   DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_recordings_lesson ON recordings(class_name, lesson_id)`,
+		`CREATE TABLE IF NOT EXISTS transcript_segments (
+			id        INTEGER PRIMARY KEY AUTOINCREMENT,
+			filename  TEXT NOT NULL,
+			start_sec REAL NOT NULL,
+			end_sec   REAL NOT NULL,
+			text      TEXT NOT NULL,
+			search    TEXT NOT NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_transcript_filename ON transcript_segments(filename)`,
+		`CREATE TABLE IF NOT EXISTS caption_lines (
+			id            INTEGER PRIMARY KEY AUTOINCREMENT,
+			class_name    TEXT NOT NULL DEFAULT '',
+			lesson_id     TEXT NOT NULL DEFAULT '',
+			session_start DATETIME NOT NULL,
+			start_sec     REAL NOT NULL,
+			end_sec       REAL NOT NULL,
+			spoken_at     DATETIME NOT NULL,
+			text          TEXT NOT NULL,
+			search        TEXT NOT NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_caption_lesson ON caption_lines(class_name, lesson_id)`,
+		`CREATE TABLE IF NOT EXISTS lessons (
+			id          TEXT PRIMARY KEY,
+			class_name  TEXT NOT NULL DEFAULT '',
+			subject     TEXT NOT NULL DEFAULT '',
+			title       TEXT NOT NULL DEFAULT '',
+			lesson_date TEXT NOT NULL,
+			created_at  DATETIME NOT NULL,
+			updated_at  DATETIME NOT NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_lessons_class ON lessons(class_name, lesson_date)`,
+		`CREATE TABLE IF NOT EXISTS lesson_pages (
+			id         INTEGER PRIMARY KEY AUTOINCREMENT,
+			lesson_id  TEXT NOT NULL,
+			page_id    TEXT NOT NULL,
+			position   INTEGER NOT NULL,
+			width      INTEGER NOT NULL DEFAULT 0,
+			height     INTEGER NOT NULL DEFAULT 0,
+			background TEXT NOT NULL DEFAULT '',
+			layers     TEXT NOT NULL DEFAULT '[]',
+			objects    TEXT NOT NULL DEFAULT '[]',
+			UNIQUE(lesson_id, page_id)
+		)`,
+	}
+
+	for _, stmt := range stmts {
+		if _, err := s.Conn.Exec(stmt); err != nil {
+			return fmt.Errorf("migration failed: %v", err)
+		}
+	}
+	return nil
 }
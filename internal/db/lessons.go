@@ -0,0 +1,168 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Lesson is a lesson document: the teacher's board pages for one class hour
+type Lesson struct {
+	ID        string    `json:"id"` // date-class-title, shared with recordings and submissions
+	ClassName string    `json:"className"`
+	Subject   string    `json:"subject"`
+	Title     string    `json:"title"`
+	Date      string    `json:"date"` // 2006-01-02
+	PageCount int       `json:"pageCount"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// LessonPage is the stored form of a page. Layers and Objects are JSON owned
+// by the lessons package; raster layer images are files in the lesson folder.
+type LessonPage struct {
+	PageID     string
+	Position   int
+	Width      int
+	Height     int
+	Background string
+	Layers     string
+	Objects    string
+}
+
+// CreateLesson registers a new lesson; the ID must not exist yet
+func (s *DBService) CreateLesson(lesson *Lesson) error {
+	now := time.Now()
+	_, err := s.Conn.Exec(`INSERT INTO lessons (id, class_name, subject, title, lesson_date, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		lesson.ID, lesson.ClassName, lesson.Subject, lesson.Title, lesson.Date, now, now)
+	if err != nil {
+		return fmt.Errorf("failed to create lesson: %v", err)
+	}
+	lesson.CreatedAt, lesson.UpdatedAt = now, now
+	return nil
+}
+
+// LessonExists reports whether a lesson ID is taken
+func (s *DBService) LessonExists(id string) (bool, error) {
+	var n int
+	if err := s.Conn.QueryRow(`SELECT COUNT(*) FROM lessons WHERE id = ?`, id).Scan(&n); err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+const lessonColumns = `l.id, l.class_name, l.subject, l.title, l.lesson_date, l.created_at, l.updated_at,
+	(SELECT COUNT(*) FROM lesson_pages p WHERE p.lesson_id = l.id)`
+
+func scanLesson(row interface{ Scan(...interface{}) error }) (Lesson, error) {
+	var l Lesson
+	err := row.Scan(&l.ID, &l.ClassName, &l.Subject, &l.Title, &l.Date, &l.CreatedAt, &l.UpdatedAt, &l.PageCount)
+	return l, err
+}
+
+// GetLesson returns a lesson by ID
+func (s *DBService) GetLesson(id string) (Lesson, error) {
+	lesson, err := scanLesson(s.Conn.QueryRow(`SELECT `+lessonColumns+` FROM lessons l WHERE l.id = ?`, id))
+	if err == sql.ErrNoRows {
+		return lesson, fmt.Errorf("lesson %s not found", id)
+	}
+	if err != nil {
+		return lesson, fmt.Errorf("failed to query lesson: %v", err)
+	}
+	return lesson, nil
+}
+
+// ListLessons returns the lessons of a class (empty = every class), newest first
+func (s *DBService) ListLessons(className string) ([]Lesson, error) {
+	rows, err := s.Conn.Query(`SELECT `+lessonColumns+` FROM lessons l
+		WHERE ? = '' OR l.class_name = ?
+		ORDER BY l.lesson_date DESC, l.updated_at DESC`, className, className)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query lessons: %v", err)
+	}
+	defer rows.Close()
+
+	lessons := []Lesson{}
+	for rows.Next() {
+		lesson, err := scanLesson(rows)
+		if err != nil {
+			return nil, err
+		}
+		lessons = append(lessons, lesson)
+	}
+	return lessons, rows.Err()
+}
+
+// SaveLessonPages replaces the pages of a lesson and marks it updated
+func (s *DBService) SaveLessonPages(lessonID string, pages []LessonPage) error {
+	tx, err := s.Conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`UPDATE lessons SET updated_at = ? WHERE id = ?`, time.Now(), lessonID)
+	if err != nil {
+		return fmt.Errorf("failed to save lesson: %v", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("lesson %s not found", lessonID)
+	}
+	if _, err := tx.Exec(`DELETE FROM lesson_pages WHERE lesson_id = ?`, lessonID); err != nil {
+		return fmt.Errorf("failed to clear pages: %v", err)
+	}
+
+	stmt, err := tx.Prepare(`INSERT INTO lesson_pages (lesson_id, page_id, position, width, height, background, layers, objects) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, page := range pages {
+		if _, err := stmt.Exec(lessonID, page.PageID, page.Position, page.Width, page.Height, page.Background, page.Layers, page.Objects); err != nil {
+			return fmt.Errorf("failed to save page: %v", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// GetLessonPages returns the pages of a lesson in order
+func (s *DBService) GetLessonPages(lessonID string) ([]LessonPage, error) {
+	rows, err := s.Conn.Query(`SELECT page_id, position, width, height, background, layers, objects FROM lesson_pages WHERE lesson_id = ? ORDER BY position`, lessonID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query pages: %v", err)
+	}
+	defer rows.Close()
+
+	pages := []LessonPage{}
+	for rows.Next() {
+		var page LessonPage
+		if err := rows.Scan(&page.PageID, &page.Position, &page.Width, &page.Height, &page.Background, &page.Layers, &page.Objects); err != nil {
+			return nil, err
+		}
+		pages = append(pages, page)
+	}
+	return pages, rows.Err()
+}
+
+// DeleteLesson removes a lesson and its pages. Recordings, captions and
+// submissions filed under the lesson ID are kept.
+func (s *DBService) DeleteLesson(id string) error {
+	tx, err := s.Conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM lesson_pages WHERE lesson_id = ?`, id); err != nil {
+		return fmt.Errorf("failed to delete pages: %v", err)
+	}
+	res, err := tx.Exec(`DELETE FROM lessons WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete lesson: %v", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("lesson %s not found", id)
+	}
+	return tx.Commit()
+}
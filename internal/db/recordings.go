@@ -0,0 +1,82 @@
+package db
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Recording is a processed lesson recording
+type Recording struct {
+	ID          int64     `json:"id"`
+	Filename    string    `json:"filename"` // relative to PublicDir
+	ClassName   string    `json:"className"`
+	LessonID    string    `json:"lessonId"`
+	DurationSec float64   `json:"durationSec"`
+	Width       int       `json:"width"`
+	Height      int       `json:"height"`
+	SizeBytes   int64     `json:"sizeBytes"`
+	Thumbnail   string    `json:"thumbnail"` // relative to PublicDir
+	Chapters    []string  `json:"chapters"`  // chapter images, relative to PublicDir
+	URL         string    `json:"url"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+// SaveRecording inserts a recording or updates it if the file is already registered
+func (s *DBService) SaveRecording(rec *Recording) error {
+	chapters, err := json.Marshal(rec.Chapters)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.Conn.Exec(`
+		INSERT INTO recordings (filename, class_name, lesson_id, duration_sec, width, height, size_bytes, thumbnail, chapters, url)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(filename) DO UPDATE SET
+			class_name = excluded.class_name,
+			lesson_id = excluded.lesson_id,
+			duration_sec = excluded.duration_sec,
+			width = excluded.width,
+			height = excluded.height,
+			size_bytes = excluded.size_bytes,
+			thumbnail = excluded.thumbnail,
+			chapters = excluded.chapters,
+			url = excluded.url`,
+		rec.Filename, rec.ClassName, rec.LessonID, rec.DurationSec, rec.Width, rec.Height,
+		rec.SizeBytes, rec.Thumbnail, string(chapters), rec.URL,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save recording: %v", err)
+	}
+
+	return s.Conn.QueryRow(`SELECT id, created_at FROM recordings WHERE filename = ?`, rec.Filename).
+		Scan(&rec.ID, &rec.CreatedAt)
+}
+
+// ListRecordings returns the recordings of a lesson, oldest first.
+// An empty lessonID lists every recording of the class.
+func (s *DBService) ListRecordings(className, lessonID string) ([]Recording, error) {
+	rows, err := s.Conn.Query(`
+		SELECT id, filename, class_name, lesson_id, duration_sec, width, height, size_bytes, thumbnail, chapters, url, created_at
+		FROM recordings
+		WHERE class_name = ? AND (? = '' OR lesson_id = ?)
+		ORDER BY created_at`, className, lessonID, lessonID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query recordings: %v", err)
+	}
+	defer rows.Close()
+
+	recordings := []Recording{}
+	for rows.Next() {
+		var rec Recording
+		var chapters string
+		err := rows.Scan(&rec.ID, &rec.Filename, &rec.ClassName, &rec.LessonID, &rec.DurationSec,
+			&rec.Width, &rec.Height, &rec.SizeBytes, &rec.Thumbnail, &chapters, &rec.URL, &rec.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		json.Unmarshal([]byte(chapters), &rec.Chapters)
+		recordings = append(recordings, rec)
+	}
+	return recordings, rows.Err()
+}
@@ -0,0 +1,50 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+)
+
+// GetRoster returns the student names of a class in alphabetical order
+func (s *DBService) GetRoster(className string) ([]string, error) {
+	rows, err := s.Conn.Query(`SELECT name FROM students WHERE class_name = ? ORDER BY name`, className)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query roster: %v", err)
+	}
+	defer rows.Close()
+
+	names := []string{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	return names, rows.Err()
+}
+
+// SetRoster replaces the student list of a class
+func (s *DBService) SetRoster(className string, names []string) error {
+	tx, err := s.Conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM students WHERE class_name = ?`, className); err != nil {
+		return fmt.Errorf("failed to clear roster: %v", err)
+	}
+
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if _, err := tx.Exec(`INSERT OR IGNORE INTO students (class_name, name) VALUES (?, ?)`, className, name); err != nil {
+			return fmt.Errorf("failed to insert student %s: %v", name, err)
+		}
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,110 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// TranscriptSegment is one subtitle line of a recording
+type TranscriptSegment struct {
+	Start float64 `json:"start"` // seconds
+	End   float64 `json:"end"`
+	Text  string  `json:"text"`
+}
+
+// TranscriptHit is a search result: where in which lesson a phrase was said
+type TranscriptHit struct {
+	Filename  string  `json:"filename"`
+	ClassName string  `json:"className"`
+	LessonID  string  `json:"lessonId"`
+	Start     float64 `json:"start"`
+	Text      string  `json:"text"`
+}
+
+// searchText folds text for matching. SQLite's LIKE only folds ASCII,
+// so "Türev" and "TÜREV" are lowercased here with Turkish rules (I -> ı, İ -> i).
+func searchText(text string) string {
+	return strings.ToLowerSpecial(unicode.TurkishCase, text)
+}
+
+// SaveTranscript replaces the transcript of a recording
+func (s *DBService) SaveTranscript(filename string, segments []TranscriptSegment) error {
+	tx, err := s.Conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM transcript_segments WHERE filename = ?`, filename); err != nil {
+		return fmt.Errorf("failed to clear transcript: %v", err)
+	}
+
+	stmt, err := tx.Prepare(`INSERT INTO transcript_segments (filename, start_sec, end_sec, text, search) VALUES (?, ?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, seg := range segments {
+		if _, err := stmt.Exec(filename, seg.Start, seg.End, seg.Text, searchText(seg.Text)); err != nil {
+			return fmt.Errorf("failed to save transcript: %v", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// GetTranscript returns the transcript of a recording in time order
+func (s *DBService) GetTranscript(filename string) ([]TranscriptSegment, error) {
+	rows, err := s.Conn.Query(`SELECT start_sec, end_sec, text FROM transcript_segments WHERE filename = ? ORDER BY start_sec`, filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query transcript: %v", err)
+	}
+	defer rows.Close()
+
+	segments := []TranscriptSegment{}
+	for rows.Next() {
+		var seg TranscriptSegment
+		if err := rows.Scan(&seg.Start, &seg.End, &seg.Text); err != nil {
+			return nil, err
+		}
+		segments = append(segments, seg)
+	}
+	return segments, rows.Err()
+}
+
+// SearchTranscripts finds a phrase in the transcripts of a class (empty = every class),
+// newest recordings first (rec-<timestamp> names sort by date)
+func (s *DBService) SearchTranscripts(query, className string, limit int) ([]TranscriptHit, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return []TranscriptHit{}, nil
+	}
+	if limit <= 0 {
+		limit = 100
+	}
+	escape := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+	pattern := "%" + escape.Replace(searchText(query)) + "%"
+
+	rows, err := s.Conn.Query(`
+		SELECT t.filename, COALESCE(r.class_name, ''), COALESCE(r.lesson_id, ''), t.start_sec, t.text
+		FROM transcript_segments t
+		LEFT JOIN recordings r ON r.filename = t.filename
+		WHERE t.search LIKE ? ESCAPE '\' AND (? = '' OR r.class_name = ?)
+		ORDER BY t.filename DESC, t.start_sec
+		LIMIT ?`, pattern, className, className, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search transcripts: %v", err)
+	}
+	defer rows.Close()
+
+	hits := []TranscriptHit{}
+	for rows.Next() {
+		var hit TranscriptHit
+		if err := rows.Scan(&hit.Filename, &hit.ClassName, &hit.LessonID, &hit.Start, &hit.Text); err != nil {
+			return nil, err
+		}
+		hits = append(hits, hit)
+	}
+	return hits, rows.Err()
+}
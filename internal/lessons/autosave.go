@@ -0,0 +1,288 @@
+package lessons
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"image/png"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// autosaveDelay: snapshots are written once the board has been still this long
+	autosaveDelay = 2 * time.Second
+	// autosaveMaxDelay: during continuous drawing, at most this much is at risk
+	autosaveMaxDelay = 10 * time.Second
+	// maxAutosavePages guards against a runaway page index filling the disk
+	maxAutosavePages = 500
+
+	autosaveSessionFile = "session.json"
+)
+
+// PageSnapshot is the current picture of one board page, sent after every change
+type PageSnapshot struct {
+	Index     int    `json:"index"`     // 0-based page number
+	PageCount int    `json:"pageCount"` // pages in the lesson; later ones were removed
+	Current   int    `json:"current"`   // page on the board
+	Data      string `json:"data"`      // PNG data URL
+}
+
+// AutosaveSession describes the journal of an unfinished lesson
+type AutosaveSession struct {
+	ClassName string          `json:"className"`
+	LessonID  string          `json:"lessonId"`
+	Started   time.Time       `json:"started"`
+	Updated   time.Time       `json:"updated"`
+	Current   int             `json:"current"`
+	Pages     []AutosavedPage `json:"pages"`
+}
+
+// AutosavedPage is a page in the journal; File is empty for a page never drawn on
+type AutosavedPage struct {
+	File   string `json:"file"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+}
+
+// AutosaveRecovery is an unfinished lesson restored after a crash or power cut
+type AutosaveRecovery struct {
+	Session AutosaveSession `json:"session"`
+	Pages   []string        `json:"pages"` // PNG data URLs, "" for blank pages
+}
+
+// Autosaver keeps a journal of the board in its own folder, so a power cut
+// mid-lesson loses at most the last few seconds. Snapshots are debounced and
+// every file is written atomically. The journal is removed when the lesson
+// ends normally; one found at startup belongs to a lesson that never ended.
+type Autosaver struct {
+	dir string
+
+	mu        sync.Mutex
+	session   *AutosaveSession // the lesson being journaled
+	recovered *AutosaveSession // unfinished lesson found at startup, until restored or discarded
+	pending   map[int][]byte   // page PNGs not written yet
+	since     time.Time        // oldest pending change
+	timer     *time.Timer
+	flushMu   sync.Mutex // one flush at a time
+}
+
+// NewAutosaver journals into dir and looks for an unfinished lesson there
+func NewAutosaver(dir string) *Autosaver {
+	s := &Autosaver{dir: dir, pending: map[int][]byte{}}
+	data, err := os.ReadFile(filepath.Join(dir, autosaveSessionFile))
+	if err == nil {
+		var session AutosaveSession
+		if err := json.Unmarshal(data, &session); err != nil {
+			log.Printf("Warning: unreadable autosave journal ignored: %v", err)
+		} else if len(session.Pages) > 0 {
+			s.recovered = &session
+			log.Printf("Unfinished lesson found from %s (%d pages)", session.Updated.Format("2006-01-02 15:04"), len(session.Pages))
+		}
+	}
+	return s
+}
+
+// Recovery returns the unfinished lesson found at startup, nil if there is none
+func (s *Autosaver) Recovery() *AutosaveSession {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.recovered == nil {
+		return nil
+	}
+	session := *s.recovered
+	return &session
+}
+
+// Restore loads the unfinished lesson and continues journaling it
+func (s *Autosaver) Restore() (AutosaveRecovery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.recovered == nil {
+		return AutosaveRecovery{}, fmt.Errorf("no unfinished lesson to restore")
+	}
+
+	recovery := AutosaveRecovery{Session: *s.recovered, Pages: make([]string, len(s.recovered.Pages))}
+	for i, page := range s.recovered.Pages {
+		if page.File == "" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(s.dir, page.File))
+		if err != nil {
+			log.Printf("Warning: autosaved page %d lost: %v", i+1, err)
+			continue
+		}
+		recovery.Pages[i] = pngDataURL + base64.StdEncoding.EncodeToString(data)
+	}
+	s.session, s.recovered = s.recovered, nil
+	log.Printf("Unfinished lesson restored (%d pages)", len(recovery.Pages))
+	return recovery, nil
+}
+
+// Discard deletes the unfinished lesson found at startup
+func (s *Autosaver) Discard() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.recovered == nil {
+		return nil
+	}
+	s.recovered = nil
+	log.Println("Unfinished lesson discarded")
+	return s.removeJournalLocked()
+}
+
+// Snapshot records the picture of a page. It is written after the board
+// has been still for a moment, or within autosaveMaxDelay while drawing goes on.
+func (s *Autosaver) Snapshot(snap PageSnapshot, className, lessonID string) error {
+	data, err := decodePNG(snap.Data)
+	if err != nil {
+		return err
+	}
+	if snap.Index < 0 || snap.Index >= maxAutosavePages {
+		return fmt.Errorf("invalid page %d", snap.Index)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.recovered != nil {
+		return fmt.Errorf("restore or discard the unfinished lesson first")
+	}
+	if s.session == nil {
+		s.session = &AutosaveSession{Started: time.Now()}
+	}
+	s.session.ClassName, s.session.LessonID = className, lessonID
+	s.session.Current = snap.Current
+	count := min(max(snap.PageCount, snap.Index+1), maxAutosavePages)
+	for len(s.session.Pages) < count {
+		s.session.Pages = append(s.session.Pages, AutosavedPage{})
+	}
+	s.session.Pages = s.session.Pages[:count]
+	for index := range s.pending {
+		if index >= count {
+			delete(s.pending, index)
+		}
+	}
+
+	if len(s.pending) == 0 {
+		s.since = time.Now()
+	}
+	s.pending[snap.Index] = data
+
+	delay := autosaveDelay
+	if left := autosaveMaxDelay - time.Since(s.since); left < delay {
+		delay = max(left, 0)
+	}
+	if s.timer != nil {
+		s.timer.Stop()
+	}
+	s.timer = time.AfterFunc(delay, func() {
+		if err := s.Flush(); err != nil {
+			log.Printf("Autosave failed: %v", err)
+		}
+	})
+	return nil
+}
+
+// Flush writes the pending snapshots now: page images first, then the
+// session file that points at them
+func (s *Autosaver) Flush() error {
+	s.flushMu.Lock()
+	defer s.flushMu.Unlock()
+
+	s.mu.Lock()
+	if s.session == nil || len(s.pending) == 0 {
+		s.mu.Unlock()
+		return nil
+	}
+	pending := s.pending
+	s.pending = map[int][]byte{}
+	s.mu.Unlock()
+
+	written := map[int]AutosavedPage{}
+	for index, data := range pending {
+		page := AutosavedPage{File: fmt.Sprintf("page-%03d.png", index+1)}
+		if cfg, err := png.DecodeConfig(bytes.NewReader(data)); err == nil {
+			page.Width, page.Height = cfg.Width, cfg.Height
+		}
+		if err := writeFileAtomic(filepath.Join(s.dir, page.File), data); err != nil {
+			// Keep what wasn't written for the next attempt, unless newer snapshots replaced it
+			s.mu.Lock()
+			for i, d := range pending {
+				if _, newer := s.pending[i]; !newer {
+					s.pending[i] = d
+				}
+			}
+			s.mu.Unlock()
+			return err
+		}
+		written[index] = page
+	}
+
+	s.mu.Lock()
+	if s.session == nil {
+		// Finished while writing
+		s.mu.Unlock()
+		return nil
+	}
+	for index, page := range written {
+		if index < len(s.session.Pages) {
+			s.session.Pages[index] = page
+		}
+	}
+	s.session.Updated = time.Now()
+	data, err := json.MarshalIndent(s.session, "", "  ")
+	keep := map[string]bool{autosaveSessionFile: true}
+	for _, page := range s.session.Pages {
+		keep[page.File] = true
+	}
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(filepath.Join(s.dir, autosaveSessionFile), data); err != nil {
+		return err
+	}
+
+	// Pages removed from the lesson
+	files, _ := os.ReadDir(s.dir)
+	for _, file := range files {
+		if strings.HasPrefix(file.Name(), "page-") && !keep[file.Name()] {
+			os.Remove(filepath.Join(s.dir, file.Name()))
+		}
+	}
+	return nil
+}
+
+// Finish ends the journal after the lesson was saved or shared
+func (s *Autosaver) Finish() error {
+	s.flushMu.Lock()
+	defer s.flushMu.Unlock()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.timer != nil {
+		s.timer.Stop()
+	}
+	s.pending = map[int][]byte{}
+	s.session = nil
+	return s.removeJournalLocked()
+}
+
+// removeJournalLocked deletes the session file first, so a crash halfway
+// never leaves a session pointing at missing pages. s.mu must be held.
+func (s *Autosaver) removeJournalLocked() error {
+	if err := os.Remove(filepath.Join(s.dir, autosaveSessionFile)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove autosave journal: %v", err)
+	}
+	files, _ := os.ReadDir(s.dir)
+	for _, file := range files {
+		if strings.HasPrefix(file.Name(), "page-") || strings.HasPrefix(file.Name(), ".tmp-") {
+			os.Remove(filepath.Join(s.dir, file.Name()))
+		}
+	}
+	return nil
+}
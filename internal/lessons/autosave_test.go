@@ -0,0 +1,68 @@
+package lessons
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func testPage(t *testing.T, width int) string {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, width, 10))); err != nil {
+		t.Fatal(err)
+	}
+	return pngDataURL + base64.StdEncoding.EncodeToString(buf.Bytes())
+}
+
+func TestAutosaveSurvivesRestart(t *testing.T) {
+	dir := t.TempDir()
+	s := NewAutosaver(dir)
+	if s.Recovery() != nil {
+		t.Fatal("empty folder has an unfinished lesson")
+	}
+
+	for i, width := range []int{10, 20, 30} {
+		if err := s.Snapshot(PageSnapshot{Index: i, PageCount: i + 1, Current: i, Data: testPage(t, width)}, "9-A", "ders"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The teacher went back and deleted the last page
+	if err := s.Snapshot(PageSnapshot{Index: 1, PageCount: 2, Current: 1, Data: testPage(t, 25)}, "9-A", "ders"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "page-003.png")); !os.IsNotExist(err) {
+		t.Error("deleted page is still in the journal")
+	}
+
+	// Power cut: a new process finds the journal
+	restarted := NewAutosaver(dir)
+	session := restarted.Recovery()
+	if session == nil || len(session.Pages) != 2 || session.Current != 1 || session.LessonID != "ders" {
+		t.Fatalf("recovery = %+v", session)
+	}
+	if err := restarted.Snapshot(PageSnapshot{Index: 0, PageCount: 1, Data: testPage(t, 5)}, "9-A", "ders"); err == nil {
+		t.Error("new snapshot overwrote the unfinished lesson before the teacher decided")
+	}
+	recovery, err := restarted.Restore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recovery.Pages) != 2 || recovery.Session.Pages[1].Width != 25 || recovery.Pages[1] != testPage(t, 25) {
+		t.Errorf("restored %d pages, page 2 = %+v", len(recovery.Pages), recovery.Session.Pages[1])
+	}
+
+	if err := restarted.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	if NewAutosaver(dir).Recovery() != nil {
+		t.Error("finished lesson offered for restore")
+	}
+}
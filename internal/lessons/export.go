@@ -0,0 +1,397 @@
+package lessons
+
+import (
+	"DersDostu/internal/pdf"
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Printed pages are A4 landscape, in points
+const (
+	exportPageWidth  = 842
+	exportPageHeight = 595
+	exportMargin     = 28
+	exportFooter     = 22 // room for the page number under the board
+)
+
+// ExportInfo is printed on the title page next to the lesson's own details
+type ExportInfo struct {
+	School  string
+	Teacher string
+}
+
+// ExportPDF renders a saved lesson as a PDF at path: a title page, then every
+// page with its visible layers and vector objects, numbered. Pages are drawn
+// and written one at a time, so memory use doesn't grow with the lesson.
+func (m *LessonManager) ExportPDF(id string, info ExportInfo, path string) error {
+	if m.db == nil {
+		return fmt.Errorf("database not available")
+	}
+	lesson, err := m.db.GetLesson(id)
+	if err != nil {
+		return err
+	}
+	doc, err := m.loadPages(lesson.ID)
+	if err != nil {
+		return err
+	}
+	if len(doc) == 0 {
+		return fmt.Errorf("lesson %s has no pages", id)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %v", filepath.Dir(path), err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %v", path, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	out := bufio.NewWriterSize(tmp, 256*1024)
+	w := pdf.NewWriter(out)
+	font := m.exportFont(w)
+
+	title := lesson.Title
+	if title == "" {
+		title = lesson.Subject
+	}
+	writeTitlePage(w, font, lesson.ClassName, lesson.Subject, title, lesson.Date, len(doc), info)
+	for i, page := range doc {
+		p, err := m.writePage(w, font, lesson.ID, page)
+		if err != nil {
+			tmp.Close()
+			return err
+		}
+		writeFooter(p, font, title, i+1, len(doc))
+	}
+
+	err = w.Close(pdf.Info{Title: title, Author: info.Teacher, Subject: lesson.ClassName + " " + lesson.Subject, Creator: "DersDostu"})
+	if err == nil {
+		err = out.Flush()
+	}
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %v", path, err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write %s: %v", path, err)
+	}
+	syncDir(filepath.Dir(path))
+	log.Printf("Lesson exported: %s (%d pages)", filepath.Base(path), len(doc))
+	return nil
+}
+
+// loadPages reads the page list without the layer images; those are
+// decoded one page at a time while writing
+func (m *LessonManager) loadPages(id string) ([]Page, error) {
+	stored, err := m.db.GetLessonPages(id)
+	if err != nil {
+		return nil, err
+	}
+	pages := make([]Page, 0, len(stored))
+	for _, sp := range stored {
+		page := Page{ID: sp.PageID, Width: sp.Width, Height: sp.Height, Background: sp.Background}
+		if err := json.Unmarshal([]byte(sp.Layers), &page.Layers); err != nil {
+			return nil, fmt.Errorf("page %s has invalid layers: %v", sp.PageID, err)
+		}
+		if err := json.Unmarshal([]byte(sp.Objects), &page.Objects); err != nil {
+			return nil, fmt.Errorf("page %s has invalid objects: %v", sp.PageID, err)
+		}
+		pages = append(pages, page)
+	}
+	return pages, nil
+}
+
+// exportFont embeds a font with Turkish letters: from the fonts folder of the
+// data directory or next to the executable, else a system font. Without one
+// the PDF falls back to Helvetica, which lacks only rarer letters.
+func (m *LessonManager) exportFont(w *pdf.Writer) *pdf.Font {
+	dirs := []string{m.storage.FontsDir}
+	if exe, err := os.Executable(); err == nil {
+		dirs = append(dirs, filepath.Join(filepath.Dir(exe), "fonts"))
+	}
+	if path := pdf.FindFont(dirs...); path != "" {
+		data, err := os.ReadFile(path)
+		if err == nil {
+			if font, err := w.TrueTypeFont(data); err == nil {
+				return font
+			}
+		}
+		log.Printf("Warning: font %s could not be embedded, using Helvetica", path)
+	} else {
+		log.Println("Warning: no TrueType font with Turkish letters found, using Helvetica")
+	}
+	return w.StandardFont()
+}
+
+// writeTitlePage prints the school, lesson, class, teacher, date and page count
+func writeTitlePage(w *pdf.Writer, font *pdf.Font, className, subject, title, date string, pages int, info ExportInfo) {
+	p := w.NewPage(exportPageWidth, exportPageHeight)
+	gray := color.Gray{Y: 0x66}
+	black := color.Gray{}
+
+	centered := func(text string, size, y float64, c color.Color) {
+		size = fitText(font, text, size, exportPageWidth-4*exportMargin)
+		p.SetFillColor(c)
+		p.Text(font, size, (exportPageWidth-font.Width(text, size))/2, y, text)
+	}
+
+	if info.School != "" {
+		centered(info.School, 20, exportPageHeight-90, gray)
+	}
+	centered(title, 36, exportPageHeight/2+60, black)
+	if subject != "" && subject != title {
+		centered(subject, 22, exportPageHeight/2+24, gray)
+	}
+
+	var details []string
+	if className != "" {
+		details = append(details, "Sınıf: "+className)
+	}
+	if info.Teacher != "" {
+		details = append(details, "Öğretmen: "+info.Teacher)
+	}
+	if t, err := time.Parse("2006-01-02", date); err == nil {
+		details = append(details, "Tarih: "+t.Format("02.01.2006"))
+	}
+	details = append(details, fmt.Sprintf("Sayfa sayısı: %d", pages))
+	for i, line := range details {
+		centered(line, 16, exportPageHeight/2-40-float64(i)*26, black)
+	}
+}
+
+// writeFooter numbers a board page
+func writeFooter(p *pdf.Page, font *pdf.Font, title string, number, total int) {
+	p.SetFillColor(color.Gray{Y: 0x88})
+	label := fmt.Sprintf("Sayfa %d / %d", number, total)
+	p.Text(font, 10, exportPageWidth-exportMargin-font.Width(label, 10), exportMargin/2, label)
+	title = truncateText(font, title, 10, exportPageWidth/2)
+	p.Text(font, 10, exportMargin, exportMargin/2, title)
+}
+
+// writePage draws a board page scaled into the printable area: the layers
+// merged into one image, then the vector objects on top, kept sharp
+func (m *LessonManager) writePage(w *pdf.Writer, font *pdf.Font, lessonID string, page Page) (*pdf.Page, error) {
+	board := m.flattenLayers(lessonID, page)
+	width, height := float64(board.Bounds().Dx()), float64(board.Bounds().Dy())
+
+	availW := float64(exportPageWidth - 2*exportMargin)
+	availH := float64(exportPageHeight - 2*exportMargin - exportFooter)
+	scale := math.Min(availW/width, availH/height)
+	left := (exportPageWidth - width*scale) / 2
+	top := float64(exportMargin) + (availH-height*scale)/2
+
+	img := w.AddImage(board)
+	p := w.NewPage(exportPageWidth, exportPageHeight)
+	p.SaveState()
+	// Board pixels from the top left, y pointing down
+	p.Transform(scale, 0, 0, -scale, left, exportPageHeight-top)
+	p.Rectangle(0, 0, width, height)
+	p.Clip()
+	p.DrawImage(img, 0, height, width, -height)
+	for _, obj := range page.Objects {
+		drawObject(p, font, obj)
+	}
+	p.RestoreState()
+
+	p.SetStrokeColor(color.Gray{Y: 0xcc})
+	p.SetLineWidth(0.5)
+	p.Rectangle(left, exportPageHeight-top-height*scale, width*scale, height*scale)
+	p.Stroke()
+	return p, nil
+}
+
+// flattenLayers paints the visible layers over the page background
+func (m *LessonManager) flattenLayers(lessonID string, page Page) *image.RGBA {
+	type layerImage struct {
+		img     image.Image
+		opacity float64
+	}
+	var layers []layerImage
+	width, height := page.Width, page.Height
+	for _, layer := range page.Layers {
+		if !layer.Visible || layer.Opacity <= 0 || !safeID.MatchString(layer.Name) {
+			continue
+		}
+		img, err := decodePNGFile(m.layerPath(lessonID, page.ID, layer.Name))
+		if err != nil {
+			// A lost image leaves the layer out rather than failing the export
+			log.Printf("Warning: layer %s of page %s skipped: %v", layer.Name, page.ID, err)
+			continue
+		}
+		layers = append(layers, layerImage{img, math.Min(layer.Opacity, 1)})
+		if width <= 0 || height <= 0 {
+			width, height = img.Bounds().Dx(), img.Bounds().Dy()
+		}
+	}
+	if width <= 0 || height <= 0 {
+		width, height = 1920, 1080
+	}
+	width, height = min(width, maxPageSize), min(height, maxPageSize)
+
+	board := image.NewRGBA(image.Rect(0, 0, width, height))
+	background, ok := parseColor(page.Background)
+	if !ok {
+		background = color.White
+	}
+	draw.Draw(board, board.Bounds(), image.NewUniform(background), image.Point{}, draw.Src)
+	for _, layer := range layers {
+		var mask image.Image
+		if layer.opacity < 1 {
+			mask = image.NewUniform(color.Alpha{A: uint8(layer.opacity * 255)})
+		}
+		draw.DrawMask(board, board.Bounds(), layer.img, layer.img.Bounds().Min, mask, image.Point{}, draw.Over)
+	}
+	return board
+}
+
+// drawObject draws a vector object in board pixels
+func drawObject(p *pdf.Page, font *pdf.Font, obj VectorObject) {
+	c, ok := parseColor(obj.Color)
+	if !ok {
+		c = color.Black
+	}
+	width := obj.Width
+	if width <= 0 {
+		width = 2
+	}
+	pts := obj.Points
+
+	p.SaveState()
+	defer p.RestoreState()
+	p.SetStrokeColor(c)
+	p.SetFillColor(c)
+	p.SetLineWidth(width)
+	p.SetRoundLines()
+
+	switch obj.Type {
+	case ObjectStroke:
+		if len(pts) == 0 {
+			return
+		}
+		if len(pts) == 1 {
+			// A tap of the pen leaves a dot
+			p.Ellipse(pts[0].X-width/2, pts[0].Y-width/2, width, width)
+			p.Fill()
+			return
+		}
+		p.MoveTo(pts[0].X, pts[0].Y)
+		for _, pt := range pts[1:] {
+			p.LineTo(pt.X, pt.Y)
+		}
+		p.Stroke()
+
+	case ObjectShape:
+		if len(pts) < 2 {
+			return
+		}
+		a, b := pts[0], pts[len(pts)-1]
+		x, y := math.Min(a.X, b.X), math.Min(a.Y, b.Y)
+		w, h := math.Abs(b.X-a.X), math.Abs(b.Y-a.Y)
+		switch obj.Shape {
+		case "rect":
+			p.Rectangle(x, y, w, h)
+		case "ellipse":
+			p.Ellipse(x, y, w, h)
+		default: // line, arrow
+			p.MoveTo(a.X, a.Y)
+			p.LineTo(b.X, b.Y)
+			if obj.Shape == "arrow" {
+				head := math.Max(12, 4*width)
+				angle := math.Atan2(b.Y-a.Y, b.X-a.X)
+				for _, side := range []float64{-0.45, 0.45} {
+					p.MoveTo(b.X, b.Y)
+					p.LineTo(b.X-head*math.Cos(angle+side), b.Y-head*math.Sin(angle+side))
+				}
+			}
+			p.Stroke()
+			return
+		}
+		if fill, ok := parseColor(obj.Fill); ok {
+			p.SetFillColor(fill)
+			p.FillStroke()
+		} else {
+			p.Stroke()
+		}
+
+	case ObjectText:
+		if len(pts) == 0 || obj.Text == "" {
+			return
+		}
+		size := obj.FontSize
+		if size <= 0 {
+			size = 24
+		}
+		for i, line := range strings.Split(obj.Text, "\n") {
+			baseline := pts[0].Y + font.Ascent(size) + float64(i)*size*1.2
+			p.SaveState()
+			// Undo the page's downward y axis so letters stand upright
+			p.Transform(1, 0, 0, -1, pts[0].X, baseline)
+			p.Text(font, size, 0, 0, line)
+			p.RestoreState()
+		}
+	}
+}
+
+// fitText shrinks the size until the text fits the width, down to 60%
+func fitText(font *pdf.Font, text string, size, width float64) float64 {
+	if w := font.Width(text, size); w > width {
+		return math.Max(size*width/w, size*0.6)
+	}
+	return size
+}
+
+// truncateText shortens the text with "..." to fit the width
+func truncateText(font *pdf.Font, text string, size, width float64) string {
+	if font.Width(text, size) <= width {
+		return text
+	}
+	runes := []rune(text)
+	for len(runes) > 0 && font.Width(string(runes)+"...", size) > width {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + "..."
+}
+
+// parseColor reads a CSS hex color: #rgb or #rrggbb
+func parseColor(s string) (color.Color, bool) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "#")
+	if len(s) == 3 {
+		s = string([]byte{s[0], s[0], s[1], s[1], s[2], s[2]})
+	}
+	if len(s) != 6 {
+		return nil, false
+	}
+	v, err := strconv.ParseUint(s, 16, 32)
+	if err != nil {
+		return nil, false
+	}
+	return color.RGBA{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v), A: 0xff}, true
+}
+
+func decodePNGFile(path string) (image.Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return png.Decode(f)
+}
@@ -0,0 +1,392 @@
+package lessons
+
+import (
+	"DersDostu/internal/db"
+	"DersDostu/internal/storage"
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"image/png"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Vector object types
+const (
+	ObjectStroke = "stroke" // freehand pen path
+	ObjectShape  = "shape"  // line, rect, ellipse or arrow between two points
+	ObjectText   = "text"
+)
+
+// Document is a lesson with its pages, as the frontend opens and saves it
+type Document struct {
+	Lesson db.Lesson `json:"lesson"`
+	Pages  []Page    `json:"pages"`
+}
+
+// Page is one board page: raster layers painted over each other in order,
+// then the vector objects on top
+type Page struct {
+	ID         string         `json:"id"` // stable; empty for a new page
+	Width      int            `json:"width"`
+	Height     int            `json:"height"`
+	Background string         `json:"background,omitempty"` // CSS color, empty = white
+	Layers     []Layer        `json:"layers"`
+	Objects    []VectorObject `json:"objects"`
+}
+
+// Layer is a raster layer stored as a PNG in the lesson folder
+type Layer struct {
+	Name    string  `json:"name"` // e.g. "ink"; unique within the page
+	Visible bool    `json:"visible"`
+	Opacity float64 `json:"opacity"` // 0..1
+	// Data is a PNG data URL. Open fills it in; on Save an empty Data keeps
+	// the stored image, so unchanged layers needn't be sent again.
+	Data string `json:"data,omitempty"`
+}
+
+// VectorObject is a vector object in page pixels. Not named Object, which
+// would shadow the global Object in the generated TypeScript models.
+type VectorObject struct {
+	ID       string  `json:"id"`
+	Type     string  `json:"type"`            // ObjectStroke, ObjectShape or ObjectText
+	Color    string  `json:"color,omitempty"` // #rrggbb
+	Width    float64 `json:"width,omitempty"` // line width
+	Points   []Point `json:"points,omitempty"`
+	Shape    string  `json:"shape,omitempty"` // line, rect, ellipse, arrow
+	Fill     string  `json:"fill,omitempty"`  // shape fill color, empty = none
+	Text     string  `json:"text,omitempty"`
+	FontSize float64 `json:"fontSize,omitempty"` // text starts at Points[0], its top left corner
+}
+
+// Point is a position on the page
+type Point struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+// maxPageSize bounds pages and layer images, in pixels. The PDF export
+// allocates a full-size image per page, which a bogus size must not blow up.
+const maxPageSize = 8192
+
+// safeID: page IDs and layer names become file names
+var safeID = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+const pngDataURL = "data:image/png;base64,"
+
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// LessonManager stores lesson documents: metadata and pages in SQLite, raster
+// layers as PNG files under <lessons>/<lesson ID>/pages/<page ID>/<layer>.png
+type LessonManager struct {
+	db      *db.DBService // nil when the database failed to open
+	storage *storage.StorageManager
+	mu      sync.Mutex // serializes saves, so two never interleave their files
+}
+
+// NewLessonManager creates a manager
+func NewLessonManager(database *db.DBService, sm *storage.StorageManager) *LessonManager {
+	return &LessonManager{db: database, storage: sm}
+}
+
+// Create starts a new, empty lesson for today. The ID follows App.SetCurrentLesson
+// (date-class-title), with a number appended if the class already has that lesson today.
+func (m *LessonManager) Create(className, subject, title string) (db.Lesson, error) {
+	if m.db == nil {
+		return db.Lesson{}, fmt.Errorf("database not available")
+	}
+	if strings.TrimSpace(title) == "" {
+		title = subject
+	}
+	date := time.Now().Format("2006-01-02")
+	base := fmt.Sprintf("%s-%s-%s", date, storage.SanitizeName(className), storage.SanitizeName(title))
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	id := base
+	for n := 2; ; n++ {
+		exists, err := m.db.LessonExists(id)
+		if err != nil {
+			return db.Lesson{}, fmt.Errorf("failed to check lesson ID: %v", err)
+		}
+		if !exists {
+			break
+		}
+		id = fmt.Sprintf("%s-%d", base, n)
+	}
+
+	lesson := db.Lesson{
+		ID:        id,
+		ClassName: strings.TrimSpace(className),
+		Subject:   strings.TrimSpace(subject),
+		Title:     strings.TrimSpace(title),
+		Date:      date,
+	}
+	if err := m.db.CreateLesson(&lesson); err != nil {
+		return lesson, err
+	}
+	log.Printf("Lesson created: %s", id)
+	return lesson, nil
+}
+
+// List returns the lessons of a class (empty = every class), newest first
+func (m *LessonManager) List(className string) ([]db.Lesson, error) {
+	if m.db == nil {
+		return nil, fmt.Errorf("database not available")
+	}
+	return m.db.ListLessons(className)
+}
+
+// Open loads a lesson with every page and layer image
+func (m *LessonManager) Open(id string) (Document, error) {
+	if m.db == nil {
+		return Document{}, fmt.Errorf("database not available")
+	}
+	lesson, err := m.db.GetLesson(id)
+	if err != nil {
+		return Document{}, err
+	}
+	stored, err := m.db.GetLessonPages(id)
+	if err != nil {
+		return Document{}, err
+	}
+
+	doc := Document{Lesson: lesson, Pages: []Page{}}
+	for _, sp := range stored {
+		page := Page{ID: sp.PageID, Width: sp.Width, Height: sp.Height, Background: sp.Background}
+		if err := json.Unmarshal([]byte(sp.Layers), &page.Layers); err != nil {
+			return doc, fmt.Errorf("page %s has invalid layers: %v", sp.PageID, err)
+		}
+		if err := json.Unmarshal([]byte(sp.Objects), &page.Objects); err != nil {
+			return doc, fmt.Errorf("page %s has invalid objects: %v", sp.PageID, err)
+		}
+		for i := range page.Layers {
+			data, err := os.ReadFile(m.layerPath(id, page.ID, page.Layers[i].Name))
+			if err != nil {
+				// A lost image leaves an empty layer rather than losing the whole lesson
+				log.Printf("Warning: layer %s of page %s missing: %v", page.Layers[i].Name, page.ID, err)
+				continue
+			}
+			page.Layers[i].Data = pngDataURL + base64.StdEncoding.EncodeToString(data)
+		}
+		doc.Pages = append(doc.Pages, page)
+	}
+	return doc, nil
+}
+
+// Save stores the pages of a lesson in the given order. New pages get an ID;
+// pages and layers missing from doc are deleted. Returns the document with
+// IDs assigned and layer data removed.
+func (m *LessonManager) Save(doc Document) (Document, error) {
+	if m.db == nil {
+		return doc, fmt.Errorf("database not available")
+	}
+	id := doc.Lesson.ID
+	if _, err := m.db.GetLesson(id); err != nil {
+		return doc, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	pagesDir := filepath.Join(m.storage.LessonsDir, id, "pages")
+	stored := make([]db.LessonPage, 0, len(doc.Pages))
+	keep := map[string]map[string]bool{} // page ID -> layer file names
+	for i := range doc.Pages {
+		page := &doc.Pages[i]
+		if page.ID == "" {
+			page.ID = newID()
+		}
+		if !safeID.MatchString(page.ID) {
+			return doc, fmt.Errorf("invalid page ID %q", page.ID)
+		}
+		if keep[page.ID] != nil {
+			return doc, fmt.Errorf("page %s appears twice", page.ID)
+		}
+		keep[page.ID] = map[string]bool{}
+		if page.Width < 0 || page.Height < 0 || page.Width > maxPageSize || page.Height > maxPageSize {
+			return doc, fmt.Errorf("page %s is %dx%d pixels, at most %dx%d are supported", page.ID, page.Width, page.Height, maxPageSize, maxPageSize)
+		}
+
+		for j := range page.Layers {
+			layer := &page.Layers[j]
+			if !safeID.MatchString(layer.Name) {
+				return doc, fmt.Errorf("invalid layer name %q on page %s", layer.Name, page.ID)
+			}
+			if keep[page.ID][layer.Name+".png"] {
+				return doc, fmt.Errorf("layer %s appears twice on page %s", layer.Name, page.ID)
+			}
+			keep[page.ID][layer.Name+".png"] = true
+			if layer.Data == "" {
+				continue
+			}
+			png, err := decodePNG(layer.Data)
+			if err != nil {
+				return doc, fmt.Errorf("layer %s of page %s: %v", layer.Name, page.ID, err)
+			}
+			if err := writeFileAtomic(m.layerPath(id, page.ID, layer.Name), png); err != nil {
+				return doc, err
+			}
+			layer.Data = ""
+		}
+		if page.Objects == nil {
+			page.Objects = []VectorObject{}
+		}
+		if page.Layers == nil {
+			page.Layers = []Layer{}
+		}
+
+		layers, _ := json.Marshal(page.Layers)
+		objects, _ := json.Marshal(page.Objects)
+		stored = append(stored, db.LessonPage{
+			PageID:     page.ID,
+			Position:   i,
+			Width:      page.Width,
+			Height:     page.Height,
+			Background: page.Background,
+			Layers:     string(layers),
+			Objects:    string(objects),
+		})
+	}
+
+	if err := m.db.SaveLessonPages(id, stored); err != nil {
+		return doc, err
+	}
+	removeStale(pagesDir, keep)
+
+	if lesson, err := m.db.GetLesson(id); err == nil {
+		doc.Lesson = lesson
+	}
+	return doc, nil
+}
+
+// Delete removes a lesson and its pages. Student submissions in the lesson
+// folder, recordings and captions stay.
+func (m *LessonManager) Delete(id string) error {
+	if m.db == nil {
+		return fmt.Errorf("database not available")
+	}
+	if id == "" || strings.ContainsAny(id, `/\`) || id == "." || id == ".." {
+		return fmt.Errorf("invalid lesson ID %q", id)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.db.DeleteLesson(id); err != nil {
+		return err
+	}
+	if err := os.RemoveAll(filepath.Join(m.storage.LessonsDir, id, "pages")); err != nil {
+		return fmt.Errorf("failed to delete lesson pages: %v", err)
+	}
+	log.Printf("Lesson deleted: %s", id)
+	return nil
+}
+
+// LayerPath returns the PNG of a raster layer
+func (m *LessonManager) LayerPath(lessonID, pageID, layer string) (string, error) {
+	if !safeID.MatchString(pageID) || !safeID.MatchString(layer) {
+		return "", fmt.Errorf("invalid page or layer")
+	}
+	return m.layerPath(lessonID, pageID, layer), nil
+}
+
+func (m *LessonManager) layerPath(lessonID, pageID, layer string) string {
+	return filepath.Join(m.storage.LessonsDir, lessonID, "pages", pageID, layer+".png")
+}
+
+// removeStale deletes the files of pages and layers no longer in the lesson
+func removeStale(pagesDir string, keep map[string]map[string]bool) {
+	pages, err := os.ReadDir(pagesDir)
+	if err != nil {
+		return
+	}
+	for _, page := range pages {
+		dir := filepath.Join(pagesDir, page.Name())
+		layers, ok := keep[page.Name()]
+		if !ok {
+			os.RemoveAll(dir)
+			continue
+		}
+		files, _ := os.ReadDir(dir)
+		for _, file := range files {
+			if !layers[file.Name()] {
+				os.Remove(filepath.Join(dir, file.Name()))
+			}
+		}
+	}
+}
+
+// decodePNG accepts a PNG data URL, as canvas.toDataURL() produces
+func decodePNG(dataURL string) ([]byte, error) {
+	if !strings.HasPrefix(dataURL, pngDataURL) {
+		return nil, fmt.Errorf("not a PNG data URL")
+	}
+	data, err := base64.StdEncoding.DecodeString(dataURL[len(pngDataURL):])
+	if err != nil {
+		return nil, fmt.Errorf("invalid image data: %v", err)
+	}
+	if !bytes.HasPrefix(data, pngSignature) {
+		return nil, fmt.Errorf("not a PNG image")
+	}
+	cfg, err := png.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("invalid PNG image: %v", err)
+	}
+	if cfg.Width > maxPageSize || cfg.Height > maxPageSize {
+		return nil, fmt.Errorf("image of %dx%d pixels is too large", cfg.Width, cfg.Height)
+	}
+	return data, nil
+}
+
+// writeFileAtomic writes via a synced temp file and a rename, so a power cut
+// leaves either the old or the new file, never half of one
+func writeFileAtomic(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %v", filepath.Dir(path), err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %v", path, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write %s: %v", path, err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write %s: %v", path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %v", path, err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write %s: %v", path, err)
+	}
+	syncDir(filepath.Dir(path))
+	return nil
+}
+
+// syncDir makes a rename in dir durable. Windows can't sync directories;
+// NTFS journals the rename itself.
+func syncDir(dir string) {
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+}
+
+func newID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
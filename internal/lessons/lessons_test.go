@@ -0,0 +1,157 @@
+package lessons
+
+import (
+	"DersDostu/internal/db"
+	"DersDostu/internal/storage"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func newTestManager(t *testing.T) *LessonManager {
+	t.Helper()
+	dir := t.TempDir()
+	database, err := db.NewDBService(filepath.Join(dir, "dersdostu.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { database.Conn.Close() })
+	return NewLessonManager(database, &storage.StorageManager{LessonsDir: filepath.Join(dir, "lessons")})
+}
+
+func TestLessonSurvivesSaveAndOpen(t *testing.T) {
+	m := newTestManager(t)
+	lesson, err := m.Create("9-A", "Matematik", "Türev")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	objects := []VectorObject{
+		{ID: "s1", Type: ObjectStroke, Color: "#ff0000", Width: 4, Points: []Point{{10, 10}, {20, 25.5}, {30, 12}}},
+		{ID: "r1", Type: ObjectShape, Shape: "rect", Color: "#0000ff", Fill: "#ffd700", Width: 2, Points: []Point{{5, 5}, {50, 40}}},
+		{ID: "t1", Type: ObjectText, Color: "#000000", Text: "f'(x) = 2x · ğüşıöç", FontSize: 24, Points: []Point{{100, 100}}},
+	}
+	doc := Document{Lesson: lesson, Pages: []Page{
+		{Width: 20, Height: 10, Background: "#fffbe6", Layers: []Layer{
+			{Name: "background", Visible: true, Opacity: 1, Data: testPage(t, 20)},
+			{Name: "ink", Visible: false, Opacity: 0.5, Data: testPage(t, 30)},
+		}, Objects: objects},
+		{Width: 20, Height: 10, Layers: []Layer{{Name: "ink", Visible: true, Opacity: 1, Data: testPage(t, 40)}}},
+	}}
+	saved, err := m.Save(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.Pages[0].ID == "" || saved.Pages[1].ID == "" || saved.Pages[0].Layers[0].Data != "" {
+		t.Errorf("save didn't assign IDs or strip layer data: %+v", saved.Pages)
+	}
+	if saved.Lesson.PageCount != 2 {
+		t.Errorf("page count = %d", saved.Lesson.PageCount)
+	}
+
+	opened, err := m.Open(lesson.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opened.Lesson.Title != "Türev" || len(opened.Pages) != 2 {
+		t.Fatalf("opened %+v", opened)
+	}
+	first := opened.Pages[0]
+	if first.ID != saved.Pages[0].ID || first.Background != "#fffbe6" || first.Width != 20 {
+		t.Errorf("page 1 = %+v", first)
+	}
+	if !reflect.DeepEqual(first.Objects, objects) {
+		t.Errorf("objects = %+v, want %+v", first.Objects, objects)
+	}
+	wantLayers := []Layer{
+		{Name: "background", Visible: true, Opacity: 1, Data: testPage(t, 20)},
+		{Name: "ink", Visible: false, Opacity: 0.5, Data: testPage(t, 30)},
+	}
+	if !reflect.DeepEqual(first.Layers, wantLayers) {
+		t.Error("layers of page 1 changed")
+	}
+	if opened.Pages[1].Layers[0].Data != testPage(t, 40) || len(opened.Pages[1].Objects) != 0 {
+		t.Errorf("page 2 = %+v", opened.Pages[1])
+	}
+
+	// Reordering, dropping a page and keeping an unchanged layer without its data
+	opened.Pages[1].Layers[0].Data = ""
+	opened.Pages = opened.Pages[1:]
+	if _, err := m.Save(opened); err != nil {
+		t.Fatal(err)
+	}
+	again, err := m.Open(lesson.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(again.Pages) != 1 || again.Pages[0].Layers[0].Data != testPage(t, 40) {
+		t.Errorf("after removing page 1: %+v", again.Pages)
+	}
+	if _, err := os.Stat(filepath.Join(m.storage.LessonsDir, lesson.ID, "pages", first.ID)); !os.IsNotExist(err) {
+		t.Error("files of the removed page are left behind")
+	}
+}
+
+func TestDamagedLessonFiles(t *testing.T) {
+	m := newTestManager(t)
+	if _, err := m.Open("2026-01-01-9-A-yok"); err == nil {
+		t.Error("opened a lesson that doesn't exist")
+	}
+
+	lesson, err := m.Create("9-A", "Fizik", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bad := Document{Lesson: lesson, Pages: []Page{{Width: 10, Height: 10, Layers: []Layer{{Name: "ink", Visible: true, Opacity: 1, Data: "data:image/png;base64,bm90IGEgcG5n"}}}}}
+	if _, err := m.Save(bad); err == nil {
+		t.Error("saved a layer that isn't a PNG")
+	}
+	for _, name := range []string{"../ink", ""} {
+		bad.Pages[0].Layers[0] = Layer{Name: name, Data: testPage(t, 10)}
+		if _, err := m.Save(bad); err == nil {
+			t.Errorf("saved a layer named %q", name)
+		}
+	}
+	// The export would allocate the full page
+	bad.Pages[0].Layers = nil
+	for _, size := range [][2]int{{100000, 100000}, {-1, 10}, {1920, maxPageSize + 1}} {
+		bad.Pages[0].Width, bad.Pages[0].Height = size[0], size[1]
+		if _, err := m.Save(bad); err == nil {
+			t.Errorf("saved a page of %dx%d", size[0], size[1])
+		}
+	}
+	bad.Pages[0].Layers = []Layer{{Name: "ink", Visible: true, Opacity: 1, Data: testPage(t, maxPageSize+1)}}
+	bad.Pages[0].Width, bad.Pages[0].Height = 0, 0
+	if _, err := m.Save(bad); err == nil {
+		t.Error("saved a layer image wider than a page may be")
+	}
+
+	saved, err := m.Save(Document{Lesson: lesson, Pages: []Page{{Width: 10, Height: 10, Layers: []Layer{
+		{Name: "ink", Visible: true, Opacity: 1, Data: testPage(t, 10)},
+	}}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pageID := saved.Pages[0].ID
+
+	// A lost layer image leaves the page open with an empty layer
+	if err := os.Remove(filepath.Join(m.storage.LessonsDir, lesson.ID, "pages", pageID, "ink.png")); err != nil {
+		t.Fatal(err)
+	}
+	doc, err := m.Open(lesson.ID)
+	if err != nil {
+		t.Fatalf("lesson with a missing layer file didn't open: %v", err)
+	}
+	if len(doc.Pages) != 1 || doc.Pages[0].Layers[0].Data != "" {
+		t.Errorf("missing layer = %+v", doc.Pages[0].Layers)
+	}
+
+	// Damaged page records are reported, not half loaded
+	if _, err := m.db.Conn.Exec(`UPDATE lesson_pages SET objects = '[{"id":' WHERE page_id = ?`, pageID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Open(lesson.ID); err == nil {
+		t.Error("opened a page with damaged objects")
+	}
+}
@@ -0,0 +1,274 @@
+package pdf
+
+import (
+	"fmt"
+	"hash/fnv"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// turkishLetters must all be in a font for it to be used
+const turkishLetters = "çğıöşüÇĞİÖŞÜ"
+
+// Font is a font used in one document. TrueType fonts are embedded with only
+// the glyphs used; the fallback is the viewer's Helvetica.
+type Font struct {
+	id   int
+	tt   *trueType       // nil for Helvetica
+	used map[uint16]rune // glyphs drawn, with the letter each stands for
+}
+
+// TrueTypeFont embeds a TrueType font (.ttf) into the document
+func (w *Writer) TrueTypeFont(data []byte) (*Font, error) {
+	tt, err := parseTrueType(data)
+	if err != nil {
+		return nil, err
+	}
+	f := &Font{id: w.newObject(), tt: tt, used: map[uint16]rune{}}
+	w.fonts = append(w.fonts, f)
+	return f, nil
+}
+
+// StandardFont returns Helvetica, which every viewer has. Turkish letters
+// outside WinAnsi are mapped to its glyph names; other scripts show as "?".
+func (w *Writer) StandardFont() *Font {
+	f := &Font{id: w.newObject()}
+	w.fonts = append(w.fonts, f)
+	return f
+}
+
+// FindFont returns the first TrueType font with Turkish letters: *.ttf files
+// in dirs, then the usual system fonts. Empty if there is none.
+func FindFont(dirs ...string) string {
+	var candidates []string
+	for _, dir := range dirs {
+		if dir == "" {
+			continue
+		}
+		files, _ := filepath.Glob(filepath.Join(dir, "*.ttf"))
+		sort.Strings(files)
+		candidates = append(candidates, files...)
+	}
+	switch runtime.GOOS {
+	case "windows":
+		fonts := filepath.Join(os.Getenv("WINDIR"), "Fonts")
+		for _, name := range []string{"segoeui.ttf", "arial.ttf", "calibri.ttf", "tahoma.ttf"} {
+			candidates = append(candidates, filepath.Join(fonts, name))
+		}
+	case "darwin":
+		candidates = append(candidates, "/System/Library/Fonts/Supplemental/Arial.ttf", "/Library/Fonts/Arial.ttf")
+	default:
+		candidates = append(candidates,
+			"/usr/share/fonts/truetype/dejavu/DejaVuSans.ttf",
+			"/usr/share/fonts/TTF/DejaVuSans.ttf",
+			"/usr/share/fonts/truetype/noto/NotoSans-Regular.ttf",
+			"/usr/share/fonts/truetype/liberation/LiberationSans-Regular.ttf",
+		)
+	}
+
+	for _, path := range candidates {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		tt, err := parseTrueType(data)
+		if err != nil {
+			log.Printf("Font %s skipped: %v", filepath.Base(path), err)
+			continue
+		}
+		if tt.covers(turkishLetters) {
+			return path
+		}
+	}
+	return ""
+}
+
+// Width returns the width of a line of text at the given size
+func (f *Font) Width(text string, size float64) float64 {
+	total := 0.0
+	for _, r := range text {
+		if f.tt == nil {
+			total += float64(helveticaWidth(r))
+			continue
+		}
+		total += float64(f.tt.advance(f.tt.glyph(r))) * 1000 / float64(f.tt.unitsPerEm)
+	}
+	return total * size / 1000
+}
+
+// Ascent returns how far letters reach above the baseline at the given size
+func (f *Font) Ascent(size float64) float64 {
+	if f.tt == nil {
+		return 718 * size / 1000
+	}
+	return float64(f.tt.ascent) * size / float64(f.tt.unitsPerEm)
+}
+
+// encode returns the bytes of a text string: glyph IDs for TrueType, single
+// byte codes for Helvetica
+func (f *Font) encode(text string) []byte {
+	var out []byte
+	for _, r := range text {
+		if f.tt == nil {
+			out = append(out, helveticaCode(r))
+			continue
+		}
+		g := f.tt.glyph(r)
+		if _, ok := f.tt.cmap[r]; !ok {
+			r = '?'
+		}
+		f.used[g] = r
+		out = append(out, byte(g>>8), byte(g))
+	}
+	return out
+}
+
+// write adds the font objects at the end of the document
+func (f *Font) write(w *Writer) {
+	if f.tt == nil {
+		w.writeObject(f.id, "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding << /Type /Encoding /BaseEncoding /WinAnsiEncoding /Differences [128 /Gbreve /gbreve /Scedilla /scedilla /Idotaccent /dotlessi] >> >>")
+		return
+	}
+
+	tt := f.tt
+	glyphs := make([]int, 0, len(f.used))
+	for g := range f.used {
+		glyphs = append(glyphs, int(g))
+	}
+	sort.Ints(glyphs)
+
+	// The subset tag is derived from the glyphs, so equal subsets get equal names
+	h := fnv.New32a()
+	for _, g := range glyphs {
+		h.Write([]byte{byte(g >> 8), byte(g)})
+	}
+	tag := make([]byte, 6)
+	sum := h.Sum32()
+	for i := range tag {
+		tag[i] = 'A' + byte(sum%26)
+		sum /= 26
+	}
+	name := string(tag) + "+" + tt.name
+
+	scale := func(v int) int { return v * 1000 / int(tt.unitsPerEm) }
+	var widths strings.Builder
+	for _, g := range glyphs {
+		fmt.Fprintf(&widths, "%d [%d] ", g, scale(int(tt.advance(uint16(g)))))
+	}
+
+	keep := map[uint16]bool{}
+	for g := range f.used {
+		keep[g] = true
+	}
+	file := tt.subset(keep)
+
+	cid, descriptor, fontFile, toUnicode := w.newObject(), w.newObject(), w.newObject(), w.newObject()
+	w.writeObject(f.id, fmt.Sprintf("<< /Type /Font /Subtype /Type0 /BaseFont /%s /Encoding /Identity-H /DescendantFonts [%d 0 R] /ToUnicode %d 0 R >>", name, cid, toUnicode))
+	w.writeObject(cid, fmt.Sprintf("<< /Type /Font /Subtype /CIDFontType2 /BaseFont /%s /CIDSystemInfo << /Registry (Adobe) /Ordering (Identity) /Supplement 0 >> /FontDescriptor %d 0 R /W [%s] /CIDToGIDMap /Identity >>",
+		name, descriptor, strings.TrimSpace(widths.String())))
+	w.writeObject(descriptor, fmt.Sprintf("<< /Type /FontDescriptor /FontName /%s /Flags 32 /FontBBox [%d %d %d %d] /ItalicAngle 0 /Ascent %d /Descent %d /CapHeight %d /StemV 80 /FontFile2 %d 0 R >>",
+		name, scale(int(tt.bbox[0])), scale(int(tt.bbox[1])), scale(int(tt.bbox[2])), scale(int(tt.bbox[3])),
+		scale(int(tt.ascent)), scale(int(tt.descent)), scale(int(tt.capHeight)), fontFile))
+	w.writeCompressed(fontFile, fmt.Sprintf(" /Length1 %d", len(file)), file)
+	w.writeCompressed(toUnicode, "", toUnicodeCMap(f.used, glyphs))
+}
+
+// toUnicodeCMap lets viewers copy and search the text
+func toUnicodeCMap(used map[uint16]rune, glyphs []int) []byte {
+	var b strings.Builder
+	b.WriteString("/CIDInit /ProcSet findresource begin\n12 dict begin\nbegincmap\n")
+	b.WriteString("/CIDSystemInfo << /Registry (Adobe) /Ordering (UCS) /Supplement 0 >> def\n")
+	b.WriteString("/CMapName /Adobe-Identity-UCS def\n/CMapType 2 def\n")
+	b.WriteString("1 begincodespacerange\n<0000> <FFFF>\nendcodespacerange\n")
+	for start := 0; start < len(glyphs); start += 100 {
+		end := min(start+100, len(glyphs))
+		fmt.Fprintf(&b, "%d beginbfchar\n", end-start)
+		for _, g := range glyphs[start:end] {
+			fmt.Fprintf(&b, "<%04X> <%s>\n", g, utf16Hex(used[uint16(g)]))
+		}
+		b.WriteString("endbfchar\n")
+	}
+	b.WriteString("endcmap\nCMapName currentdict /CMap defineresource pop\nend\nend\n")
+	return []byte(b.String())
+}
+
+func utf16Hex(r rune) string {
+	if r < 0x10000 {
+		return fmt.Sprintf("%04X", r)
+	}
+	r -= 0x10000
+	return fmt.Sprintf("%04X%04X", 0xd800+(r>>10), 0xdc00+(r&0x3ff))
+}
+
+// helveticaCode maps a letter to WinAnsi, with the Turkish letters WinAnsi
+// lacks at 128-133 (see the Differences array in Font.write)
+func helveticaCode(r rune) byte {
+	switch r {
+	case 'Ğ':
+		return 128
+	case 'ğ':
+		return 129
+	case 'Ş':
+		return 130
+	case 'ş':
+		return 131
+	case 'İ':
+		return 132
+	case 'ı':
+		return 133
+	}
+	if r >= 32 && r < 127 || r >= 160 && r <= 255 {
+		return byte(r)
+	}
+	return '?'
+}
+
+// helveticaWidths are the advance widths of ASCII 32-126 in 1/1000 em
+var helveticaWidths = [...]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+}
+
+func helveticaWidth(r rune) int {
+	// Accented letters are as wide as their base letter
+	switch r {
+	case 'ç':
+		r = 'c'
+	case 'ğ':
+		r = 'g'
+	case 'ı', 'î':
+		r = 'i'
+	case 'ö':
+		r = 'o'
+	case 'ş':
+		r = 's'
+	case 'ü', 'û':
+		r = 'u'
+	case 'â':
+		r = 'a'
+	case 'Ç':
+		r = 'C'
+	case 'Ğ':
+		r = 'G'
+	case 'İ':
+		r = 'I'
+	case 'Ö':
+		r = 'O'
+	case 'Ş':
+		r = 'S'
+	case 'Ü':
+		r = 'U'
+	}
+	if r >= 32 && r <= 126 {
+		return helveticaWidths[r-32]
+	}
+	return 556
+}
@@ -0,0 +1,369 @@
+// Package pdf writes PDF documents page by page. Images are written out as
+// soon as they are added, so a long lesson never has to fit in memory.
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Info is the document metadata shown by PDF viewers
+type Info struct {
+	Title   string
+	Author  string
+	Subject string
+	Creator string
+}
+
+// Writer writes a PDF to an io.Writer. Call NewPage for every page and
+// Close at the end; the first error sticks and is returned by Close.
+type Writer struct {
+	out     *countingWriter
+	offsets []int64 // file offset of every object, by number-1; -1 until written
+	pagesID int
+	pages   []int
+	fonts   []*Font
+	page    *Page
+	err     error
+}
+
+// Image is an image written to the document, drawable on any page
+type Image struct {
+	id            int
+	Width, Height int
+}
+
+// NewWriter starts a PDF document
+func NewWriter(w io.Writer) *Writer {
+	pw := &Writer{out: &countingWriter{w: w}}
+	pw.write("%%PDF-1.7\n%%\xe2\xe3\xcf\xd3\n")
+	pw.pagesID = pw.newObject()
+	return pw
+}
+
+// newObject reserves an object number; the object is written later
+func (w *Writer) newObject() int {
+	w.offsets = append(w.offsets, -1)
+	return len(w.offsets)
+}
+
+func (w *Writer) write(format string, args ...interface{}) {
+	if w.err != nil {
+		return
+	}
+	_, w.err = fmt.Fprintf(w.out, format, args...)
+}
+
+func (w *Writer) beginObject(id int) {
+	w.offsets[id-1] = w.out.n
+	w.write("%d 0 obj\n", id)
+}
+
+// writeObject writes a dictionary object
+func (w *Writer) writeObject(id int, dict string) {
+	w.beginObject(id)
+	w.write("%s\nendobj\n", dict)
+}
+
+// writeStream writes a stream object; dict is the dictionary without Length
+func (w *Writer) writeStream(id int, dict string, data []byte) {
+	w.beginObject(id)
+	w.write("<<%s /Length %d>>\nstream\n", dict, len(data))
+	if w.err == nil {
+		_, w.err = w.out.Write(data)
+	}
+	w.write("\nendstream\nendobj\n")
+}
+
+// writeCompressed writes a Flate-compressed stream
+func (w *Writer) writeCompressed(id int, dict string, data []byte) {
+	w.writeStream(id, dict+" /Filter /FlateDecode", deflate(data))
+}
+
+// AddImage writes an image to the document. It is Flate-compressed with PNG
+// row prediction, which suits board drawings; transparency becomes a soft mask.
+func (w *Writer) AddImage(img image.Image) *Image {
+	b := img.Bounds()
+	width, height := b.Dx(), b.Dy()
+	rgb := make([]byte, 0, (width*3+1)*height)
+	var alpha []byte
+	opaque := true
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			rgb = append(rgb, c.R, c.G, c.B)
+			alpha = append(alpha, c.A)
+			if c.A != 0xff {
+				opaque = false
+			}
+		}
+	}
+
+	id := w.newObject()
+	params := func(colors int) string {
+		return fmt.Sprintf(" /Width %d /Height %d /BitsPerComponent 8 /DecodeParms <</Predictor 15 /Colors %d /BitsPerComponent 8 /Columns %d>>", width, height, colors, width)
+	}
+	dict := " /Type /XObject /Subtype /Image /ColorSpace /DeviceRGB" + params(3)
+	if !opaque {
+		mask := w.newObject()
+		w.writeCompressed(mask, " /Type /XObject /Subtype /Image /ColorSpace /DeviceGray"+params(1), predictRows(alpha, width, 1))
+		dict += fmt.Sprintf(" /SMask %d 0 R", mask)
+	}
+	w.writeCompressed(id, dict, predictRows(rgb, width, 3))
+	return &Image{id: id, Width: width, Height: height}
+}
+
+// predictRows applies the PNG "up" filter: every byte becomes the difference
+// to the one above, so flat areas compress to almost nothing
+func predictRows(pixels []byte, width, colors int) []byte {
+	stride := width * colors
+	if stride == 0 {
+		return nil
+	}
+	out := make([]byte, 0, len(pixels)+len(pixels)/stride)
+	for y := 0; y*stride < len(pixels); y++ {
+		row := pixels[y*stride : (y+1)*stride]
+		out = append(out, 2)
+		if y == 0 {
+			out = append(out, row...)
+			continue
+		}
+		prev := pixels[(y-1)*stride : y*stride]
+		for i := range row {
+			out = append(out, row[i]-prev[i])
+		}
+	}
+	return out
+}
+
+// NewPage finishes the current page and starts a new one, size in points
+// (1/72 inch). The origin is the bottom left corner.
+func (w *Writer) NewPage(width, height float64) *Page {
+	w.finishPage()
+	w.page = &Page{width: width, height: height, images: map[int]bool{}, fonts: map[*Font]bool{}}
+	return w.page
+}
+
+func (w *Writer) finishPage() {
+	p := w.page
+	if p == nil {
+		return
+	}
+	w.page = nil
+
+	var resources strings.Builder
+	resources.WriteString("<<")
+	if len(p.fonts) > 0 {
+		resources.WriteString(" /Font <<")
+		for _, f := range w.fonts {
+			if p.fonts[f] {
+				fmt.Fprintf(&resources, " /F%d %d 0 R", f.id, f.id)
+			}
+		}
+		resources.WriteString(" >>")
+	}
+	if len(p.images) > 0 {
+		ids := make([]int, 0, len(p.images))
+		for id := range p.images {
+			ids = append(ids, id)
+		}
+		sort.Ints(ids)
+		resources.WriteString(" /XObject <<")
+		for _, id := range ids {
+			fmt.Fprintf(&resources, " /Im%d %d 0 R", id, id)
+		}
+		resources.WriteString(" >>")
+	}
+	resources.WriteString(" >>")
+
+	content := w.newObject()
+	w.writeCompressed(content, "", p.content.Bytes())
+	id := w.newObject()
+	w.writeObject(id, fmt.Sprintf("<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %s %s] /Resources %s /Contents %d 0 R >>",
+		w.pagesID, num(p.width), num(p.height), resources.String(), content))
+	w.pages = append(w.pages, id)
+}
+
+// Close finishes the document: fonts, page tree, metadata and cross-reference table
+func (w *Writer) Close(info Info) error {
+	w.finishPage()
+	if len(w.pages) == 0 {
+		w.NewPage(595, 842)
+		w.finishPage()
+	}
+	for _, f := range w.fonts {
+		f.write(w)
+	}
+
+	kids := make([]string, len(w.pages))
+	for i, id := range w.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", id)
+	}
+	w.writeObject(w.pagesID, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(w.pages)))
+	catalog := w.newObject()
+	w.writeObject(catalog, fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", w.pagesID))
+
+	var meta strings.Builder
+	meta.WriteString("<<")
+	for _, field := range []struct{ key, value string }{{"Title", info.Title}, {"Author", info.Author}, {"Subject", info.Subject}, {"Creator", info.Creator}} {
+		if field.value != "" {
+			fmt.Fprintf(&meta, " /%s %s", field.key, textString(field.value))
+		}
+	}
+	meta.WriteString(" >>")
+	infoID := w.newObject()
+	w.writeObject(infoID, meta.String())
+
+	xref := w.out.n
+	w.write("xref\n0 %d\n0000000000 65535 f \n", len(w.offsets)+1)
+	for _, offset := range w.offsets {
+		if offset < 0 && w.err == nil {
+			w.err = fmt.Errorf("pdf object was reserved but never written")
+		}
+		w.write("%010d 00000 n \n", offset)
+	}
+	w.write("trailer\n<< /Size %d /Root %d 0 R /Info %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(w.offsets)+1, catalog, infoID, xref)
+	return w.err
+}
+
+// Page is the page being drawn. Coordinates are points from the bottom left
+// unless changed with Transform.
+type Page struct {
+	width, height float64
+	content       bytes.Buffer
+	images        map[int]bool
+	fonts         map[*Font]bool
+}
+
+func (p *Page) op(format string, args ...interface{}) {
+	fmt.Fprintf(&p.content, format, args...)
+	p.content.WriteByte('\n')
+}
+
+// Size returns the page size in points
+func (p *Page) Size() (float64, float64) { return p.width, p.height }
+
+// SaveState and RestoreState bracket changes to colors, line style,
+// transformation and clipping
+func (p *Page) SaveState()    { p.op("q") }
+func (p *Page) RestoreState() { p.op("Q") }
+
+// Transform changes the coordinate system by the matrix [a b c d e f]
+func (p *Page) Transform(a, b, c, d, e, f float64) {
+	p.op("%s %s %s %s %s %s cm", num(a), num(b), num(c), num(d), num(e), num(f))
+}
+
+// SetStrokeColor sets the color of lines
+func (p *Page) SetStrokeColor(c color.Color) { p.op("%s RG", rgb(c)) }
+
+// SetFillColor sets the color of filled shapes and text
+func (p *Page) SetFillColor(c color.Color) { p.op("%s rg", rgb(c)) }
+
+// SetLineWidth sets the width of lines
+func (p *Page) SetLineWidth(width float64) { p.op("%s w", num(width)) }
+
+// SetRoundLines gives lines round ends and corners, like a pen
+func (p *Page) SetRoundLines() { p.op("1 J 1 j") }
+
+// Path construction
+func (p *Page) MoveTo(x, y float64) { p.op("%s %s m", num(x), num(y)) }
+func (p *Page) LineTo(x, y float64) { p.op("%s %s l", num(x), num(y)) }
+func (p *Page) CurveTo(x1, y1, x2, y2, x3, y3 float64) {
+	p.op("%s %s %s %s %s %s c", num(x1), num(y1), num(x2), num(y2), num(x3), num(y3))
+}
+func (p *Page) ClosePath() { p.op("h") }
+func (p *Page) Rectangle(x, y, width, height float64) {
+	p.op("%s %s %s %s re", num(x), num(y), num(width), num(height))
+}
+
+// Ellipse adds an ellipse inside the given box as four Bézier curves
+func (p *Page) Ellipse(x, y, width, height float64) {
+	const k = 0.5523 // control point distance for a quarter circle
+	rx, ry := width/2, height/2
+	cx, cy := x+rx, y+ry
+	p.MoveTo(cx+rx, cy)
+	p.CurveTo(cx+rx, cy+k*ry, cx+k*rx, cy+ry, cx, cy+ry)
+	p.CurveTo(cx-k*rx, cy+ry, cx-rx, cy+k*ry, cx-rx, cy)
+	p.CurveTo(cx-rx, cy-k*ry, cx-k*rx, cy-ry, cx, cy-ry)
+	p.CurveTo(cx+k*rx, cy-ry, cx+rx, cy-k*ry, cx+rx, cy)
+	p.ClosePath()
+}
+
+// Path painting
+func (p *Page) Stroke()     { p.op("S") }
+func (p *Page) Fill()       { p.op("f") }
+func (p *Page) FillStroke() { p.op("B") }
+
+// Clip limits drawing to the current path until RestoreState
+func (p *Page) Clip() { p.op("W n") }
+
+// DrawImage draws an image into the box at x, y. A negative height flips it,
+// for coordinate systems whose y axis points down.
+func (p *Page) DrawImage(img *Image, x, y, width, height float64) {
+	p.images[img.id] = true
+	p.op("q %s 0 0 %s %s %s cm /Im%d Do Q", num(width), num(height), num(x), num(y), img.id)
+}
+
+// Text draws one line of text with its baseline starting at x, y
+func (p *Page) Text(f *Font, size, x, y float64, text string) {
+	if text == "" {
+		return
+	}
+	p.fonts[f] = true
+	p.op("BT /F%d %s Tf %s %s Td <%X> Tj ET", f.id, num(size), num(x), num(y), f.encode(text))
+}
+
+// num formats a number compactly, as PDF content streams add up quickly
+func num(v float64) string {
+	s := fmt.Sprintf("%.3f", v)
+	s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	if s == "-0" || s == "" {
+		return "0"
+	}
+	return s
+}
+
+func rgb(c color.Color) string {
+	r, g, b, _ := c.RGBA()
+	return fmt.Sprintf("%s %s %s", num(float64(r)/0xffff), num(float64(g)/0xffff), num(float64(b)/0xffff))
+}
+
+// textString encodes metadata as UTF-16 so Turkish letters survive
+func textString(s string) string {
+	var b strings.Builder
+	b.WriteString("<FEFF")
+	for _, r := range s {
+		if r > 0xffff {
+			r = '?'
+		}
+		fmt.Fprintf(&b, "%04X", r)
+	}
+	b.WriteString(">")
+	return b.String()
+}
+
+func deflate(data []byte) []byte {
+	var buf bytes.Buffer
+	zw, _ := zlib.NewWriterLevel(&buf, zlib.BestCompression)
+	zw.Write(data)
+	zw.Close()
+	return buf.Bytes()
+}
+
+// countingWriter tracks the file offset for the cross-reference table
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
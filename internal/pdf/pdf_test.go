@@ -0,0 +1,115 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"os"
+	"regexp"
+	"strconv"
+	"testing"
+)
+
+// checkCrossReference verifies every xref entry points at its object
+func checkCrossReference(t *testing.T, data []byte) int {
+	t.Helper()
+	m := regexp.MustCompile(`startxref\n(\d+)\n%%EOF\n$`).FindSubmatch(data)
+	if m == nil {
+		t.Fatal("no startxref at the end")
+	}
+	xref, _ := strconv.Atoi(string(m[1]))
+	var count int
+	if _, err := fmt.Sscanf(string(data[xref:]), "xref\n0 %d\n", &count); err != nil {
+		t.Fatalf("no xref table at %d: %v", xref, err)
+	}
+	entries := data[bytes.IndexByte(data[xref+5:], '\n')+xref+6:]
+	for i := 1; i < count; i++ {
+		offset, _ := strconv.Atoi(string(entries[20*i : 20*i+10]))
+		if !bytes.HasPrefix(data[offset:], []byte(fmt.Sprintf("%d 0 obj\n", i))) {
+			t.Errorf("xref entry %d points at %q", i, data[offset:offset+10])
+		}
+	}
+	return count - 1
+}
+
+func TestWriterProducesValidStructure(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	font := w.StandardFont()
+
+	img := image.NewNRGBA(image.Rect(0, 0, 8, 4))
+	img.Set(2, 2, color.NRGBA{R: 255, A: 128})
+	photo := w.AddImage(img)
+	for i := 0; i < 2; i++ {
+		p := w.NewPage(842, 595)
+		p.DrawImage(photo, 10, 10, 80, 40)
+		p.Text(font, 12, 20, 100, "Öğretmen İşık")
+	}
+	if err := w.Close(Info{Title: "Ders"}); err != nil {
+		t.Fatal(err)
+	}
+
+	data := buf.Bytes()
+	if !bytes.HasPrefix(data, []byte("%PDF-1.7\n")) {
+		t.Errorf("header = %q", data[:9])
+	}
+	checkCrossReference(t, data)
+	if !bytes.Contains(data, []byte("/Count 2")) {
+		t.Error("page tree doesn't count 2 pages")
+	}
+	if !bytes.Contains(data, []byte("/SMask")) {
+		t.Error("transparent image has no soft mask")
+	}
+	// The image is written once and shared by both pages
+	if n := bytes.Count(data, []byte("/Subtype /Image /ColorSpace /DeviceRGB")); n != 1 {
+		t.Errorf("image written %d times", n)
+	}
+	if got := font.encode("ğŞı"); !bytes.Equal(got, []byte{129, 130, 133}) {
+		t.Errorf("Turkish letters encoded as %v", got)
+	}
+}
+
+func TestTrueTypeSubsetKeepsUsedGlyphs(t *testing.T) {
+	path := FindFont()
+	if path == "" {
+		t.Skip("no system font with Turkish letters")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tt, err := parseTrueType(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	used := map[uint16]bool{tt.glyph('ğ'): true, tt.glyph('İ'): true}
+	sub, err := parseTrueType(buildFont(withCmap(tt.subset(used), tt)))
+	if err != nil {
+		t.Fatalf("subset is not a valid font: %v", err)
+	}
+	if len(sub.tables["glyf"]) >= len(tt.tables["glyf"]) {
+		t.Errorf("subset outlines are %d bytes, the font's %d", len(sub.tables["glyf"]), len(tt.tables["glyf"]))
+	}
+	for _, r := range "ğİ" {
+		g := tt.glyph(r)
+		if sub.loca[g+1]-sub.loca[g] != tt.loca[g+1]-tt.loca[g] {
+			t.Errorf("outline of %c lost", r)
+		}
+	}
+	if g := tt.glyph('x'); sub.loca[g+1] != sub.loca[g] {
+		t.Error("unused glyph kept")
+	}
+}
+
+// withCmap adds the original character map back, which the subset leaves out
+// because PDF text addresses glyphs directly
+func withCmap(file []byte, tt *trueType) map[string][]byte {
+	tables := map[string][]byte{"cmap": tt.tables["cmap"]}
+	for i := 0; i < int(u16(file, 4)); i++ {
+		rec := file[12+16*i:]
+		tables[string(rec[:4])] = file[u32(rec, 8) : u32(rec, 8)+u32(rec, 12)]
+	}
+	return tables
+}
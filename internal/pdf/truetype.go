@@ -0,0 +1,369 @@
+package pdf
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf16"
+)
+
+// trueType is the part of a TrueType font needed to lay out text and embed
+// a subset of it
+type trueType struct {
+	tables     map[string][]byte
+	name       string // PostScript name
+	unitsPerEm uint16
+	ascent     int16
+	descent    int16
+	capHeight  int16
+	bbox       [4]int16
+	numGlyphs  int
+	advances   []uint16
+	cmap       map[rune]uint16
+	loca       []uint32 // glyph offsets into glyf, numGlyphs+1
+}
+
+func u16(b []byte, off int) uint16 { return binary.BigEndian.Uint16(b[off:]) }
+func u32(b []byte, off int) uint32 { return binary.BigEndian.Uint32(b[off:]) }
+
+func parseTrueType(data []byte) (tt *trueType, err error) {
+	// Table contents are checked as they are read; a truncated font must not panic
+	defer func() {
+		if recover() != nil {
+			tt, err = nil, fmt.Errorf("damaged font file")
+		}
+	}()
+
+	if len(data) < 12 {
+		return nil, fmt.Errorf("not a font file")
+	}
+	switch string(data[:4]) {
+	case "\x00\x01\x00\x00", "true":
+	case "OTTO":
+		return nil, fmt.Errorf("OpenType fonts with CFF outlines are not supported")
+	case "ttcf":
+		return nil, fmt.Errorf("font collections (.ttc) are not supported")
+	default:
+		return nil, fmt.Errorf("not a TrueType font")
+	}
+
+	tt = &trueType{tables: map[string][]byte{}}
+	for i := 0; i < int(u16(data, 4)); i++ {
+		rec := data[12+16*i:]
+		offset, length := u32(rec, 8), u32(rec, 12)
+		if uint64(offset)+uint64(length) > uint64(len(data)) {
+			return nil, fmt.Errorf("damaged font file")
+		}
+		tt.tables[string(rec[:4])] = data[offset : offset+length]
+	}
+	for _, tag := range []string{"head", "hhea", "maxp", "hmtx", "loca", "glyf", "cmap"} {
+		if tt.tables[tag] == nil {
+			return nil, fmt.Errorf("font has no %s table", tag)
+		}
+	}
+
+	if os2 := tt.tables["OS/2"]; len(os2) >= 10 && u16(os2, 8)&0x000f == 0x0002 {
+		return nil, fmt.Errorf("font license does not allow embedding")
+	}
+
+	head := tt.tables["head"]
+	tt.unitsPerEm = u16(head, 18)
+	if tt.unitsPerEm == 0 {
+		return nil, fmt.Errorf("damaged font file")
+	}
+	for i := range tt.bbox {
+		tt.bbox[i] = int16(u16(head, 36+2*i))
+	}
+	longLoca := u16(head, 50) == 1
+
+	hhea := tt.tables["hhea"]
+	tt.ascent, tt.descent = int16(u16(hhea, 4)), int16(u16(hhea, 6))
+	tt.capHeight = tt.ascent
+	if os2 := tt.tables["OS/2"]; len(os2) >= 90 && u16(os2, 0) >= 2 {
+		tt.capHeight = int16(u16(os2, 88))
+	}
+
+	tt.numGlyphs = int(u16(tt.tables["maxp"], 4))
+	hmtx := tt.tables["hmtx"]
+	metrics := int(u16(hhea, 34))
+	tt.advances = make([]uint16, tt.numGlyphs)
+	for g := range tt.advances {
+		if g < metrics {
+			tt.advances[g] = u16(hmtx, 4*g)
+		} else if g > 0 {
+			tt.advances[g] = tt.advances[g-1]
+		}
+	}
+
+	loca := tt.tables["loca"]
+	tt.loca = make([]uint32, tt.numGlyphs+1)
+	for g := range tt.loca {
+		if longLoca {
+			tt.loca[g] = u32(loca, 4*g)
+		} else {
+			tt.loca[g] = uint32(u16(loca, 2*g)) * 2
+		}
+	}
+	glyfLen := uint32(len(tt.tables["glyf"]))
+	for g := 0; g < tt.numGlyphs; g++ {
+		if tt.loca[g] > tt.loca[g+1] || tt.loca[g+1] > glyfLen {
+			return nil, fmt.Errorf("damaged font file")
+		}
+	}
+
+	if err := tt.parseCmap(); err != nil {
+		return nil, err
+	}
+	tt.name = tt.postScriptName()
+	return tt, nil
+}
+
+// parseCmap reads the Unicode character map: format 12 (full Unicode) if the
+// font has one, otherwise format 4 (Basic Multilingual Plane)
+func (tt *trueType) parseCmap() error {
+	cmap := tt.tables["cmap"]
+	var format4, format12 []byte
+	for i := 0; i < int(u16(cmap, 2)); i++ {
+		platform, encoding, offset := u16(cmap, 4+8*i), u16(cmap, 6+8*i), u32(cmap, 8+8*i)
+		unicode := platform == 0 || platform == 3 && (encoding == 1 || encoding == 10)
+		if !unicode || int(offset) >= len(cmap) {
+			continue
+		}
+		sub := cmap[offset:]
+		switch u16(sub, 0) {
+		case 4:
+			format4 = sub
+		case 12:
+			format12 = sub
+		}
+	}
+
+	tt.cmap = map[rune]uint16{}
+	switch {
+	case format12 != nil:
+		for i := 0; i < int(u32(format12, 12)); i++ {
+			group := format12[16+12*i:]
+			start, end, glyph := u32(group, 0), u32(group, 4), u32(group, 8)
+			for c := start; c <= end && c-start < 0x10000; c++ {
+				tt.cmap[rune(c)] = uint16(glyph + c - start)
+			}
+		}
+	case format4 != nil:
+		segments := int(u16(format4, 6)) / 2
+		ends, starts := 14, 16+2*segments
+		deltas, ranges := starts+2*segments, starts+4*segments
+		for s := 0; s < segments; s++ {
+			start, end := u16(format4, starts+2*s), u16(format4, ends+2*s)
+			delta, rangeOffset := u16(format4, deltas+2*s), u16(format4, ranges+2*s)
+			for c := uint32(start); c <= uint32(end) && c != 0xffff; c++ {
+				glyph := uint16(c) + delta
+				if rangeOffset != 0 {
+					glyph = u16(format4, ranges+2*s+int(rangeOffset)+2*int(c-uint32(start)))
+					if glyph != 0 {
+						glyph += delta
+					}
+				}
+				if glyph != 0 {
+					tt.cmap[rune(c)] = glyph
+				}
+			}
+		}
+	default:
+		return fmt.Errorf("font has no Unicode character map")
+	}
+	return nil
+}
+
+// postScriptName reads name ID 6 from the name table
+func (tt *trueType) postScriptName() string {
+	name := tt.tables["name"]
+	if len(name) >= 6 {
+		count, storage := int(u16(name, 2)), int(u16(name, 4))
+		for i := 0; i < count && 6+12*i+12 <= len(name); i++ {
+			rec := name[6+12*i:]
+			platform, nameID := u16(rec, 0), u16(rec, 6)
+			length, offset := int(u16(rec, 8)), int(u16(rec, 10))
+			if nameID != 6 || storage+offset+length > len(name) {
+				continue
+			}
+			raw := name[storage+offset : storage+offset+length]
+			var s string
+			if platform == 3 || platform == 0 {
+				units := make([]uint16, len(raw)/2)
+				for j := range units {
+					units[j] = u16(raw, 2*j)
+				}
+				s = string(utf16.Decode(units))
+			} else {
+				s = string(raw)
+			}
+			if s = cleanName(s); s != "" {
+				return s
+			}
+		}
+	}
+	return "Embedded"
+}
+
+// cleanName keeps the characters allowed in a PDF name without escaping
+func cleanName(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r > 32 && r < 127 && !strings.ContainsRune("()<>[]{}/%#", r) {
+			return r
+		}
+		return -1
+	}, s)
+}
+
+// glyph returns the glyph of a letter, "?" for letters the font lacks
+func (tt *trueType) glyph(r rune) uint16 {
+	if g, ok := tt.cmap[r]; ok {
+		return g
+	}
+	return tt.cmap['?']
+}
+
+func (tt *trueType) advance(g uint16) uint16 {
+	if int(g) < len(tt.advances) {
+		return tt.advances[g]
+	}
+	return 0
+}
+
+func (tt *trueType) covers(letters string) bool {
+	for _, r := range letters {
+		if tt.cmap[r] == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Composite glyph flags
+const (
+	argsAreWords   = 0x0001
+	haveScale      = 0x0008
+	moreComponents = 0x0020
+	haveXYScale    = 0x0040
+	haveTwoByTwo   = 0x0080
+)
+
+// subset returns a font file with the outlines of only the given glyphs (and
+// the glyphs they are built from). Glyph IDs stay the same, so text can use
+// them directly; unused glyphs are left empty.
+func (tt *trueType) subset(glyphs map[uint16]bool) []byte {
+	glyf := tt.tables["glyf"]
+	keep := map[uint16]bool{0: true}
+	queue := make([]uint16, 0, len(glyphs))
+	for g := range glyphs {
+		queue = append(queue, g)
+	}
+	for len(queue) > 0 {
+		g := queue[len(queue)-1]
+		queue = queue[:len(queue)-1]
+		if int(g) >= tt.numGlyphs || keep[g] && g != 0 {
+			continue
+		}
+		keep[g] = true
+		data := glyf[tt.loca[g]:tt.loca[g+1]]
+		if len(data) < 10 || int16(u16(data, 0)) >= 0 {
+			continue
+		}
+		// Composite: collect its components
+		for off := 10; off+4 <= len(data); {
+			flags, component := u16(data, off), u16(data, off+2)
+			if !keep[component] {
+				queue = append(queue, component)
+			}
+			off += 4
+			if flags&argsAreWords != 0 {
+				off += 4
+			} else {
+				off += 2
+			}
+			switch {
+			case flags&haveScale != 0:
+				off += 2
+			case flags&haveXYScale != 0:
+				off += 4
+			case flags&haveTwoByTwo != 0:
+				off += 8
+			}
+			if flags&moreComponents == 0 {
+				break
+			}
+		}
+	}
+
+	var newGlyf []byte
+	newLoca := make([]byte, 4*(tt.numGlyphs+1))
+	for g := 0; g < tt.numGlyphs; g++ {
+		binary.BigEndian.PutUint32(newLoca[4*g:], uint32(len(newGlyf)))
+		if keep[uint16(g)] {
+			newGlyf = append(newGlyf, glyf[tt.loca[g]:tt.loca[g+1]]...)
+			for len(newGlyf)%4 != 0 {
+				newGlyf = append(newGlyf, 0)
+			}
+		}
+	}
+	binary.BigEndian.PutUint32(newLoca[4*tt.numGlyphs:], uint32(len(newGlyf)))
+
+	head := append([]byte(nil), tt.tables["head"]...)
+	binary.BigEndian.PutUint32(head[8:], 0)  // checkSumAdjustment
+	binary.BigEndian.PutUint16(head[50:], 1) // long loca
+
+	tables := map[string][]byte{"head": head, "loca": newLoca, "glyf": newGlyf}
+	for _, tag := range []string{"hhea", "maxp", "hmtx", "cvt ", "fpgm", "prep"} {
+		if data := tt.tables[tag]; data != nil {
+			tables[tag] = data
+		}
+	}
+	return buildFont(tables)
+}
+
+// buildFont writes a font file from its tables
+func buildFont(tables map[string][]byte) []byte {
+	tags := make([]string, 0, len(tables))
+	for tag := range tables {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+
+	n := len(tags)
+	entrySelector := 0
+	for 1<<(entrySelector+1) <= n {
+		entrySelector++
+	}
+	searchRange := 16 << entrySelector
+
+	out := make([]byte, 12+16*n)
+	binary.BigEndian.PutUint32(out[0:], 0x00010000)
+	binary.BigEndian.PutUint16(out[4:], uint16(n))
+	binary.BigEndian.PutUint16(out[6:], uint16(searchRange))
+	binary.BigEndian.PutUint16(out[8:], uint16(entrySelector))
+	binary.BigEndian.PutUint16(out[10:], uint16(16*n-searchRange))
+	for i, tag := range tags {
+		data := tables[tag]
+		rec := out[12+16*i:]
+		copy(rec, tag)
+		binary.BigEndian.PutUint32(rec[4:], checksum(data))
+		binary.BigEndian.PutUint32(rec[8:], uint32(len(out)))
+		binary.BigEndian.PutUint32(rec[12:], uint32(len(data)))
+		out = append(out, data...)
+		for len(out)%4 != 0 {
+			out = append(out, 0)
+		}
+	}
+	return out
+}
+
+func checksum(data []byte) uint32 {
+	var sum uint32
+	for i := 0; i < len(data); i += 4 {
+		var word [4]byte
+		copy(word[:], data[i:])
+		sum += binary.BigEndian.Uint32(word[:])
+	}
+	return sum
+}
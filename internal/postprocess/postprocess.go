@@ -0,0 +1,218 @@
+package postprocess
+
+import (
+	"DersDostu/internal/db"
+	"DersDostu/internal/recorder"
+	"DersDostu/internal/sync"
+	"fmt"
+	"log"
+	"path/filepath"
+	"strings"
+	gosync "sync"
+	"time"
+)
+
+const (
+	thumbnailHeight = 240
+	chapterHeight   = 360
+	// chapterInterval spaces the chapter images of recordings without chapter
+	// marks; a 40 minute lesson gets 8
+	chapterInterval = 5 * time.Minute
+	maxChapters     = 24
+)
+
+// Job is a finished recording and the lesson it belongs to
+type Job struct {
+	Filename  string // relative to PublicDir
+	ClassName string
+	LessonID  string
+}
+
+// PostProcessor turns a finished recording into a lesson asset: it probes the
+// file, extracts a thumbnail and chapter images, registers it in SQLite and
+// hands it to the sync layer. Jobs run one at a time in the background.
+type PostProcessor struct {
+	PublicDir string
+	db        *db.DBService // nil when the database failed to open
+	sync      *sync.SyncManager
+	previews  *recorder.PreviewService
+
+	mu          gosync.Mutex
+	pending     []Job         // finished recordings waiting, oldest first
+	wake        chan struct{} // signals the worker that pending grew
+	onProcessed func(db.Recording)
+}
+
+// NewPostProcessor creates the processor and starts its worker
+func NewPostProcessor(publicDir string, database *db.DBService, syn *sync.SyncManager, previews *recorder.PreviewService) *PostProcessor {
+	p := &PostProcessor{
+		PublicDir: publicDir,
+		db:        database,
+		sync:      syn,
+		previews:  previews,
+		wake:      make(chan struct{}, 1),
+	}
+	go p.worker()
+	return p
+}
+
+// OnProcessed registers a callback run after each recording is processed
+func (p *PostProcessor) OnProcessed(fn func(db.Recording)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.onProcessed = fn
+}
+
+// Enqueue schedules a finished recording. The queue has no limit: a dropped
+// job would leave the recording without thumbnail, database row and sync.
+func (p *PostProcessor) Enqueue(job Job) {
+	p.mu.Lock()
+	p.pending = append(p.pending, job)
+	p.mu.Unlock()
+
+	select {
+	case p.wake <- struct{}{}:
+	default: // the worker is already awake
+	}
+}
+
+// ThumbnailPath returns the thumbnail of a recording, relative to PublicDir
+func ThumbnailPath(recording string) string {
+	return filepath.Join(recorder.PreviewDir, baseName(recording)+"-thumb.jpg")
+}
+
+// ChapterDir returns the folder of a recording's chapter images, relative to PublicDir
+func ChapterDir(recording string) string {
+	return filepath.Join(recorder.PreviewDir, baseName(recording)+"-chapters")
+}
+
+func (p *PostProcessor) worker() {
+	for range p.wake {
+		for {
+			job, ok := p.next()
+			if !ok {
+				break
+			}
+			rec, err := p.process(job)
+			if err != nil {
+				log.Printf("Post-processing failed for %s: %v", job.Filename, err)
+				continue
+			}
+			log.Printf("Recording %s processed (%s)", job.Filename, time.Duration(rec.DurationSec*float64(time.Second)).Round(time.Second))
+
+			p.mu.Lock()
+			onProcessed := p.onProcessed
+			p.mu.Unlock()
+			if onProcessed != nil {
+				onProcessed(rec)
+			}
+		}
+	}
+}
+
+// next takes the oldest pending job
+func (p *PostProcessor) next() (Job, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.pending) == 0 {
+		return Job{}, false
+	}
+	job := p.pending[0]
+	p.pending = p.pending[1:]
+	return job, true
+}
+
+func (p *PostProcessor) process(job Job) (db.Recording, error) {
+	input := filepath.Join(p.PublicDir, job.Filename)
+
+	info, err := recorder.ProbeMedia(input)
+	if err != nil {
+		return db.Recording{}, err
+	}
+
+	rec := db.Recording{
+		Filename:    filepath.ToSlash(job.Filename),
+		ClassName:   job.ClassName,
+		LessonID:    job.LessonID,
+		DurationSec: info.Duration.Seconds(),
+		Width:       info.Width,
+		Height:      info.Height,
+		SizeBytes:   info.Size,
+		Chapters:    []string{},
+	}
+
+	// Audio-only recordings have nothing to show
+	if info.Width > 0 {
+		rec.Thumbnail, rec.Chapters = p.extractImages(input, job.Filename, info.Duration)
+	}
+
+	// The light preview for LAN viewers has its own worker (and needs ffmpeg)
+	if p.previews != nil && recorder.HasFFmpeg() {
+		p.previews.Enqueue(job.Filename)
+	}
+
+	if p.sync != nil {
+		url, err := p.sync.SyncFile(input)
+		if err != nil {
+			log.Printf("Sync failed for %s: %v", job.Filename, err)
+		}
+		rec.URL = url
+	}
+
+	if p.db != nil {
+		if err := p.db.SaveRecording(&rec); err != nil {
+			return rec, err
+		}
+	}
+	return rec, nil
+}
+
+// extractImages saves a thumbnail and the chapter images: one per recorded
+// chapter when the lesson has them, else one every chapterInterval.
+// Failures are logged and skipped: a recording without images is still a recording.
+func (p *PostProcessor) extractImages(input, filename string, duration time.Duration) (string, []string) {
+	// Skip the first seconds, they are usually the teacher's desktop
+	intro := min(3*time.Second, duration/2)
+
+	thumbnail := ThumbnailPath(filename)
+	if err := recorder.ExtractFrame(input, intro, thumbnailHeight, filepath.Join(p.PublicDir, thumbnail)); err != nil {
+		log.Printf("Thumbnail failed for %s: %v", filename, err)
+		thumbnail = ""
+	}
+
+	times := []time.Duration{}
+	if marks, err := recorder.ReadChapterTrack(filepath.Join(p.PublicDir, recorder.ChapterTrackPath(filename))); err == nil && len(marks) > 0 {
+		for _, ch := range marks {
+			times = append(times, time.Duration(ch.Start*float64(time.Second)))
+		}
+	} else {
+		for at := time.Duration(0); at < duration; at += chapterInterval {
+			times = append(times, at)
+		}
+	}
+
+	chapters := []string{}
+	dir := ChapterDir(filename)
+	for i, at := range times {
+		if i == maxChapters {
+			break
+		}
+		// A page is still blank the moment it is opened; show it as it was when the chapter ended
+		end := duration
+		if i+1 < len(times) {
+			end = times[i+1]
+		}
+		at = max(at, end-time.Second)
+		image := filepath.Join(dir, fmt.Sprintf("%03d.jpg", i+1))
+		if err := recorder.ExtractFrame(input, at, chapterHeight, filepath.Join(p.PublicDir, image)); err != nil {
+			log.Printf("Chapter image %d failed for %s: %v", i+1, filename, err)
+			continue
+		}
+		chapters = append(chapters, filepath.ToSlash(image))
+	}
+	return filepath.ToSlash(thumbnail), chapters
+}
+
+func baseName(recording string) string {
+	return strings.TrimSuffix(filepath.Base(recording), filepath.Ext(recording))
+}
@@ -0,0 +1,197 @@
+package recorder
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// CaptureMode selects which part of the screen is recorded
+type CaptureMode string
+
+const (
+	CaptureDesktop CaptureMode = "desktop" // every monitor
+	CaptureMonitor CaptureMode = "monitor" // one monitor
+	CaptureRegion  CaptureMode = "region"  // a rectangle on a monitor
+	CaptureWindow  CaptureMode = "window"  // only the DersDostu window
+)
+
+// AppWindowTitle is the title of the main window, used by CaptureWindow
+const AppWindowTitle = "DersDostu"
+
+// CaptureTarget describes what to record. Region coordinates are relative to
+// Monitor (or to the whole desktop when Monitor is empty), so a saved region
+// stays correct when a second screen is plugged in to the left.
+type CaptureTarget struct {
+	Mode    CaptureMode `json:"mode"`
+	Monitor string      `json:"monitor"` // CaptureDevice ID, empty = primary
+	X       int         `json:"x"`
+	Y       int         `json:"y"`
+	Width   int         `json:"width"`
+	Height  int         `json:"height"`
+}
+
+// rect is an area in desktop coordinates
+type rect struct {
+	X, Y, Width, Height int
+}
+
+// Validate reports targets that can never be resolved
+func (t CaptureTarget) Validate() error {
+	switch t.Mode {
+	case "", CaptureDesktop, CaptureMonitor, CaptureWindow:
+		return nil
+	case CaptureRegion:
+		if t.Width <= 0 || t.Height <= 0 {
+			return fmt.Errorf("region needs a width and height")
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown capture mode: %s", t.Mode)
+}
+
+// screenArgs returns the ffmpeg video input arguments for the target
+func screenArgs(target CaptureTarget, framerate string) ([]string, error) {
+	if runtime.GOOS == "windows" {
+		// Windows: gdigrab - reduced FPS for old i3/i5
+		args := []string{"-f", "gdigrab", "-framerate", framerate}
+		switch target.Mode {
+		case CaptureWindow:
+			// gdigrab follows the window itself, even when it moves
+			return append(args, "-i", "title="+AppWindowTitle), nil
+		case CaptureMonitor, CaptureRegion:
+			area, err := resolveArea(target)
+			if err != nil {
+				return nil, err
+			}
+			fmt.Printf("Recording area: %dx%d at %d,%d\n", area.Width, area.Height, area.X, area.Y)
+			return append(args,
+				"-offset_x", fmt.Sprintf("%d", area.X),
+				"-offset_y", fmt.Sprintf("%d", area.Y),
+				"-video_size", fmt.Sprintf("%dx%d", area.Width, area.Height),
+				"-i", "desktop",
+			), nil
+		}
+		return append(args, "-i", "desktop"), nil
+	}
+
+	// Linux: x11grab
+	display := os.Getenv("DISPLAY")
+	if display == "" {
+		display = ":0"
+	}
+
+	var area rect
+	var err error
+	if target.Mode == CaptureWindow {
+		area, err = windowArea(AppWindowTitle)
+	} else {
+		area, err = resolveArea(target)
+	}
+	if err != nil {
+		// A guessed size would record the wrong part of the screen without anyone noticing
+		return nil, fmt.Errorf("could not find the screen area to record: %v", err)
+	}
+
+	fmt.Printf("Recording on Display: %s area: %dx%d at %d,%d\n", display, area.Width, area.Height, area.X, area.Y)
+
+	return []string{
+		"-f", "x11grab",
+		"-video_size", fmt.Sprintf("%dx%d", area.Width, area.Height),
+		"-framerate", framerate,
+		"-i", fmt.Sprintf("%s+%d,%d", display, area.X, area.Y),
+	}, nil
+}
+
+// resolveArea turns a desktop/monitor/region target into desktop coordinates
+func resolveArea(target CaptureTarget) (rect, error) {
+	monitors, err := listMonitors()
+	if err != nil {
+		return rect{}, err
+	}
+	if len(monitors) == 0 {
+		return rect{}, fmt.Errorf("no monitors found")
+	}
+
+	var bounds rect
+	if target.Mode == CaptureDesktop || target.Mode == "" || (target.Mode == CaptureRegion && target.Monitor == "") {
+		desktop, _ := desktopBounds(monitors)
+		bounds = rect{desktop.X, desktop.Y, desktop.Width, desktop.Height}
+	} else {
+		m := findMonitor(monitors, target.Monitor)
+		bounds = rect{m.X, m.Y, m.Width, m.Height}
+	}
+
+	if target.Mode != CaptureRegion {
+		return evenSize(bounds), nil
+	}
+
+	// Clamp the region to its monitor so ffmpeg doesn't reject it
+	region := rect{bounds.X + max(0, target.X), bounds.Y + max(0, target.Y), target.Width, target.Height}
+	region.Width = min(region.Width, bounds.X+bounds.Width-region.X)
+	region.Height = min(region.Height, bounds.Y+bounds.Height-region.Y)
+	if region.Width < 2 || region.Height < 2 {
+		return rect{}, fmt.Errorf("region is outside the selected monitor")
+	}
+	return evenSize(region), nil
+}
+
+// findMonitor returns the monitor with the given ID, else the primary one.
+// A second screen that was unplugged shouldn't stop the lesson from being recorded.
+func findMonitor(monitors []CaptureDevice, id string) CaptureDevice {
+	for _, m := range monitors {
+		if m.ID == id {
+			return m
+		}
+	}
+	if id != "" {
+		log.Printf("Monitor %s not found, recording the primary monitor", id)
+	}
+	for _, m := range monitors {
+		if m.Default {
+			return m
+		}
+	}
+	return monitors[0]
+}
+
+// windowArea finds a window's position with xwininfo (x11-utils).
+// x11grab records that rectangle, so moving the window during a lesson isn't followed.
+func windowArea(title string) (rect, error) {
+	out, err := exec.Command("xwininfo", "-name", title).Output()
+	if err != nil {
+		return rect{}, fmt.Errorf("window %q not found: %v", title, err)
+	}
+
+	var area rect
+	for _, line := range strings.Split(string(out), "\n") {
+		key, value, ok := strings.Cut(strings.TrimSpace(line), ":")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "Absolute upper-left X":
+			area.X = atoi(value)
+		case "Absolute upper-left Y":
+			area.Y = atoi(value)
+		case "Width":
+			area.Width = atoi(value)
+		case "Height":
+			area.Height = atoi(value)
+		}
+	}
+	if area.Width < 2 || area.Height < 2 {
+		return rect{}, fmt.Errorf("window %q has no size", title)
+	}
+	return evenSize(area), nil
+}
+
+// evenSize rounds dimensions down to even numbers, which yuv420p requires
+func evenSize(r rect) rect {
+	r.Width -= r.Width % 2
+	r.Height -= r.Height % 2
+	return r
+}
@@ -0,0 +1,240 @@
+package recorder
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Chapter marks a moment of the lesson students may want to jump to
+type Chapter struct {
+	Start float64 `json:"start"` // seconds from the recording start, pauses excluded
+	Title string  `json:"title"` // e.g. "Sayfa 3"
+	Kind  string  `json:"kind"`  // "page", "clear", "voice", ...
+}
+
+// chapterMergeWindow: a chapter this close to the previous one replaces it,
+// so flipping through five pages to reach the sixth leaves one marker.
+const chapterMergeWindow = 3 * time.Second
+
+// chaptersFile is kept in the parts folder so a crashed recording keeps its chapters
+const chaptersFile = "chapters.json"
+
+// ChapterTrackPath returns the WebVTT chapters file of a recording, relative to PublicDir
+func ChapterTrackPath(recording string) string {
+	return strings.TrimSuffix(recording, filepath.Ext(recording)) + ".chapters.vtt"
+}
+
+// SubtitlePaths returns the SRT and WebVTT subtitles of a recording, relative to PublicDir
+func SubtitlePaths(recording string) (srt string, vtt string) {
+	base := strings.TrimSuffix(recording, filepath.Ext(recording))
+	return base + ".srt", base + ".vtt"
+}
+
+// AddChapter marks the current position of the recording. Chapters added while
+// paused point at where the recording will continue.
+func (r *RecorderService) AddChapter(kind, title string) (Chapter, error) {
+	title = strings.Join(strings.Fields(title), " ")
+	if title == "" {
+		return Chapter{}, fmt.Errorf("chapter needs a title")
+	}
+
+	r.mu.Lock()
+	if !r.recording {
+		r.mu.Unlock()
+		return Chapter{}, fmt.Errorf("no recording in progress")
+	}
+
+	ch := Chapter{Start: r.elapsedLocked().Seconds(), Title: title, Kind: kind}
+	if n := len(r.chapters); n > 0 && ch.Start-r.chapters[n-1].Start < chapterMergeWindow.Seconds() {
+		ch.Start = r.chapters[n-1].Start
+		r.chapters[n-1] = ch
+	} else {
+		r.chapters = append(r.chapters, ch)
+	}
+	chapters := append([]Chapter(nil), r.chapters...)
+	partsDir := r.partsDir
+	r.mu.Unlock()
+
+	if err := saveChapters(filepath.Join(partsDir, chaptersFile), chapters); err != nil {
+		log.Printf("Failed to save chapters: %v", err)
+	}
+	r.emit("recording-chapter", ch)
+	return ch, nil
+}
+
+// Chapters returns the chapters of the current or last recording
+func (r *RecorderService) Chapters() []Chapter {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Chapter{}, r.chapters...)
+}
+
+func saveChapters(path string, chapters []Chapter) error {
+	data, err := json.Marshal(chapters)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+func loadChapters(path string) []Chapter {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var chapters []Chapter
+	if err := json.Unmarshal(data, &chapters); err != nil {
+		log.Printf("Ignoring damaged %s: %v", path, err)
+		return nil
+	}
+	return chapters
+}
+
+// writeChapters embeds the chapters into the MP4 (players like VLC show them)
+// and writes a WebVTT chapters track next to it for the LAN page. It costs one
+// more stream copy of the file, which is cheap next to the encode.
+func writeChapters(videoPath string, chapters []Chapter) error {
+	if len(chapters) == 0 {
+		return nil
+	}
+	info, err := ProbeMedia(videoPath)
+	if err != nil {
+		return err
+	}
+	chapters = chapterSpans(chapters, info.Duration.Seconds())
+	if len(chapters) == 0 {
+		return nil
+	}
+
+	meta, err := os.CreateTemp(filepath.Dir(videoPath), ".chapters-*.txt")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(meta.Name())
+	_, err = meta.WriteString(ffmetadata(chapters, info.Duration.Seconds()))
+	meta.Close()
+	if err != nil {
+		return err
+	}
+
+	tmp := strings.TrimSuffix(videoPath, filepath.Ext(videoPath)) + ".chapters.part.mp4"
+	err = runFFmpeg(
+		"-i", videoPath,
+		"-i", meta.Name(),
+		"-map", "0",
+		"-map_metadata", "0",
+		"-map_chapters", "1",
+		"-c", "copy",
+		"-movflags", "+faststart",
+		"-y", tmp,
+	)
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, videoPath); err != nil {
+		return err
+	}
+
+	vtt := ChapterTrackPath(videoPath)
+	tmpVTT := vtt + ".part"
+	if err := os.WriteFile(tmpVTT, []byte(webVTT(chapters, info.Duration.Seconds())), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpVTT, vtt)
+}
+
+// chapterSpans drops chapters past the end of the file and makes sure the first
+// one starts at zero, so the chapter list covers the whole recording.
+func chapterSpans(chapters []Chapter, duration float64) []Chapter {
+	spans := []Chapter{}
+	for _, ch := range chapters {
+		if ch.Start < duration {
+			spans = append(spans, ch)
+		}
+	}
+	if len(spans) > 0 && spans[0].Start > chapterMergeWindow.Seconds() {
+		spans = append([]Chapter{{Start: 0, Title: "Başlangıç", Kind: "start"}}, spans...)
+	} else if len(spans) > 0 {
+		spans[0].Start = 0
+	}
+	return spans
+}
+
+// ffmetadata renders chapters in ffmpeg's metadata file format
+func ffmetadata(chapters []Chapter, duration float64) string {
+	escape := strings.NewReplacer(`\`, `\\`, "=", `\=`, ";", `\;`, "#", `\#`, "\n", " ")
+
+	var b strings.Builder
+	b.WriteString(";FFMETADATA1\n")
+	for i, ch := range chapters {
+		end := duration
+		if i+1 < len(chapters) {
+			end = chapters[i+1].Start
+		}
+		fmt.Fprintf(&b, "[CHAPTER]\nTIMEBASE=1/1000\nSTART=%d\nEND=%d\ntitle=%s\n",
+			int64(ch.Start*1000), int64(end*1000), escape.Replace(ch.Title))
+	}
+	return b.String()
+}
+
+// webVTT renders chapters as a WebVTT chapters track
+func webVTT(chapters []Chapter, duration float64) string {
+	escape := strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", "\n", " ")
+
+	var b strings.Builder
+	b.WriteString("WEBVTT\n\n")
+	for i, ch := range chapters {
+		end := duration
+		if i+1 < len(chapters) {
+			end = chapters[i+1].Start
+		}
+		fmt.Fprintf(&b, "%d\n%s --> %s\n%s\n\n", i+1, vttTime(ch.Start), vttTime(end), escape.Replace(ch.Title))
+	}
+	return b.String()
+}
+
+// ReadChapterTrack parses a WebVTT chapters file written by writeChapters
+func ReadChapterTrack(path string) ([]Chapter, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	unescape := strings.NewReplacer("&lt;", "<", "&gt;", ">", "&amp;", "&")
+	chapters := []Chapter{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		start, _, ok := strings.Cut(scanner.Text(), " --> ")
+		if !ok || !scanner.Scan() {
+			continue
+		}
+		seconds, err := parseVTTTime(start)
+		if err != nil {
+			continue
+		}
+		chapters = append(chapters, Chapter{Start: seconds, Title: unescape.Replace(scanner.Text())})
+	}
+	return chapters, scanner.Err()
+}
+
+// vttTime formats seconds as hh:mm:ss.mmm
+func vttTime(seconds float64) string {
+	ms := int64(seconds*1000 + 0.5)
+	return fmt.Sprintf("%02d:%02d:%02d.%03d", ms/3600000, ms/60000%60, ms/1000%60, ms%1000)
+}
+
+func parseVTTTime(s string) (float64, error) {
+	var h, m, sec, ms int64
+	if _, err := fmt.Sscanf(strings.TrimSpace(s), "%d:%d:%d.%d", &h, &m, &sec, &ms); err != nil {
+		return 0, fmt.Errorf("invalid timestamp %q", s)
+	}
+	return float64(h*3600+m*60+sec) + float64(ms)/1000, nil
+}
@@ -0,0 +1,241 @@
+package recorder
+
+import (
+	"fmt"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// Capture device kinds
+const (
+	DeviceMicrophone = "microphone"
+	DeviceMonitor    = "monitor"
+	DeviceDesktop    = "desktop"
+)
+
+// CaptureDevice is a microphone or screen area the recorder can capture
+type CaptureDevice struct {
+	Kind    string `json:"kind"`
+	ID      string `json:"id"`   // value to pass back when selecting the device
+	Name    string `json:"name"` // human readable
+	Default bool   `json:"default"`
+
+	// Screen geometry in desktop coordinates (monitors and desktop only)
+	X      int `json:"x"`
+	Y      int `json:"y"`
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
+// ListCaptureDevices returns the microphones and screen areas available for recording
+func ListCaptureDevices() ([]CaptureDevice, error) {
+	devices := []CaptureDevice{}
+
+	mics, err := listMicrophones()
+	if err != nil {
+		return nil, err
+	}
+	devices = append(devices, mics...)
+
+	monitors, err := listMonitors()
+	if err != nil {
+		return nil, err
+	}
+	if desktop, ok := desktopBounds(monitors); ok {
+		devices = append(devices, desktop)
+	}
+	devices = append(devices, monitors...)
+
+	return devices, nil
+}
+
+func listMicrophones() ([]CaptureDevice, error) {
+	switch runtime.GOOS {
+	case "linux":
+		return listPulseSources()
+	case "windows":
+		return listDshowAudio()
+	}
+	return nil, fmt.Errorf("unsupported OS for recording")
+}
+
+// listPulseSources parses `pactl list sources`. ".monitor" sources are loopbacks
+// of the speakers, not microphones, and are skipped.
+func listPulseSources() ([]CaptureDevice, error) {
+	out, err := exec.Command("pactl", "list", "sources").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list microphones (is PulseAudio running?): %v", err)
+	}
+
+	defaultSource := ""
+	if info, err := exec.Command("pactl", "get-default-source").Output(); err == nil {
+		defaultSource = strings.TrimSpace(string(info))
+	}
+
+	devices := []CaptureDevice{}
+	var current *CaptureDevice
+	flush := func() {
+		if current != nil && current.ID != "" && !strings.HasSuffix(current.ID, ".monitor") {
+			current.Default = current.ID == defaultSource
+			devices = append(devices, *current)
+		}
+		current = nil
+	}
+
+	for _, line := range strings.Split(string(out), "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "Source #"):
+			flush()
+			current = &CaptureDevice{Kind: DeviceMicrophone}
+		case current == nil:
+		case strings.HasPrefix(line, "Name: "):
+			current.ID = strings.TrimPrefix(line, "Name: ")
+		case strings.HasPrefix(line, "Description: "):
+			current.Name = strings.TrimPrefix(line, "Description: ")
+		}
+	}
+	flush()
+
+	return devices, nil
+}
+
+// dshowDevice matches ffmpeg's device list lines, e.g.
+//
+//	[dshow @ 000001] "Mikrofon (USB Audio Device)" (audio)
+//	[dshow @ 000001] "Mikrofon (USB Audio Device)"        (older builds, under a header)
+var dshowDevice = regexp.MustCompile(`\]\s+"([^"]+)"\s*(\((audio|video)\))?`)
+
+// listDshowAudio asks ffmpeg for the DirectShow audio inputs
+func listDshowAudio() ([]CaptureDevice, error) {
+	// ffmpeg always exits with an error here ("dummy" is not a device); the list is on stderr
+	out, _ := exec.Command("ffmpeg", "-hide_banner", "-list_devices", "true", "-f", "dshow", "-i", "dummy").CombinedOutput()
+
+	devices := []CaptureDevice{}
+	section := ""
+	for _, line := range strings.Split(string(out), "\n") {
+		switch {
+		case strings.Contains(line, "DirectShow audio devices"):
+			section = "audio"
+			continue
+		case strings.Contains(line, "DirectShow video devices"):
+			section = "video"
+			continue
+		case strings.Contains(line, "Alternative name"):
+			continue
+		}
+
+		m := dshowDevice.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		kind := m[3]
+		if kind == "" {
+			kind = section
+		}
+		if kind == "audio" {
+			devices = append(devices, CaptureDevice{Kind: DeviceMicrophone, ID: m[1], Name: m[1], Default: len(devices) == 0})
+		}
+	}
+	return devices, nil
+}
+
+func listMonitors() ([]CaptureDevice, error) {
+	switch runtime.GOOS {
+	case "linux":
+		return listXrandrMonitors()
+	case "windows":
+		return listWindowsScreens()
+	}
+	return nil, fmt.Errorf("unsupported OS for recording")
+}
+
+// xrandrMonitor matches `xrandr --listmonitors` lines, e.g.
+//
+//	0: +*eDP-1 1920/344x1080/193+0+0  eDP-1
+var xrandrMonitor = regexp.MustCompile(`^\s*\d+:\s+\+?(\*?)(\S+)\s+(\d+)/\d+x(\d+)/\d+\+(-?\d+)\+(-?\d+)`)
+
+func listXrandrMonitors() ([]CaptureDevice, error) {
+	out, err := exec.Command("xrandr", "--listmonitors").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list monitors: %v", err)
+	}
+
+	monitors := []CaptureDevice{}
+	for _, line := range strings.Split(string(out), "\n") {
+		m := xrandrMonitor.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		monitors = append(monitors, CaptureDevice{
+			Kind:    DeviceMonitor,
+			ID:      m[2],
+			Name:    m[2],
+			Default: m[1] == "*",
+			Width:   atoi(m[3]),
+			Height:  atoi(m[4]),
+			X:       atoi(m[5]),
+			Y:       atoi(m[6]),
+		})
+	}
+	return monitors, nil
+}
+
+// listWindowsScreens asks .NET for the screen bounds; there is no ffmpeg equivalent
+func listWindowsScreens() ([]CaptureDevice, error) {
+	script := `Add-Type -AssemblyName System.Windows.Forms; ` +
+		`[System.Windows.Forms.Screen]::AllScreens | ForEach-Object { ` +
+		`'{0}|{1}|{2}|{3}|{4}|{5}' -f $_.DeviceName,$_.Primary,$_.Bounds.X,$_.Bounds.Y,$_.Bounds.Width,$_.Bounds.Height }`
+	out, err := exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command", script).Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list monitors: %v", err)
+	}
+
+	monitors := []CaptureDevice{}
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Split(strings.TrimSpace(line), "|")
+		if len(fields) != 6 {
+			continue
+		}
+		// \\.\DISPLAY1 -> DISPLAY1
+		id := strings.TrimPrefix(fields[0], `\\.\`)
+		monitors = append(monitors, CaptureDevice{
+			Kind:    DeviceMonitor,
+			ID:      id,
+			Name:    id,
+			Default: strings.EqualFold(fields[1], "True"),
+			X:       atoi(fields[2]),
+			Y:       atoi(fields[3]),
+			Width:   atoi(fields[4]),
+			Height:  atoi(fields[5]),
+		})
+	}
+	return monitors, nil
+}
+
+// desktopBounds is the bounding box of all monitors
+func desktopBounds(monitors []CaptureDevice) (CaptureDevice, bool) {
+	if len(monitors) == 0 {
+		return CaptureDevice{}, false
+	}
+	minX, minY := monitors[0].X, monitors[0].Y
+	maxX, maxY := monitors[0].X+monitors[0].Width, monitors[0].Y+monitors[0].Height
+	for _, m := range monitors[1:] {
+		minX = min(minX, m.X)
+		minY = min(minY, m.Y)
+		maxX = max(maxX, m.X+m.Width)
+		maxY = max(maxY, m.Y+m.Height)
+	}
+	return CaptureDevice{
+		Kind: DeviceDesktop, ID: "desktop", Name: "Tüm Masaüstü",
+		X: minX, Y: minY, Width: maxX - minX, Height: maxY - minY,
+	}, true
+}
+
+func atoi(s string) int {
+	n, _ := strconv.Atoi(strings.TrimSpace(s))
+	return n
+}
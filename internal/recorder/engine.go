@@ -0,0 +1,38 @@
+package recorder
+
+import "os/exec"
+
+// Recorder is the Start/Stop contract shared by the ffmpeg screen recorder and
+// the audio-only recorder, so App can drive whichever the board can run.
+type Recorder interface {
+	StartRecording(filename string) error
+	StopRecording() error
+	PauseRecording() error
+	ResumeRecording() error
+	IsRecording() bool
+	Status() RecorderStatus
+}
+
+// Recording engines, see RecordingConfig.Engine
+const (
+	EngineAuto   = ""       // ffmpeg when installed, else audio-only
+	EngineFFmpeg = "ffmpeg" // screen + microphone through ffmpeg
+	EngineAudio  = "audio"  // microphone only through PortAudio, no ffmpeg needed
+)
+
+// HasFFmpeg reports whether ffmpeg can be run on this board
+func HasFFmpeg() bool {
+	_, err := exec.LookPath("ffmpeg")
+	return err == nil
+}
+
+// ResolveEngine turns EngineAuto into the engine this board can run
+func ResolveEngine(engine string) string {
+	if engine == EngineAuto {
+		if HasFFmpeg() {
+			return EngineFFmpeg
+		}
+		return EngineAudio
+	}
+	return engine
+}
@@ -0,0 +1,207 @@
+package recorder
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// PreviewDir is the folder inside PublicDir that holds previews and posters
+const PreviewDir = "previews"
+
+// PreviewService turns finished recordings into a low-bitrate rendition and a
+// poster frame, so 30 students on the school Wi-Fi don't all pull the full MP4.
+// Jobs run one at a time in the background to keep the board responsive.
+type PreviewService struct {
+	PublicDir string
+	workDir   string // half-written previews, not served to the LAN
+
+	mu      sync.Mutex
+	pending []string      // recordings waiting for a preview, oldest first
+	wake    chan struct{} // signals the worker that pending grew
+}
+
+// NewPreviewService creates the service and starts its worker. Previews are
+// written in workDir and renamed into PublicDir when complete.
+func NewPreviewService(publicDir, workDir string) *PreviewService {
+	p := &PreviewService{
+		PublicDir: publicDir,
+		workDir:   filepath.Join(workDir, PreviewDir),
+		wake:      make(chan struct{}, 1),
+	}
+	// Leftovers of a job cut off by a restart; EnqueueMissing redoes it
+	os.RemoveAll(p.workDir)
+	go p.worker()
+	return p
+}
+
+// PreviewPaths returns the preview video and poster paths of a recording, relative to PublicDir
+func PreviewPaths(recording string) (video string, poster string) {
+	base := strings.TrimSuffix(filepath.Base(recording), filepath.Ext(recording))
+	return filepath.Join(PreviewDir, base+".mp4"), filepath.Join(PreviewDir, base+".jpg")
+}
+
+// Enqueue schedules a preview for a recording (filename relative to PublicDir).
+// The queue has no limit, so a busy day never loses a preview.
+func (p *PreviewService) Enqueue(filename string) {
+	p.mu.Lock()
+	for _, queued := range p.pending {
+		if queued == filename {
+			p.mu.Unlock()
+			return
+		}
+	}
+	p.pending = append(p.pending, filename)
+	p.mu.Unlock()
+
+	select {
+	case p.wake <- struct{}{}:
+	default: // the worker is already awake
+	}
+}
+
+// EnqueueMissing schedules previews for every recording that doesn't have one yet
+func (p *PreviewService) EnqueueMissing() {
+	matches, err := filepath.Glob(filepath.Join(p.PublicDir, "rec-*.mp4"))
+	if err != nil {
+		return
+	}
+	for _, match := range matches {
+		name := filepath.Base(match)
+		// Only the rendition is checked: audio-only recordings never get a poster
+		video, _ := PreviewPaths(name)
+		if !exists(filepath.Join(p.PublicDir, video)) {
+			p.Enqueue(name)
+		}
+	}
+}
+
+func (p *PreviewService) worker() {
+	for range p.wake {
+		for {
+			filename, ok := p.next()
+			if !ok {
+				break
+			}
+			if err := p.generate(filename); err != nil {
+				log.Printf("Preview generation failed for %s: %v", filename, err)
+				continue
+			}
+			log.Printf("Preview ready for %s", filename)
+		}
+	}
+}
+
+// next takes the oldest pending recording
+func (p *PreviewService) next() (string, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.pending) == 0 {
+		return "", false
+	}
+	filename := p.pending[0]
+	p.pending = p.pending[1:]
+	return filename, true
+}
+
+// generate runs ffmpeg twice: a 480p/15fps rendition and a single poster frame.
+// Outputs are written to the work folder and renamed so the server never serves half a file.
+func (p *PreviewService) generate(filename string) error {
+	input := filepath.Join(p.PublicDir, filename)
+	if !exists(input) {
+		return fmt.Errorf("recording not found")
+	}
+
+	if err := os.MkdirAll(filepath.Join(p.PublicDir, PreviewDir), 0755); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(p.workDir, 0755); err != nil {
+		return err
+	}
+
+	video, poster := PreviewPaths(filename)
+	videoPath := filepath.Join(p.PublicDir, video)
+	posterPath := filepath.Join(p.PublicDir, poster)
+
+	tmpVideo := filepath.Join(p.workDir, filepath.Base(video))
+
+	// Audio-only profile: a small mono rendition, no poster
+	if !hasVideo(input) {
+		err := runFFmpeg("-i", input, "-vn", "-c:a", "aac", "-b:a", "48k", "-ac", "1", "-movflags", "+faststart", "-y", tmpVideo)
+		if err != nil {
+			os.Remove(tmpVideo)
+			return err
+		}
+		return os.Rename(tmpVideo, videoPath)
+	}
+
+	err := runFFmpeg(
+		"-i", input,
+		"-vf", "scale=-2:480",
+		"-r", "15",
+		"-c:v", "libx264",
+		"-preset", "veryfast",
+		"-crf", "32",
+		"-maxrate", "400k",
+		"-bufsize", "800k",
+		"-c:a", "aac",
+		"-b:a", "64k",
+		"-ac", "1",
+		// moov atom first so browsers can start playing before the download ends
+		"-movflags", "+faststart",
+		"-threads", "1",
+		"-y", tmpVideo,
+	)
+	if err != nil {
+		os.Remove(tmpVideo)
+		return err
+	}
+	if err := os.Rename(tmpVideo, videoPath); err != nil {
+		return err
+	}
+
+	tmpPoster := filepath.Join(p.workDir, filepath.Base(poster))
+	err = runFFmpeg(
+		// Skip the first seconds, they are usually the teacher's desktop
+		"-ss", "3",
+		"-i", input,
+		"-frames:v", "1",
+		"-vf", "scale=-2:480",
+		"-q:v", "5",
+		"-y", tmpPoster,
+	)
+	if err != nil || !exists(tmpPoster) {
+		// Very short clips: fall back to the first frame
+		err = runFFmpeg("-i", input, "-frames:v", "1", "-vf", "scale=-2:480", "-q:v", "5", "-y", tmpPoster)
+		if err != nil {
+			os.Remove(tmpPoster)
+			return err
+		}
+	}
+	return os.Rename(tmpPoster, posterPath)
+}
+
+// runFFmpeg runs a one-shot ffmpeg job and includes its output in the error
+func runFFmpeg(args ...string) error {
+	args = append([]string{"-hide_banner", "-loglevel", "error"}, args...)
+	out, err := exec.Command("ffmpeg", args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("ffmpeg failed: %v: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// hasVideo reports whether the file has a video stream (audio-only profiles don't)
+func hasVideo(path string) bool {
+	out, err := exec.Command("ffprobe", "-v", "error", "-select_streams", "v", "-show_entries", "stream=index", "-of", "csv=p=0", path).Output()
+	return err == nil && strings.TrimSpace(string(out)) != ""
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,119 @@
+package recorder
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MediaInfo is what ffprobe tells us about a recording
+type MediaInfo struct {
+	Duration time.Duration
+	Width    int // 0 for audio-only recordings
+	Height   int
+	Size     int64
+}
+
+// ProbeMedia reads duration and resolution with ffprobe. Without ffprobe,
+// WAV files from the audio-only recorder are measured from their header.
+func ProbeMedia(path string) (MediaInfo, error) {
+	if _, err := exec.LookPath("ffprobe"); err != nil && strings.EqualFold(filepath.Ext(path), ".wav") {
+		return probeWAV(path)
+	}
+
+	out, err := exec.Command("ffprobe",
+		"-v", "error",
+		"-show_entries", "format=duration,size:stream=codec_type,width,height",
+		"-of", "json",
+		path,
+	).Output()
+	if err != nil {
+		return MediaInfo{}, fmt.Errorf("ffprobe failed: %v", err)
+	}
+
+	var probe struct {
+		Format struct {
+			Duration string `json:"duration"`
+			Size     string `json:"size"`
+		} `json:"format"`
+		Streams []struct {
+			CodecType string `json:"codec_type"`
+			Width     int    `json:"width"`
+			Height    int    `json:"height"`
+		} `json:"streams"`
+	}
+	if err := json.Unmarshal(out, &probe); err != nil {
+		return MediaInfo{}, fmt.Errorf("failed to parse ffprobe output: %v", err)
+	}
+
+	info := MediaInfo{}
+	if seconds, err := strconv.ParseFloat(probe.Format.Duration, 64); err == nil {
+		info.Duration = time.Duration(seconds * float64(time.Second))
+	}
+	info.Size, _ = strconv.ParseInt(probe.Format.Size, 10, 64)
+	for _, s := range probe.Streams {
+		if s.CodecType == "video" {
+			info.Width, info.Height = s.Width, s.Height
+			break
+		}
+	}
+	return info, nil
+}
+
+// probeWAV measures a PCM WAV file from its header
+func probeWAV(path string) (MediaInfo, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return MediaInfo{}, err
+	}
+	defer f.Close()
+
+	stat, err := f.Stat()
+	if err != nil {
+		return MediaInfo{}, err
+	}
+	header := make([]byte, 44)
+	if _, err := io.ReadFull(f, header); err != nil || string(header[0:4]) != "RIFF" || string(header[8:12]) != "WAVE" {
+		return MediaInfo{}, fmt.Errorf("not a WAV file: %s", path)
+	}
+
+	info := MediaInfo{Size: stat.Size()}
+	if byteRate := binary.LittleEndian.Uint32(header[28:32]); byteRate > 0 {
+		// The file size, not the header, so a WAV cut off by a power loss still measures right
+		seconds := float64(stat.Size()-44) / float64(byteRate)
+		info.Duration = time.Duration(seconds * float64(time.Second))
+	}
+	return info, nil
+}
+
+// ExtractFrame saves the frame at the given time as a JPEG scaled to height.
+// Seeking before -i keeps this fast even deep into a 40 minute lesson.
+func ExtractFrame(input string, at time.Duration, height int, output string) error {
+	if err := os.MkdirAll(filepath.Dir(output), 0755); err != nil {
+		return err
+	}
+	tmp := output + ".part.jpg"
+	err := runFFmpeg(
+		"-ss", fmt.Sprintf("%.3f", at.Seconds()),
+		"-i", input,
+		"-frames:v", "1",
+		"-vf", fmt.Sprintf("scale=-2:%d", height),
+		"-q:v", "5",
+		"-y", tmp,
+	)
+	if err != nil || !exists(tmp) {
+		os.Remove(tmp)
+		if err == nil {
+			err = fmt.Errorf("no frame at %s", at)
+		}
+		return err
+	}
+	return os.Rename(tmp, output)
+}
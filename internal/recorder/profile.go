@@ -0,0 +1,117 @@
+package recorder
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Profile is a named set of encoder settings. Profiles live in config.json so
+// schools can tune them per board without a rebuild.
+type Profile struct {
+	Name         string `json:"name"`         // stable id, e.g. "dusuk"
+	Label        string `json:"label"`        // shown to the teacher
+	AudioOnly    bool   `json:"audioOnly"`    // skip screen capture entirely
+	Framerate    int    `json:"framerate"`    // capture fps
+	VideoCodec   string `json:"videoCodec"`   // e.g. "libx264"
+	Preset       string `json:"preset"`       // x264 speed preset
+	CRF          int    `json:"crf"`          // constant quality, used when VideoBitrate is empty
+	VideoBitrate string `json:"videoBitrate"` // e.g. "1500k"; overrides CRF
+	ScaleHeight  int    `json:"scaleHeight"`  // downscale to this height, 0 = native
+	AudioCodec   string `json:"audioCodec"`   // e.g. "aac"
+	AudioBitrate string `json:"audioBitrate"` // e.g. "96k"
+}
+
+// DefaultProfileName is used when nothing has been chosen yet
+const DefaultProfileName = "standart"
+
+// DefaultProfiles returns the built-in profiles
+func DefaultProfiles() []Profile {
+	return []Profile{
+		{
+			// Same settings the recorder always used: fine for most i3 boards
+			Name: "standart", Label: "Standart",
+			Framerate: 15, VideoCodec: "libx264", Preset: "ultrafast", CRF: 23,
+			AudioCodec: "aac", AudioBitrate: "96k",
+		},
+		{
+			Name: "dusuk", Label: "Düşük (eski tahta)",
+			Framerate: 10, VideoCodec: "libx264", Preset: "ultrafast", CRF: 30, ScaleHeight: 720,
+			AudioCodec: "aac", AudioBitrate: "64k",
+		},
+		{
+			Name: "hd", Label: "HD",
+			Framerate: 25, VideoCodec: "libx264", Preset: "superfast", CRF: 21,
+			AudioCodec: "aac", AudioBitrate: "128k",
+		},
+		{
+			Name: "ses", Label: "Sadece Ses",
+			AudioOnly: true, AudioCodec: "aac", AudioBitrate: "96k",
+		},
+	}
+}
+
+// FindProfile looks up a profile by name, falling back to the default profile
+func FindProfile(profiles []Profile, name string) Profile {
+	if len(profiles) == 0 {
+		profiles = DefaultProfiles()
+	}
+	for _, p := range profiles {
+		if p.Name == name {
+			return p
+		}
+	}
+	for _, p := range profiles {
+		if p.Name == DefaultProfileName {
+			return p
+		}
+	}
+	return profiles[0]
+}
+
+// Validate reports settings ffmpeg would reject
+func (p Profile) Validate() error {
+	if p.Name == "" {
+		return fmt.Errorf("profile has no name")
+	}
+	if !p.AudioOnly && (p.Framerate <= 0 || p.VideoCodec == "") {
+		return fmt.Errorf("profile %s needs a framerate and video codec", p.Name)
+	}
+	return nil
+}
+
+// encodeArgs returns the ffmpeg output codec arguments for the profile
+func (p Profile) encodeArgs() []string {
+	args := []string{}
+
+	if p.AudioOnly {
+		args = append(args, "-vn")
+	} else {
+		args = append(args, "-c:v", p.VideoCodec)
+		if p.Preset != "" {
+			args = append(args, "-preset", p.Preset)
+		}
+		if p.VideoBitrate != "" {
+			args = append(args, "-b:v", p.VideoBitrate, "-maxrate", p.VideoBitrate, "-bufsize", p.VideoBitrate)
+		} else if p.CRF > 0 {
+			args = append(args, "-crf", strconv.Itoa(p.CRF))
+		}
+		// Window and region captures can have odd sizes, which yuv420p rejects
+		filters := "crop=trunc(iw/2)*2:trunc(ih/2)*2"
+		if p.ScaleHeight > 0 {
+			filters += fmt.Sprintf(",scale=-2:%d", p.ScaleHeight)
+		}
+		args = append(args, "-vf", filters)
+		// A keyframe every 2s bounds what a crash can lose to one fragment
+		args = append(args, "-g", strconv.Itoa(2*p.Framerate))
+		// Browsers only play 4:2:0
+		args = append(args, "-pix_fmt", "yuv420p")
+	}
+
+	if p.AudioCodec != "" {
+		args = append(args, "-c:a", p.AudioCodec)
+		if p.AudioBitrate != "" {
+			args = append(args, "-b:a", p.AudioBitrate)
+		}
+	}
+	return args
+}
@@ -0,0 +1,125 @@
+package recorder
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// progressInterval is how often "recording-progress" is emitted
+const progressInterval = time.Second
+
+// Progress holds the statistics ffmpeg reports with -progress
+type Progress struct {
+	Frames     int64   `json:"frames"`
+	FPS        float64 `json:"fps"`
+	Bitrate    string  `json:"bitrate"` // e.g. "1250.3kbits/s"
+	TotalSize  int64   `json:"totalSize"`
+	Speed      string  `json:"speed"` // below "1x" means the board can't keep up
+	DupFrames  int64   `json:"dupFrames"`
+	DropFrames int64   `json:"dropFrames"`
+}
+
+// RecorderStatus is the snapshot returned by Status and sent with recorder events
+type RecorderStatus struct {
+	State     string  `json:"state"` // "idle", "recording" or "paused"
+	Filename  string  `json:"filename"`
+	Elapsed   float64 `json:"elapsed"` // seconds recorded, pauses excluded
+	Takes     int     `json:"takes"`
+	LastError string  `json:"lastError"`
+	Progress
+}
+
+// Status returns the current recorder state
+func (r *RecorderService) Status() RecorderStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	status := RecorderStatus{
+		State:     "idle",
+		Filename:  r.filename,
+		Takes:     r.takes,
+		LastError: r.lastError,
+	}
+	if !r.recording {
+		return status
+	}
+
+	status.Elapsed = r.elapsedLocked().Seconds()
+
+	if r.paused {
+		status.State = "paused"
+	} else {
+		status.State = "recording"
+		status.Progress = r.progress
+	}
+	return status
+}
+
+// elapsedLocked returns the recorded time so far, pauses excluded. r.mu must be held.
+func (r *RecorderService) elapsedLocked() time.Duration {
+	elapsed := r.elapsed
+	if r.current != nil {
+		elapsed += time.Since(r.current.started)
+	}
+	return elapsed
+}
+
+// readProgress parses ffmpeg's "-progress pipe:1" output, a stream of
+// key=value blocks each terminated by "progress=continue" (or "=end"),
+// and emits "recording-progress" at most once per progressInterval.
+func (r *RecorderService) readProgress(stdout io.Reader) {
+	var block Progress
+	lastEmit := time.Time{}
+
+	scanner := bufio.NewScanner(stdout)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(strings.TrimSpace(scanner.Text()), "=")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "frame":
+			block.Frames, _ = strconv.ParseInt(value, 10, 64)
+		case "fps":
+			block.FPS, _ = strconv.ParseFloat(value, 64)
+		case "bitrate":
+			block.Bitrate = value
+		case "total_size":
+			block.TotalSize, _ = strconv.ParseInt(value, 10, 64)
+		case "speed":
+			block.Speed = value
+		case "dup_frames":
+			block.DupFrames, _ = strconv.ParseInt(value, 10, 64)
+		case "drop_frames":
+			block.DropFrames, _ = strconv.ParseInt(value, 10, 64)
+		case "progress":
+			r.mu.Lock()
+			r.progress = block
+			r.mu.Unlock()
+
+			if time.Since(lastEmit) >= progressInterval {
+				lastEmit = time.Now()
+				r.emit("recording-progress", r.Status())
+			}
+		}
+	}
+}
+
+// tailLog returns the last lines of the ffmpeg log, which name the actual failure
+func tailLog(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) > 5 {
+		lines = lines[len(lines)-5:]
+	}
+	return strings.Join(lines, "\n")
+}
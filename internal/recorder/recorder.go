@@ -1,157 +1,545 @@
 package recorder
 
 import (
+	"context"
 	"fmt"
+	"io"
+	"log"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"sort"
 	"strings"
-	"syscall"
+	"sync"
 	"time"
+
+	wailsruntime "github.com/wailsapp/wails/v2/pkg/runtime"
 )
 
+// fragmentedMovFlags makes ffmpeg write self-contained MP4 fragments instead of a
+// single moov atom at the end, so a file cut off by a power loss stays playable
+// up to the last complete fragment. Parts are remuxed to a regular MP4 on stop.
+const fragmentedMovFlags = "+frag_keyframe+empty_moov+default_base_moof"
+
+// fragmentDuration (µs) also cuts fragments without video keyframes, which
+// audio-only recordings never produce.
+const fragmentDuration = "2000000"
+
+// RecordingsDir is the folder inside the work folder that holds the segments
+// of unfinished recordings
+const RecordingsDir = "recordings"
+
 type RecorderService struct {
-	cmd         *exec.Cmd
-	IsRecording bool
-	PublicDir   string
+	PublicDir string
+	workDir   string // parts folders of running recordings, not served to the LAN
+
+	ctx  context.Context
+	opMu sync.Mutex // serializes Start/Pause/Resume/Stop, which can block for seconds
+	mu   sync.Mutex // guards everything below; never held while waiting on ffmpeg
+
+	current   *take // running ffmpeg, nil while idle or paused
+	recording bool
+	paused    bool
+	lastError string
+
+	// segmentDuration splits every take into files of this length so a power cut
+	// only loses the segment being written. Zero writes one file per take.
+	segmentDuration time.Duration
+	// profile selects framerate, codecs and quality (see DefaultProfiles)
+	profile Profile
+	// microphone is a CaptureDevice ID from ListCaptureDevices; empty uses the system default
+	microphone string
+	// capture selects the desktop, a monitor, a region or the app window
+	capture CaptureTarget
+	// lesson the next recording belongs to, kept in the parts folder for recovery
+	lesson RecordingLesson
+
+	filename   string        // final file name, relative to PublicDir
+	outputPath string        // final MP4 assembled on stop
+	partsDir   string        // hidden work folder holding the segments
+	takes      int           // incremented on every start/resume
+	elapsed    time.Duration // recorded time of finished takes
+	progress   Progress      // latest ffmpeg statistics of the current take
+	chapters   []Chapter     // marks reported by the frontend, see AddChapter
 }
 
-func NewRecorderService(publicDir string) *RecorderService {
+// take is one ffmpeg run; a recording has a new take after every resume
+type take struct {
+	cmd      *exec.Cmd
+	stdin    io.WriteCloser
+	started  time.Time
+	done     chan struct{} // closed once ffmpeg has exited
+	exited   bool          // guarded by RecorderService.mu
+	stopping bool          // guarded by RecorderService.mu; exit was requested
+	err      error
+}
+
+// NewRecorderService creates the service. Recordings are assembled from
+// segments in workDir and only the finished MP4 lands in PublicDir.
+func NewRecorderService(publicDir, workDir string) *RecorderService {
 	return &RecorderService{
-		IsRecording: false,
-		PublicDir:   publicDir,
+		PublicDir: publicDir,
+		workDir:   filepath.Join(workDir, RecordingsDir),
+		profile:   FindProfile(nil, DefaultProfileName),
 	}
 }
 
+// Startup stores the Wails context used for progress and error events
+func (r *RecorderService) Startup(ctx context.Context) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.ctx = ctx
+}
+
+// SetProfile selects the encoder settings of the next take
+func (r *RecorderService) SetProfile(p Profile) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.profile = p
+}
+
+// SetMicrophone selects the microphone of the next take
+func (r *RecorderService) SetMicrophone(deviceID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.microphone = deviceID
+}
+
+// SetCapture selects the screen area of the next take
+func (r *RecorderService) SetCapture(target CaptureTarget) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.capture = target
+}
+
+// SetLesson records which lesson the next recording belongs to
+func (r *RecorderService) SetLesson(className, lessonID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.lesson = RecordingLesson{ClassName: className, LessonID: lessonID}
+}
+
+// SetSegmentDuration sets the segment length of the next take (0 = off)
+func (r *RecorderService) SetSegmentDuration(d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.segmentDuration = d
+}
+
+// IsRecording reports whether a recording is in progress (including paused)
+func (r *RecorderService) IsRecording() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.recording
+}
+
 func (r *RecorderService) StartRecording(filename string) error {
-	if r.IsRecording {
+	r.opMu.Lock()
+	defer r.opMu.Unlock()
+
+	if r.IsRecording() {
 		return fmt.Errorf("recording already in progress")
 	}
 
 	outputPath := filepath.Join(r.PublicDir, filename)
+	base := strings.TrimSuffix(filename, filepath.Ext(filename))
+	partsDir := filepath.Join(r.workDir, "."+base+".parts")
+	if err := os.MkdirAll(partsDir, 0755); err != nil {
+		return fmt.Errorf("failed to create segment folder: %v", err)
+	}
 
-	// Ensure file doesn't exist? ffmpeg -y overwrites.
+	r.mu.Lock()
+	lesson := r.lesson
+	r.mu.Unlock()
+	if err := saveLesson(filepath.Join(partsDir, lessonFile), lesson); err != nil {
+		log.Printf("Failed to save the lesson of %s: %v", filename, err)
+	}
 
-	var cmd *exec.Cmd
+	r.mu.Lock()
+	r.filename = filename
+	r.outputPath = outputPath
+	r.partsDir = partsDir
+	r.takes = 0
+	r.elapsed = 0
+	r.chapters = nil
+	r.lastError = ""
+	r.mu.Unlock()
 
-	if runtime.GOOS == "windows" {
-		// Windows: gdigrab - reduced FPS for old i3/i5
-		cmd = exec.Command("ffmpeg", "-f", "gdigrab", "-framerate", "15", "-i", "desktop", "-preset", "ultrafast", "-y", outputPath)
-	} else if runtime.GOOS == "linux" {
-		// Linux: x11grab + pulse
-
-		// 1. Detect Display
-		display := os.Getenv("DISPLAY")
-		if display == "" {
-			display = ":0"
-		}
+	if err := r.startTake(); err != nil {
+		os.RemoveAll(partsDir)
+		return err
+	}
 
-		// 2. Detect Resolution
-		// Default to 1920x1080 if detection fails
-		resolution := "1920x1080"
-
-		out, err := exec.Command("xrandr").Output()
-		if err == nil {
-			// Parse output for the mode with '*'
-			// Example line: "   1920x1200    164.87*+"
-			lines := strings.Split(string(out), "\n")
-			for _, line := range lines {
-				if strings.Contains(line, "*") {
-					fields := strings.Fields(line)
-					if len(fields) > 0 {
-						resolution = fields[0]
-						break
-					}
-				}
-			}
-		}
+	r.mu.Lock()
+	r.recording = true
+	r.paused = false
+	r.mu.Unlock()
+	return nil
+}
+
+// PauseRecording closes the current segment; nothing is captured until ResumeRecording
+func (r *RecorderService) PauseRecording() error {
+	r.opMu.Lock()
+	defer r.opMu.Unlock()
+
+	r.mu.Lock()
+	if !r.recording {
+		r.mu.Unlock()
+		return fmt.Errorf("no recording in progress")
+	}
+	if r.paused {
+		r.mu.Unlock()
+		return fmt.Errorf("recording already paused")
+	}
+	r.paused = true
+	r.mu.Unlock()
+
+	r.stopFFmpeg()
+	return nil
+}
+
+// ResumeRecording starts a new take that is appended to the paused recording.
+// It also restarts a recording whose ffmpeg died unexpectedly.
+func (r *RecorderService) ResumeRecording() error {
+	r.opMu.Lock()
+	defer r.opMu.Unlock()
+
+	r.mu.Lock()
+	if !r.recording {
+		r.mu.Unlock()
+		return fmt.Errorf("no recording in progress")
+	}
+	if !r.paused {
+		r.mu.Unlock()
+		return fmt.Errorf("recording is not paused")
+	}
+	r.mu.Unlock()
+
+	if err := r.startTake(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	r.paused = false
+	r.lastError = ""
+	r.mu.Unlock()
+	return nil
+}
+
+func (r *RecorderService) StopRecording() error {
+	r.opMu.Lock()
+	defer r.opMu.Unlock()
+
+	if !r.IsRecording() {
+		return fmt.Errorf("no recording in progress")
+	}
+
+	r.stopFFmpeg()
+
+	r.mu.Lock()
+	r.recording = false
+	r.paused = false
+	chapters := append([]Chapter(nil), r.chapters...)
+	r.mu.Unlock()
+
+	if err := r.joinSegments(); err != nil {
+		return err
+	}
+	// The recording itself is fine without chapters, so this never fails the stop
+	if err := writeChapters(r.outputPath, chapters); err != nil {
+		log.Printf("Failed to write chapters: %v", err)
+	}
+	return nil
+}
+
+// startTake launches ffmpeg for the next take, writing into partsDir
+func (r *RecorderService) startTake() error {
+	r.mu.Lock()
+	r.takes++
+	takeNo, filename := r.takes, r.filename
+	profile, microphone, capture := r.profile, r.microphone, r.capture
+	segmentDuration, partsDir := r.segmentDuration, r.partsDir
+	r.mu.Unlock()
 
-		fmt.Printf("Recording on Display: %s with Resolution: %s\n", display, resolution)
-
-		cmd = exec.Command("ffmpeg",
-			"-f", "x11grab",
-			"-video_size", resolution,
-			"-framerate", "15",
-			"-i", display,
-			"-f", "pulse",
-			"-i", "default",
-			"-c:v", "libx264",
-			"-preset", "ultrafast",
-			"-c:a", "aac",
-			"-y", outputPath,
+	if err := profile.Validate(); err != nil {
+		return err
+	}
+	if err := capture.Validate(); err != nil {
+		return err
+	}
+
+	args, err := captureArgs(profile, microphone, capture)
+	if err != nil {
+		return err
+	}
+	// Machine readable statistics on stdout (see readProgress); the log keeps errors only
+	args = append([]string{"-progress", "pipe:1", "-nostats"}, args...)
+	args = append(args, profile.encodeArgs()...)
+
+	// take-01-000.mp4, take-01-001.mp4, take-02-000.mp4 ... see listSegments for the order
+	pattern := filepath.Join(partsDir, fmt.Sprintf("take-%02d-%%03d.mp4", takeNo))
+	if segmentDuration > 0 {
+		seconds := fmt.Sprintf("%d", int(segmentDuration.Seconds()))
+		if !profile.AudioOnly {
+			// Keyframe exactly on every boundary so segments cut cleanly
+			args = append(args, "-force_key_frames", "expr:gte(t,n_forced*"+seconds+")")
+		}
+		args = append(args,
+			"-f", "segment",
+			"-segment_time", seconds,
+			"-segment_format", "mp4",
+			"-segment_format_options", "movflags="+fragmentedMovFlags+":frag_duration="+fragmentDuration,
+			"-reset_timestamps", "1",
+			"-y", pattern,
 		)
 	} else {
-		return fmt.Errorf("unsupported OS for recording")
+		args = append(args, "-movflags", fragmentedMovFlags, "-frag_duration", fragmentDuration, "-y", fmt.Sprintf(pattern, 0))
 	}
 
-	// Capture stdout/stderr for debugging
-	logFile, err := os.Create(filepath.Join(r.PublicDir, "ffmpeg_log.txt"))
+	cmd := exec.Command("ffmpeg", args...)
+
+	// Capture stderr for debugging. A new recording starts a fresh log; later
+	// takes append, so the log of a failed take survives the resume after it.
+	logFlags := os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	if takeNo == 1 {
+		logFlags |= os.O_TRUNC
+	}
+	logFile, err := os.OpenFile(r.logPath(), logFlags, 0644)
 	if err == nil {
-		cmd.Stdout = logFile
+		fmt.Fprintf(logFile, "=== %s, take %d, %s ===\n", filename, takeNo, time.Now().Format(time.RFC3339))
 		cmd.Stderr = logFile
 		defer logFile.Close()
 	}
 
-	r.cmd = cmd
+	// ffmpeg quits cleanly when it reads 'q'; this works on Windows where SIGINT doesn't
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return fmt.Errorf("failed to open ffmpeg stdin: %v", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("failed to open ffmpeg stdout: %v", err)
+	}
+
 	if err := cmd.Start(); err != nil {
 		return fmt.Errorf("failed to start ffmpeg: %v", err)
 	}
 
-	// Verification: Wait a bit to ensure it doesn't crash immediately.
-	// Instead of calling Wait() which blocks and consumes the status,
-	// we simply sleep and check if process is still alive.
-	time.Sleep(500 * time.Millisecond)
+	t := &take{cmd: cmd, stdin: stdin, started: time.Now(), done: make(chan struct{})}
+	go r.watch(t, stdout)
 
-	// Check if process is alive by sending signal 0 (Linux/Unix only)
-	if runtime.GOOS != "windows" {
-		if err := r.cmd.Process.Signal(syscall.Signal(0)); err != nil {
-			// Process likely dead.
-			r.cmd.Wait() // Clean up resources
-			r.cmd = nil
-			r.IsRecording = false
-			return fmt.Errorf("ffmpeg exited immediately (check logs)")
-		}
+	// Verification: Wait a bit to ensure it doesn't crash immediately
+	// (wrong device name, unsupported capture area...)
+	select {
+	case <-t.done:
+	case <-time.After(500 * time.Millisecond):
 	}
 
-	r.IsRecording = true
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if t.exited {
+		return fmt.Errorf("ffmpeg exited immediately (check logs): %s", tailLog(r.logPath()))
+	}
+	r.current = t
+	r.progress = Progress{}
 	return nil
 }
 
-func (r *RecorderService) StopRecording() error {
-	if !r.IsRecording || r.cmd == nil {
-		return fmt.Errorf("no recording in progress")
+// watch follows one take until ffmpeg exits. An exit nobody asked for means the
+// recording broke mid-lesson: the recorder switches to paused (the segments so
+// far are kept) and the teacher is told right away instead of after the lesson.
+func (r *RecorderService) watch(t *take, stdout io.Reader) {
+	// All output must be read before Wait
+	r.readProgress(stdout)
+	err := t.cmd.Wait()
+
+	r.mu.Lock()
+	t.exited = true
+	t.err = err
+	unexpected := r.current == t && !t.stopping
+	if r.current == t {
+		r.elapsed += time.Since(t.started)
+		r.current = nil
+	}
+	if unexpected {
+		r.paused = true
+		r.lastError = fmt.Sprintf("ffmpeg stopped unexpectedly: %v", err)
+	}
+	r.mu.Unlock()
+	close(t.done)
+
+	if unexpected {
+		fmt.Printf("Recording failed: %v\n", err)
+		r.emit("recording-error", map[string]interface{}{
+			"message": fmt.Sprintf("Kayıt beklenmedik şekilde durdu: %v", err),
+			"log":     tailLog(r.logPath()),
+			"status":  r.Status(),
+		})
+	}
+}
+
+// captureArgs returns the platform specific ffmpeg input arguments
+func captureArgs(profile Profile, microphone string, target CaptureTarget) ([]string, error) {
+	if runtime.GOOS != "windows" && runtime.GOOS != "linux" {
+		return nil, fmt.Errorf("unsupported OS for recording")
+	}
+
+	args := []string{}
+	if !profile.AudioOnly {
+		video, err := screenArgs(target, fmt.Sprintf("%d", profile.Framerate))
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, video...)
 	}
 
-	// Send Interrupt signal (SIGINT) to tell ffmpeg to stop and save gracefully
-	if err := r.cmd.Process.Signal(os.Interrupt); err != nil {
-		// Process might already be dead, try to wait anyway
-		r.cmd.Wait()
-		r.IsRecording = false
-		r.cmd = nil
-		return fmt.Errorf("failed to signal process: %v", err)
+	if runtime.GOOS == "windows" {
+		// dshow has no "default" device, so an audio-only recording needs a real name
+		if microphone == "" && profile.AudioOnly {
+			mics, err := listDshowAudio()
+			if err != nil || len(mics) == 0 {
+				return nil, fmt.Errorf("no microphone found")
+			}
+			microphone = mics[0].ID
+		}
+		if microphone != "" {
+			args = append(args, "-f", "dshow", "-i", "audio="+microphone)
+		}
+		return args, nil
 	}
 
-	// Wait for process to exit, but with a timeout
-	// Use a channel to wait for the process
-	done := make(chan error, 1)
-	go func() {
-		done <- r.cmd.Wait()
-	}()
+	// Linux: pulse
+	if microphone == "" {
+		microphone = "default"
+	}
+	return append(args, "-f", "pulse", "-i", microphone), nil
+}
+
+// stopFFmpeg asks the running ffmpeg to finish its file and waits for it
+func (r *RecorderService) stopFFmpeg() {
+	r.mu.Lock()
+	t := r.current
+	if t != nil {
+		t.stopping = true
+	}
+	r.mu.Unlock()
+	if t == nil {
+		return
+	}
+
+	// Tell ffmpeg to stop and save gracefully
+	if _, err := io.WriteString(t.stdin, "q"); err != nil {
+		// stdin closed; fall back to SIGINT (no-op on Windows, Kill below covers it)
+		t.cmd.Process.Signal(os.Interrupt)
+	}
 
 	// Wait up to 5 seconds for graceful shutdown
 	select {
-	case err := <-done:
+	case <-t.done:
 		// Process exited gracefully
-		fmt.Printf("Recording stopped gracefully: %v\n", err)
+		fmt.Printf("Recording stopped gracefully: %v\n", t.err)
 	case <-time.After(5 * time.Second):
 		// Timeout - force kill
 		fmt.Println("Timeout waiting for ffmpeg to stop, force killing...")
-		r.cmd.Process.Kill()
-		<-done // Wait for the killed process to finish
+		t.cmd.Process.Kill()
+		<-t.done // Wait for the killed process to finish
+	}
+}
+
+func (r *RecorderService) logPath() string {
+	return filepath.Join(r.PublicDir, "ffmpeg_log.txt")
+}
+
+// emit sends a Wails event once Startup has provided a context
+func (r *RecorderService) emit(name string, data interface{}) {
+	r.mu.Lock()
+	ctx := r.ctx
+	r.mu.Unlock()
+	if ctx != nil {
+		wailsruntime.EventsEmit(ctx, name, data)
+	}
+}
+
+// joinSegments remuxes all takes/segments into the final MP4 without
+// re-encoding. The parts are kept if anything goes wrong so nothing is lost.
+func (r *RecorderService) joinSegments() error {
+	segments, err := listSegments(r.partsDir)
+	if err != nil {
+		return err
+	}
+	if len(segments) == 0 {
+		os.RemoveAll(r.partsDir)
+		return fmt.Errorf("recording produced no video (check logs)")
+	}
+
+	if err := concatSegments(segments, r.partsDir, r.outputPath); err != nil {
+		return fmt.Errorf("failed to join segments (kept in %s): %v", r.partsDir, err)
+	}
+	os.RemoveAll(r.partsDir)
+	return nil
+}
+
+// concatSegments joins fragmented parts into one regular, faststart MP4 at outputPath.
+// Even a single part goes through ffmpeg so the final file has a normal moov atom.
+func concatSegments(segments []string, workDir, outputPath string) error {
+	listPath := filepath.Join(workDir, "concat.txt")
+	var list strings.Builder
+	for _, seg := range segments {
+		// concat demuxer quoting: ' -> '\''
+		list.WriteString("file '" + strings.ReplaceAll(seg, "'", `'\''`) + "'\n")
+	}
+	if err := os.WriteFile(listPath, []byte(list.String()), 0644); err != nil {
+		return fmt.Errorf("failed to write concat list: %v", err)
 	}
 
-	r.IsRecording = false
-	r.cmd = nil
+	tmpOut := filepath.Join(workDir, "joined.mp4")
+	err := runFFmpeg(
+		"-f", "concat",
+		"-safe", "0",
+		"-i", listPath,
+		"-c", "copy",
+		"-movflags", "+faststart",
+		"-y", tmpOut,
+	)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmpOut, outputPath); err != nil {
+		return fmt.Errorf("failed to move recording: %v", err)
+	}
 	return nil
 }
+
+// listSegments lists the non-empty parts of a recording in recording order.
+// Take and segment numbers are compared as numbers: past 99 pauses
+// "take-100" sorts after "take-99", not before "take-11".
+func listSegments(partsDir string) ([]string, error) {
+	matches, err := filepath.Glob(filepath.Join(partsDir, "take-*.mp4"))
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		ti, si := segmentNumber(matches[i])
+		tj, sj := segmentNumber(matches[j])
+		if ti != tj {
+			return ti < tj
+		}
+		return si < sj
+	})
+
+	segments := []string{}
+	for _, m := range matches {
+		if info, err := os.Stat(m); err == nil && info.Size() > 0 {
+			segments = append(segments, m)
+		}
+	}
+	return segments, nil
+}
+
+// segmentNumber reads the take and segment of "take-03-012.mp4"
+func segmentNumber(path string) (take, segment int) {
+	fmt.Sscanf(filepath.Base(path), "take-%d-%d.mp4", &take, &segment)
+	return take, segment
+}
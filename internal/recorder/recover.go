@@ -0,0 +1,156 @@
+package recorder
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// lessonFile is kept in the parts folder so a recovered recording is filed
+// under the lesson it was recorded in, not the one open after the restart
+const lessonFile = "lesson.json"
+
+// RecordingLesson is the lesson a recording belongs to
+type RecordingLesson struct {
+	ClassName string `json:"className"`
+	LessonID  string `json:"lessonId"`
+}
+
+// RecoveredRecording is a recording salvaged by RecoverOrphans
+type RecoveredRecording struct {
+	Filename   string // relative to PublicDir
+	RecordedAt time.Time
+	RecordingLesson
+}
+
+// RecoverOrphans repairs recordings left behind by a crash or power cut.
+// Any ".rec-*.parts" folder found in the work folder at startup belongs to a
+// recording that was never stopped; its fragmented segments are salvaged one
+// by one and joined into "rec-*.mp4". The lesson comes from the folder; it is empty for
+// recordings made before lessons were stored there.
+// Call it before recording is enabled: StartRecording waits until it returns,
+// and a recording already running is never touched.
+func (r *RecorderService) RecoverOrphans() ([]RecoveredRecording, error) {
+	r.opMu.Lock()
+	defer r.opMu.Unlock()
+
+	dirs, err := filepath.Glob(filepath.Join(r.workDir, ".rec-*.parts"))
+	if err != nil {
+		return nil, err
+	}
+	// Earlier versions kept the parts next to the recordings
+	legacy, err := filepath.Glob(filepath.Join(r.PublicDir, ".rec-*.parts"))
+	if err != nil {
+		return nil, err
+	}
+	dirs = append(dirs, legacy...)
+	r.mu.Lock()
+	active := ""
+	if r.recording {
+		active = r.partsDir
+	}
+	r.mu.Unlock()
+
+	recovered := []RecoveredRecording{}
+	for _, dir := range dirs {
+		if dir == active {
+			continue
+		}
+		lesson := loadLesson(filepath.Join(dir, lessonFile))
+		recordedAt := recordingTime(dir)
+		name, err := recoverParts(r.PublicDir, dir)
+		if err != nil {
+			log.Printf("Could not recover %s: %v", dir, err)
+			continue
+		}
+		log.Printf("Recovered interrupted recording: %s", name)
+		recovered = append(recovered, RecoveredRecording{Filename: name, RecordedAt: recordedAt, RecordingLesson: lesson})
+	}
+	return recovered, nil
+}
+
+// recordingTime reads the start time from a parts folder name,
+// ".rec-2006-01-02-15-04-05.parts"; the folder's own time if it doesn't parse
+func recordingTime(partsDir string) time.Time {
+	stamp := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(partsDir), ".rec-"), ".parts")
+	if t, err := time.ParseInLocation("2006-01-02-15-04-05", stamp, time.Local); err == nil {
+		return t
+	}
+	if info, err := os.Stat(partsDir); err == nil {
+		return info.ModTime()
+	}
+	return time.Now()
+}
+
+func saveLesson(path string, lesson RecordingLesson) error {
+	data, err := json.Marshal(lesson)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+func loadLesson(path string) RecordingLesson {
+	var lesson RecordingLesson
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return lesson
+	}
+	if err := json.Unmarshal(data, &lesson); err != nil {
+		log.Printf("Ignoring damaged %s: %v", path, err)
+	}
+	return lesson
+}
+
+func recoverParts(publicDir, partsDir string) (string, error) {
+	segments, err := listSegments(partsDir)
+	if err != nil {
+		return "", err
+	}
+
+	// ".rec-2006-01-02-15-04-05.parts" -> "rec-2006-01-02-15-04-05.mp4"
+	base := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(partsDir), "."), ".parts")
+	name := base + ".mp4"
+	if exists(filepath.Join(publicDir, name)) {
+		name = base + "-kurtarilan.mp4"
+	}
+
+	// The last segment was usually cut mid-fragment. Remux each one on its own,
+	// ignoring decode errors, so one damaged tail doesn't sink the whole concat.
+	repaired := []string{}
+	for i, seg := range segments {
+		fixed := filepath.Join(partsDir, fmt.Sprintf("fixed-%03d.mp4", i))
+		err := runFFmpeg(
+			"-err_detect", "ignore_err",
+			"-fflags", "+genpts+discardcorrupt",
+			"-i", seg,
+			"-c", "copy",
+			"-y", fixed,
+		)
+		if err != nil {
+			log.Printf("Skipping unrecoverable segment %s: %v", seg, err)
+			os.Remove(fixed)
+			continue
+		}
+		repaired = append(repaired, fixed)
+	}
+
+	if len(repaired) == 0 {
+		// Nothing playable: leave the folder for manual inspection rather than delete footage
+		return "", fmt.Errorf("no playable segments")
+	}
+
+	output := filepath.Join(publicDir, name)
+	if err := concatSegments(repaired, partsDir, output); err != nil {
+		return "", err
+	}
+	if err := writeChapters(output, loadChapters(filepath.Join(partsDir, chaptersFile))); err != nil {
+		log.Printf("Failed to restore chapters of %s: %v", name, err)
+	}
+	os.RemoveAll(partsDir)
+	return name, nil
+}
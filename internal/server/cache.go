@@ -0,0 +1,66 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// fileValidators adds ETag and Cache-Control to static files and answers
+// conditional requests. Range requests and Last-Modified are handled by
+// fasthttp's file server; this fills in what it lacks so students scrubbing
+// a recording revalidate instead of downloading it again.
+func (s *ServerService) fileValidators(c *fiber.Ctx) error {
+	if c.Method() != fiber.MethodGet && c.Method() != fiber.MethodHead {
+		return c.Next()
+	}
+
+	// URI().Path() is already unescaped and normalized (no "..")
+	rel := filepath.FromSlash(strings.TrimPrefix(string(c.Context().URI().Path()), "/"))
+	info, err := os.Stat(filepath.Join(s.PublicDir, rel))
+	if err != nil || info.IsDir() {
+		return c.Next()
+	}
+
+	etag := fmt.Sprintf(`"%x-%x"`, info.Size(), info.ModTime().UnixNano())
+	c.Set(fiber.HeaderETag, etag)
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+
+	if match := c.Get(fiber.HeaderIfNoneMatch); match != "" && etagMatches(match, etag) {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+
+	// If-Range: only honour the Range header when the client's copy is still current,
+	// otherwise a preview regenerated mid-download would be stitched from two files.
+	if ifRange := c.Get(fiber.HeaderIfRange); ifRange != "" && c.Get(fiber.HeaderRange) != "" {
+		if !ifRangeMatches(ifRange, etag, info.ModTime()) {
+			c.Request().Header.Del(fiber.HeaderRange)
+		}
+	}
+
+	return c.Next()
+}
+
+// etagMatches implements the weak comparison used by If-None-Match
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+func ifRangeMatches(header, etag string, modTime time.Time) bool {
+	if strings.HasPrefix(header, `"`) {
+		return header == etag
+	}
+	t, err := http.ParseTime(header)
+	return err == nil && modTime.Truncate(time.Second).Equal(t)
+}
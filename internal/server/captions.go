@@ -0,0 +1,152 @@
+package server
+
+import (
+	"sync"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// captionHistory is how many lines a student opening /altyazi late can scroll back
+const captionHistory = 200
+
+// Caption is a line on the LAN captions page
+type Caption struct {
+	Seq  int    `json:"seq"`
+	Text string `json:"text"`
+	Time string `json:"time"` // 15:04:05, when it was said
+}
+
+// captionFeed holds what the LAN captions page shows
+type captionFeed struct {
+	mu      sync.Mutex
+	enabled bool
+	lines   []Caption
+	partial string // words still being spoken
+	next    int    // sequence number of the next line, unique per server run
+}
+
+// captionsResponse is polled by the captions page
+type captionsResponse struct {
+	Enabled bool      `json:"enabled"`
+	Lines   []Caption `json:"lines"`
+	Partial string    `json:"partial"`
+	Last    int       `json:"last"`
+}
+
+// SetCaptionsEnabled shares live captions on /altyazi or stops sharing them.
+// Turning it off also clears the lines students can still see.
+func (s *ServerService) SetCaptionsEnabled(enabled bool) {
+	s.captions.mu.Lock()
+	defer s.captions.mu.Unlock()
+	s.captions.enabled = enabled
+	if !enabled {
+		s.captions.lines = nil
+		s.captions.partial = ""
+	}
+}
+
+// PublishCaption shows a finished caption line to the students; ignored while sharing is off
+func (s *ServerService) PublishCaption(text, spokenAt string) {
+	s.captions.mu.Lock()
+	defer s.captions.mu.Unlock()
+	if !s.captions.enabled {
+		return
+	}
+	s.captions.next++
+	s.captions.lines = append(s.captions.lines, Caption{Seq: s.captions.next, Text: text, Time: spokenAt})
+	if len(s.captions.lines) > captionHistory {
+		s.captions.lines = s.captions.lines[len(s.captions.lines)-captionHistory:]
+	}
+	s.captions.partial = ""
+}
+
+// PublishPartialCaption shows the words of the sentence still being spoken
+func (s *ServerService) PublishPartialCaption(text string) {
+	s.captions.mu.Lock()
+	defer s.captions.mu.Unlock()
+	if s.captions.enabled {
+		s.captions.partial = text
+	}
+}
+
+// handleCaptionsPage serves the full-screen captions page
+func (s *ServerService) handleCaptionsPage(c *fiber.Ctx) error {
+	c.Type("html", "utf-8")
+	return c.SendString(captionsPage)
+}
+
+// handleCaptionsFeed returns the lines after ?sonra=<seq> for the page to append
+func (s *ServerService) handleCaptionsFeed(c *fiber.Ctx) error {
+	after := c.QueryInt("sonra", 0)
+
+	s.captions.mu.Lock()
+	resp := captionsResponse{
+		Enabled: s.captions.enabled,
+		Lines:   []Caption{},
+		Partial: s.captions.partial,
+		Last:    s.captions.next,
+	}
+	for _, line := range s.captions.lines {
+		if line.Seq > after {
+			resp.Lines = append(resp.Lines, line)
+		}
+	}
+	s.captions.mu.Unlock()
+
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	return c.JSON(resp)
+}
+
+const captionsPage = `<!DOCTYPE html>
+<html lang="tr">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Canlı Altyazı</title>
+<style>
+body { font-family: sans-serif; margin: 0; background: #101820; color: #fff; }
+header { padding: .6em 1em; background: #1b2636; display: flex; justify-content: space-between; align-items: center; }
+header button { font-size: 1em; }
+#lines, #live { padding: 0 1em; font-size: 1.6em; line-height: 1.5; }
+#lines { padding-top: 1em; }
+#lines p { margin: 0 0 .6em; }
+#lines time { color: #8a96a6; font-size: .55em; margin-right: .6em; font-variant-numeric: tabular-nums; }
+#partial { color: #b8c2cf; font-style: italic; }
+#off { padding: 2em 1em; color: #8a96a6; }
+body.big #lines, body.big #live { font-size: 2.4em; }
+</style>
+</head>
+<body>
+<header><strong>Canlı Altyazı</strong><button id="size">Yazı boyutu</button></header>
+<div id="off" hidden>Öğretmen altyazıyı şu an paylaşmıyor.</div>
+<div id="lines"></div>
+<div id="live"><p id="partial"></p></div>
+<script>
+var last = 0, box = document.getElementById("lines"), partial = document.getElementById("partial");
+document.getElementById("size").onclick = function () { document.body.classList.toggle("big"); };
+function follow() {
+  return window.innerHeight + window.scrollY >= document.body.scrollHeight - 40;
+}
+function poll() {
+  fetch("/altyazi/satirlar?sonra=" + last).then(function (r) { return r.json(); }).then(function (data) {
+    var atEnd = follow();
+    document.getElementById("off").hidden = data.enabled;
+    // The board restarted or sharing was turned off: start over
+    if (data.last < last || !data.enabled) { box.textContent = ""; last = 0; }
+    data.lines.forEach(function (line) {
+      var p = document.createElement("p"), t = document.createElement("time");
+      t.textContent = line.time;
+      p.appendChild(t);
+      p.appendChild(document.createTextNode(line.text));
+      box.appendChild(p);
+      last = line.seq;
+    });
+    partial.textContent = data.partial;
+    if (atEnd) window.scrollTo(0, document.body.scrollHeight);
+  }).catch(function () {}).then(function () { setTimeout(poll, 1000); });
+}
+poll();
+</script>
+</body>
+</html>
+`
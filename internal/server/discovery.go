@@ -0,0 +1,297 @@
+package server
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/mdns"
+)
+
+// ServiceType is the DNS-SD service every DersDostu board announces
+const ServiceType = "_dersdostu._tcp"
+
+// clashCheckTimeout is how long Advertise listens for a board already using its name
+const clashCheckTimeout = time.Second
+
+// BoardInfo describes a board on the LAN. It is published in the TXT records
+// of the mDNS announcement and returned by DiscoverBoards.
+type BoardInfo struct {
+	School    string `json:"school"`
+	ClassName string `json:"className"`
+	BoardName string `json:"boardName"`
+	ID        string `json:"id"`   // from the network card, tells boards with the same class apart
+	Host      string `json:"host"` // e.g. "sinif-9a.local"
+	Addr      string `json:"addr"`
+	Port      int    `json:"port"`
+	URL       string `json:"url"`
+}
+
+// Advertise announces the board via multicast DNS as ServiceType and as
+// "<HostnameFor(class)>.local", replacing any previous announcement. If
+// another board already uses that name (two boards of the same class, or two
+// unconfigured ones), the board ID is appended so they don't hide each other.
+func (s *ServerService) Advertise(info BoardInfo) error {
+	s.StopAdvertising()
+
+	ips := LocalIPs()
+	if len(ips) == 0 {
+		return fmt.Errorf("no LAN address found to advertise")
+	}
+
+	id := BoardID()
+	host := HostnameFor(info.ClassName)
+	instance := info.BoardName
+	if instance == "" {
+		instance = host
+	}
+	if hostTaken(host, id) {
+		log.Printf("Another board is %s.local, adding the board ID to the name", host)
+		host += "-" + id
+		instance += " (" + id + ")"
+	}
+
+	txt := []string{
+		"id=" + id,
+		"class=" + info.ClassName,
+		"board=" + info.BoardName,
+		"school=" + info.School,
+		"path=/",
+	}
+
+	service, err := mdns.NewMDNSService(instance, ServiceType, "local.", host+".local.", Port, ips, txt)
+	if err != nil {
+		return fmt.Errorf("failed to create mDNS service: %v", err)
+	}
+
+	responder, err := mdns.NewServer(&mdns.Config{Zone: service})
+	if err != nil {
+		return fmt.Errorf("failed to start mDNS responder: %v", err)
+	}
+
+	s.mu.Lock()
+	s.mdns = responder
+	s.host = host + ".local"
+	s.mu.Unlock()
+
+	log.Printf("Advertising %s as http://%s.local:%d (%s)", ServiceType, host, Port, ips[0])
+	return nil
+}
+
+// StopAdvertising withdraws the mDNS announcement
+func (s *ServerService) StopAdvertising() {
+	s.mu.Lock()
+	responder := s.mdns
+	s.mdns = nil
+	s.host = ""
+	s.mu.Unlock()
+
+	if responder != nil {
+		responder.Shutdown()
+	}
+}
+
+// Host returns the advertised mDNS name, e.g. "sinif-9a.local", or "" while not advertising
+func (s *ServerService) Host() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.host
+}
+
+// BaseURL returns the address students should type to reach this board:
+// the mDNS name, or the LAN address while the board isn't advertised
+func (s *ServerService) BaseURL() string {
+	if host := s.Host(); host != "" {
+		return fmt.Sprintf("http://%s:%d", host, Port)
+	}
+	if ips := LocalIPs(); len(ips) > 0 {
+		return fmt.Sprintf("http://%s:%d", ips[0], Port)
+	}
+	return fmt.Sprintf("http://localhost:%d", Port)
+}
+
+// hostTaken asks the LAN whether a board other than id announces host
+func hostTaken(host, id string) bool {
+	boards, err := DiscoverBoards(clashCheckTimeout)
+	if err != nil {
+		log.Printf("Could not check whether %s.local is taken: %v", host, err)
+		return false
+	}
+	for _, board := range boards {
+		if board.ID != id && strings.EqualFold(board.Host, host+".local") {
+			return true
+		}
+	}
+	return false
+}
+
+// DiscoverBoards browses the LAN for other DersDostu boards
+func DiscoverBoards(timeout time.Duration) ([]BoardInfo, error) {
+	entries := make(chan *mdns.ServiceEntry, 32)
+	params := mdns.DefaultParams(ServiceType)
+	params.Timeout = timeout
+	params.Entries = entries
+	params.DisableIPv6 = true // school networks are IPv4 only, and v6 sockets fail on some boards
+
+	// Read answers while the query runs; a full channel drops boards
+	boards := []BoardInfo{}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		seen := map[string]bool{}
+		for entry := range entries {
+			if entry.AddrV4 == nil || seen[entry.Name] {
+				continue
+			}
+			seen[entry.Name] = true
+			boards = append(boards, boardFromEntry(entry))
+		}
+	}()
+
+	err := mdns.Query(params)
+	close(entries)
+	<-done
+	if err != nil {
+		return nil, fmt.Errorf("mDNS query failed: %v", err)
+	}
+	return boards, nil
+}
+
+// boardFromEntry reads a board's announcement. The URL uses the address,
+// since Windows resolves .local names only on some networks.
+func boardFromEntry(entry *mdns.ServiceEntry) BoardInfo {
+	board := BoardInfo{
+		Host: strings.TrimSuffix(entry.Host, "."),
+		Addr: entry.AddrV4.String(),
+		Port: entry.Port,
+	}
+	for _, field := range entry.InfoFields {
+		key, value, _ := strings.Cut(unescapeTXT(field), "=")
+		switch key {
+		case "id":
+			board.ID = value
+		case "class":
+			board.ClassName = value
+		case "board":
+			board.BoardName = value
+		case "school":
+			board.School = value
+		}
+	}
+	board.URL = fmt.Sprintf("http://%s:%d", board.Addr, board.Port)
+	return board
+}
+
+// unescapeTXT reverses the \DDD escaping miekg/dns applies to non-ASCII TXT bytes,
+// so "Atat\195\188rk" becomes "Atatürk" again.
+func unescapeTXT(s string) string {
+	if !strings.Contains(s, "\\") {
+		return s
+	}
+	out := make([]byte, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 >= len(s) {
+			out = append(out, s[i])
+			continue
+		}
+		if i+3 < len(s) && isDigit(s[i+1]) && isDigit(s[i+2]) && isDigit(s[i+3]) {
+			out = append(out, (s[i+1]-'0')*100+(s[i+2]-'0')*10+(s[i+3]-'0'))
+			i += 3
+			continue
+		}
+		out = append(out, s[i+1])
+		i++
+	}
+	return string(out)
+}
+
+func isDigit(b byte) bool {
+	return b >= '0' && b <= '9'
+}
+
+// HostnameFor builds the DNS label of a class, e.g. "9-A" -> "sinif-9a".
+// DNS labels are ASCII only, so Turkish letters are folded.
+func HostnameFor(className string) string {
+	folder := strings.NewReplacer(
+		"ç", "c", "Ç", "c", "ğ", "g", "Ğ", "g", "ı", "i", "I", "i", "İ", "i",
+		"ö", "o", "Ö", "o", "ş", "s", "Ş", "s", "ü", "u", "Ü", "u",
+	)
+	name := strings.ToLower(folder.Replace(className))
+
+	var b strings.Builder
+	for _, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			b.WriteRune(r)
+		case r == ' ' || r == '_':
+			if b.Len() > 0 && !strings.HasSuffix(b.String(), "-") {
+				b.WriteRune('-')
+			}
+		}
+		// '-', '/' and '.' inside class names ("9-A", "9/A") are dropped
+	}
+
+	label := strings.Trim(b.String(), "-")
+	if label == "" {
+		return "dersdostu"
+	}
+	return "sinif-" + label
+}
+
+// BoardID identifies this board on the LAN by the last three bytes of a MAC
+// address, which stay the same across restarts. The lowest factory-assigned
+// MAC of the active cards is used, so neither the order of the cards nor
+// virtual or randomized (locally administered) addresses change it.
+func BoardID() string {
+	var best net.HardwareAddr
+	ifaces, err := net.Interfaces()
+	if err == nil {
+		for _, iface := range ifaces {
+			mac := iface.HardwareAddr
+			if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagLoopback != 0 || len(mac) != 6 || mac[0]&0x02 != 0 {
+				continue
+			}
+			if best == nil || bytes.Compare(mac, best) < 0 {
+				best = mac
+			}
+		}
+	}
+	if best != nil {
+		return hex.EncodeToString(best[3:])
+	}
+	// No network card: any stable-looking label is better than a clash
+	name, _ := os.Hostname()
+	sum := fnv.New32a()
+	sum.Write([]byte(name))
+	return fmt.Sprintf("%06x", sum.Sum32()&0xffffff)
+}
+
+// LocalIPs returns the IPv4 addresses of the active, non-loopback interfaces
+func LocalIPs() []net.IP {
+	ips := []net.IP{}
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return ips
+	}
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			if ipNet, ok := addr.(*net.IPNet); ok && ipNet.IP.To4() != nil {
+				ips = append(ips, ipNet.IP.To4())
+			}
+		}
+	}
+	return ips
+}
@@ -0,0 +1,138 @@
+package server
+
+import (
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"DersDostu/internal/recorder"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// recordingEntry is one row of the LAN recordings page
+type recordingEntry struct {
+	Name     string
+	Title    string
+	Full     string
+	Audio    bool   // audio-only recording, played as is
+	Preview  string // empty until the background job has produced it
+	Poster   string
+	Track    string // WebVTT chapters, empty when the lesson has none
+	Captions string // WebVTT subtitles, empty until transcribed
+	Chapters []recordingChapter
+	SizeMB   float64
+}
+
+// recordingChapter is a jump link on the recordings page
+type recordingChapter struct {
+	Start float64
+	Time  string // m:ss
+	Title string
+}
+
+var recordingsPage = template.Must(template.New("kayitlar").Parse(`<!DOCTYPE html>
+<html lang="tr">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Ders Kayıtları</title>
+<style>
+body { font-family: sans-serif; max-width: 720px; margin: 2em auto; padding: 0 1em; background: #f4f6f8; color: #1b2636; }
+h1 { font-size: 1.4em; }
+.rec { background: #fff; border-radius: 6px; padding: 1em; margin-bottom: 1em; }
+video { width: 100%; background: #000; }
+a { color: #1b2636; }
+ol.chapters { padding-left: 1.2em; }
+ol.chapters span { color: #66707d; font-variant-numeric: tabular-nums; }
+</style>
+</head>
+<body>
+<h1>Ders Kayıtları</h1>
+{{range .}}
+<div class="rec">
+<strong>{{.Title}}</strong>
+{{if .Audio}}<audio controls preload="none" src="/{{.Full}}"></audio>
+{{else if .Preview}}<video controls preload="none" src="/{{.Preview}}"{{if .Poster}} poster="/{{.Poster}}"{{end}}>{{if .Track}}<track kind="chapters" srclang="tr" src="/{{.Track}}" default>{{end}}{{if .Captions}}<track kind="subtitles" srclang="tr" label="Türkçe" src="/{{.Captions}}">{{end}}</video>
+{{if .Chapters}}<ol class="chapters">{{range .Chapters}}<li><a href="#" data-start="{{.Start}}"><span>{{.Time}}</span> {{.Title}}</a></li>{{end}}</ol>{{end}}
+{{else}}<p>Önizleme hazırlanıyor…</p>{{end}}
+<p><a href="/{{.Full}}">Tam kalite indir ({{printf "%.0f" .SizeMB}} MB)</a></p>
+</div>
+{{else}}
+<p>Henüz kayıt yok.</p>
+{{end}}
+<script>
+document.querySelectorAll("ol.chapters a").forEach(function (a) {
+  a.addEventListener("click", function (e) {
+    e.preventDefault();
+    var video = a.closest(".rec").querySelector("video");
+    video.currentTime = parseFloat(a.dataset.start);
+    video.play();
+  });
+});
+</script>
+</body>
+</html>
+`))
+
+// handleRecordingsPage lists recordings, pointing students at the light preview first
+func (s *ServerService) handleRecordingsPage(c *fiber.Ctx) error {
+	matches := []string{}
+	for _, ext := range []string{".mp4", ".wav", ".opus"} {
+		found, _ := filepath.Glob(filepath.Join(s.PublicDir, "rec-*"+ext))
+		matches = append(matches, found...)
+	}
+	// rec-2006-01-02-15-04-05.mp4 sorts chronologically; newest first
+	sort.Sort(sort.Reverse(sort.StringSlice(matches)))
+
+	entries := []recordingEntry{}
+	for _, match := range matches {
+		info, err := os.Stat(match)
+		if err != nil {
+			continue
+		}
+		name := filepath.Base(match)
+		entry := recordingEntry{
+			Name:   name,
+			Title:  strings.TrimSuffix(strings.TrimPrefix(name, "rec-"), filepath.Ext(name)),
+			Full:   name,
+			Audio:  filepath.Ext(name) != ".mp4",
+			SizeMB: float64(info.Size()) / (1024 * 1024),
+		}
+
+		video, poster := recorder.PreviewPaths(name)
+		if fileExists(filepath.Join(s.PublicDir, video)) {
+			entry.Preview = filepath.ToSlash(video)
+		}
+		if fileExists(filepath.Join(s.PublicDir, poster)) {
+			entry.Poster = filepath.ToSlash(poster)
+		}
+		if _, captions := recorder.SubtitlePaths(name); fileExists(filepath.Join(s.PublicDir, captions)) {
+			entry.Captions = filepath.ToSlash(captions)
+		}
+		track := recorder.ChapterTrackPath(name)
+		if chapters, err := recorder.ReadChapterTrack(filepath.Join(s.PublicDir, track)); err == nil {
+			entry.Track = filepath.ToSlash(track)
+			for _, ch := range chapters {
+				secs := int(ch.Start)
+				entry.Chapters = append(entry.Chapters, recordingChapter{
+					Start: ch.Start,
+					Time:  fmt.Sprintf("%d:%02d", secs/60, secs%60),
+					Title: ch.Title,
+				})
+			}
+		}
+		entries = append(entries, entry)
+	}
+
+	c.Type("html", "utf-8")
+	return recordingsPage.Execute(c.Response().BodyWriter(), entries)
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -1,22 +1,69 @@
 package server
 
 import (
+	"fmt"
 	"log"
+	"sync"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/hashicorp/mdns"
 )
 
+// Port is the LAN port of the local file server
+const Port = 8080
+
+// ServerService runs the Fiber web server that shares lesson files on the LAN
+// and collects homework submissions from students.
+type ServerService struct {
+	PublicDir string
+
+	mu           sync.Mutex
+	session      *SubmissionSession // nil while submissions are closed
+	onSubmission func(Submission)
+	mdns         *mdns.Server // nil while not advertising
+	host         string       // advertised mDNS name, e.g. "sinif-9a.local"
+	captions     captionFeed  // live captions shared on /altyazi
+}
+
+// NewServerService creates a server for the given public directory
+func NewServerService(publicDir string) *ServerService {
+	return &ServerService{
+		PublicDir: publicDir,
+	}
+}
+
 // Start executes the Fiber web server on port 8080
 // It serves files from the local data directory for LAN access.
-func Start(dataDir string) {
-	app := fiber.New()
+func (s *ServerService) Start() {
+	app := fiber.New(fiber.Config{
+		// Leave some headroom over MaxSubmissionSize for the multipart envelope
+		BodyLimit: MaxSubmissionSize + 1024*1024,
+	})
+
+	// Homework, recordings and captions routes are registered before Static so they are not shadowed
+	app.Get("/odev", s.handleSubmissionPage)
+	app.Post("/odev", s.handleSubmissionUpload)
+	app.Get("/kayitlar", s.handleRecordingsPage)
+	app.Get("/altyazi", s.handleCaptionsPage)
+	app.Get("/altyazi/satirlar", s.handleCaptionsFeed)
 
 	// Serve static files from the data directory
 	// In production, this would be C:\DersDostu_Data\public
-	app.Static("/", dataDir)
+	// Byte ranges let students scrub recordings without downloading the whole MP4
+	app.Use(s.fileValidators)
+	app.Static("/", s.PublicDir, fiber.Static{
+		ByteRange: true,
+	})
 
-	log.Println("Local File Server starting on :8080")
-	if err := app.Listen(":8080"); err != nil {
+	log.Printf("Local File Server starting on :%d", Port)
+	if err := app.Listen(fmt.Sprintf(":%d", Port)); err != nil {
 		log.Printf("Error starting local server: %v", err)
 	}
 }
+
+// OnSubmission registers the callback invoked after a student file is stored
+func (s *ServerService) OnSubmission(fn func(Submission)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onSubmission = fn
+}
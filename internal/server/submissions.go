@@ -0,0 +1,309 @@
+package server
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"DersDostu/internal/storage"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// MaxSubmissionSize is the largest file a student may upload (phone photos are ~5 MB)
+const MaxSubmissionSize = 15 * 1024 * 1024
+
+// DuplicatePolicy decides what happens when a student submits more than once
+type DuplicatePolicy string
+
+const (
+	DuplicateKeepAll DuplicatePolicy = "keep"    // store every file with a numbered suffix
+	DuplicateReplace DuplicatePolicy = "replace" // the newest file wins
+	DuplicateReject  DuplicatePolicy = "reject"  // the first file is final
+)
+
+// allowedSubmissionTypes maps sniffed MIME types to the extension used on disk
+var allowedSubmissionTypes = map[string]string{
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+	"image/webp":      ".webp",
+	"application/pdf": ".pdf",
+}
+
+// SubmissionSession describes the lesson that currently accepts homework
+type SubmissionSession struct {
+	ClassName string
+	LessonID  string
+	Dir       string // folder the files are written to
+	Roster    []string
+	Policy    DuplicatePolicy
+}
+
+// Submission is reported to the teacher for every stored file
+type Submission struct {
+	Student    string    `json:"student"`
+	ClassName  string    `json:"className"`
+	LessonID   string    `json:"lessonId"`
+	Filename   string    `json:"filename"`
+	Path       string    `json:"path"`
+	MimeType   string    `json:"mimeType"`
+	Size       int64     `json:"size"`
+	Replaced   bool      `json:"replaced"`
+	ReceivedAt time.Time `json:"receivedAt"`
+}
+
+// OpenSubmissions starts accepting homework for a lesson
+func (s *ServerService) OpenSubmissions(session SubmissionSession) error {
+	if len(session.Roster) == 0 {
+		return fmt.Errorf("class roster is empty")
+	}
+	switch session.Policy {
+	case "":
+		session.Policy = DuplicateKeepAll
+	case DuplicateKeepAll, DuplicateReplace, DuplicateReject:
+	default:
+		return fmt.Errorf("unknown duplicate policy %q", session.Policy)
+	}
+	if err := os.MkdirAll(session.Dir, 0755); err != nil {
+		return fmt.Errorf("failed to create submission directory: %v", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.session = &session
+	log.Printf("Submissions open for %s (%s)", session.ClassName, session.LessonID)
+	return nil
+}
+
+// CloseSubmissions stops accepting homework
+func (s *ServerService) CloseSubmissions() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.session = nil
+}
+
+func (s *ServerService) currentSession() *SubmissionSession {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.session
+}
+
+func (s *ServerService) handleSubmissionPage(c *fiber.Ctx) error {
+	return s.renderSubmissionPage(c, fiber.StatusOK, "", false)
+}
+
+func (s *ServerService) handleSubmissionUpload(c *fiber.Ctx) error {
+	session := s.currentSession()
+	if session == nil {
+		return s.renderSubmissionPage(c, fiber.StatusServiceUnavailable, "", false)
+	}
+
+	student := strings.TrimSpace(c.FormValue("student"))
+	if !inRoster(session.Roster, student) {
+		return s.renderSubmissionPage(c, fiber.StatusBadRequest, "Lütfen listeden adınızı seçin.", false)
+	}
+
+	fh, err := c.FormFile("file")
+	if err != nil {
+		return s.renderSubmissionPage(c, fiber.StatusBadRequest, "Lütfen bir dosya seçin.", false)
+	}
+	if fh.Size > MaxSubmissionSize {
+		return s.renderSubmissionPage(c, fiber.StatusRequestEntityTooLarge,
+			fmt.Sprintf("Dosya çok büyük (en fazla %d MB).", MaxSubmissionSize/(1024*1024)), false)
+	}
+
+	src, err := fh.Open()
+	if err != nil {
+		return s.renderSubmissionPage(c, fiber.StatusBadRequest, "Dosya okunamadı.", false)
+	}
+	defer src.Close()
+
+	// Trust the content, not the file name or the browser's Content-Type
+	head := make([]byte, 512)
+	n, _ := io.ReadFull(src, head)
+	mimeType := strings.Split(http.DetectContentType(head[:n]), ";")[0]
+	ext, ok := allowedSubmissionTypes[mimeType]
+	if !ok {
+		return s.renderSubmissionPage(c, fiber.StatusUnsupportedMediaType, "Sadece fotoğraf (JPG, PNG) veya PDF gönderebilirsiniz.", false)
+	}
+	if _, err := src.Seek(0, io.SeekStart); err != nil {
+		return s.renderSubmissionPage(c, fiber.StatusInternalServerError, "Dosya okunamadı.", false)
+	}
+
+	sub, err := s.storeSubmission(session, student, ext, src)
+	if err != nil {
+		log.Printf("Submission from %s failed: %v", student, err)
+		if err == errDuplicateRejected {
+			return s.renderSubmissionPage(c, fiber.StatusConflict, "Bu ders için ödevinizi zaten gönderdiniz.", false)
+		}
+		return s.renderSubmissionPage(c, fiber.StatusInternalServerError, "Dosya kaydedilemedi, tekrar deneyin.", false)
+	}
+	sub.MimeType = mimeType
+	sub.Size = fh.Size
+
+	log.Printf("Submission received: %s -> %s", student, sub.Path)
+
+	s.mu.Lock()
+	notify := s.onSubmission
+	s.mu.Unlock()
+	if notify != nil {
+		notify(*sub)
+	}
+
+	return s.renderSubmissionPage(c, fiber.StatusOK, "Ödeviniz alındı. Teşekkürler!", true)
+}
+
+var errDuplicateRejected = fmt.Errorf("duplicate submission rejected")
+
+// storeSubmission writes the upload to a temp file and moves it into place
+// according to the session's duplicate policy.
+func (s *ServerService) storeSubmission(session *SubmissionSession, student, ext string, src io.Reader) (*Submission, error) {
+	tmp, err := os.CreateTemp(session.Dir, ".upload-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name()) // no-op once renamed
+
+	if _, err := io.Copy(tmp, io.LimitReader(src, MaxSubmissionSize)); err != nil {
+		tmp.Close()
+		return nil, err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return nil, err
+	}
+	if err := tmp.Close(); err != nil {
+		return nil, err
+	}
+
+	// Serialize the name lookup + rename so two uploads from the same student can't race
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	base := storage.SanitizeName(student)
+	filename := base + ext
+	target := filepath.Join(session.Dir, filename)
+	replaced := false
+
+	if existing := findSubmission(session.Dir, base); existing != "" {
+		switch session.Policy {
+		case DuplicateReject:
+			return nil, errDuplicateRejected
+		case DuplicateReplace:
+			if err := os.Remove(filepath.Join(session.Dir, existing)); err != nil {
+				return nil, err
+			}
+			replaced = true
+		case DuplicateKeepAll:
+			for i := 2; ; i++ {
+				filename = fmt.Sprintf("%s-%d%s", base, i, ext)
+				target = filepath.Join(session.Dir, filename)
+				if _, err := os.Stat(target); os.IsNotExist(err) {
+					break
+				}
+			}
+		}
+	}
+
+	if err := os.Rename(tmp.Name(), target); err != nil {
+		return nil, err
+	}
+
+	return &Submission{
+		Student:    student,
+		ClassName:  session.ClassName,
+		LessonID:   session.LessonID,
+		Filename:   filename,
+		Path:       target,
+		Replaced:   replaced,
+		ReceivedAt: time.Now(),
+	}, nil
+}
+
+// findSubmission returns the first file of a student regardless of its extension
+func findSubmission(dir, base string) string {
+	for _, ext := range allowedSubmissionTypes {
+		name := base + ext
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			return name
+		}
+	}
+	return ""
+}
+
+func inRoster(roster []string, name string) bool {
+	for _, n := range roster {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+var submissionPage = template.Must(template.New("odev").Parse(`<!DOCTYPE html>
+<html lang="tr">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Ödev Gönder</title>
+<style>
+body { font-family: sans-serif; max-width: 480px; margin: 2em auto; padding: 0 1em; background: #f4f6f8; color: #1b2636; }
+h1 { font-size: 1.4em; }
+label { display: block; margin-top: 1em; font-weight: bold; }
+select, input, button { width: 100%; font-size: 1.1em; margin-top: .4em; padding: .5em; box-sizing: border-box; }
+button { background: #1b2636; color: #fff; border: 0; border-radius: 6px; margin-top: 1.5em; }
+.msg { padding: .8em; border-radius: 6px; background: #fde2e2; }
+.msg.ok { background: #d9f5de; }
+</style>
+</head>
+<body>
+{{if .Open}}
+<h1>{{.ClassName}} – Ödev Gönder</h1>
+{{if .Message}}<p class="msg{{if .Success}} ok{{end}}">{{.Message}}</p>{{end}}
+<form method="post" action="/odev" enctype="multipart/form-data">
+<label for="student">Adınız</label>
+<select id="student" name="student" required>
+<option value="">Seçiniz…</option>
+{{range .Roster}}<option>{{.}}</option>{{end}}
+</select>
+<label for="file">Fotoğraf veya PDF (en fazla {{.MaxMB}} MB)</label>
+<input id="file" name="file" type="file" accept="image/jpeg,image/png,image/webp,application/pdf" required>
+<button type="submit">Gönder</button>
+</form>
+{{else}}
+<h1>Ödev Gönder</h1>
+<p class="msg">Şu anda ödev kabul edilmiyor. Öğretmeninizden teslimi başlatmasını isteyin.</p>
+{{end}}
+</body>
+</html>
+`))
+
+func (s *ServerService) renderSubmissionPage(c *fiber.Ctx, status int, message string, success bool) error {
+	data := struct {
+		Open      bool
+		ClassName string
+		Roster    []string
+		Message   string
+		Success   bool
+		MaxMB     int
+	}{
+		Message: message,
+		Success: success,
+		MaxMB:   MaxSubmissionSize / (1024 * 1024),
+	}
+	if session := s.currentSession(); session != nil {
+		data.Open = true
+		data.ClassName = session.ClassName
+		data.Roster = session.Roster
+	}
+
+	c.Status(status)
+	c.Type("html", "utf-8")
+	return submissionPage.Execute(c.Response().BodyWriter(), data)
+}
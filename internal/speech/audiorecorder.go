@@ -0,0 +1,391 @@
+package speech
+
+import (
+	"DersDostu/internal/recorder"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	audioTapName = "audio-recorder"
+	// audioSampleRate is the rate of the shared dictation stream
+	audioSampleRate = 16000
+	// audioFlushInterval bounds what a power cut can lose from a WAV file
+	audioFlushInterval = 5 * time.Second
+)
+
+// Audio formats of the AudioRecorder
+const (
+	AudioFormatWAV  = "wav"
+	AudioFormatOpus = "opus" // needs the bundled opusenc, falls back to WAV
+)
+
+// AudioRecorder records the microphone without ffmpeg, for boards where it is
+// missing or too heavy. It listens on the dictation stream through an audio
+// tap, so the teacher can dictate while the lesson is recorded.
+type AudioRecorder struct {
+	PublicDir string
+	speech    *SpeechService
+
+	opMu      sync.Mutex // serializes Start/Pause/Resume/Stop, e.g. a voice command and a button press
+	mu        sync.Mutex
+	format    string
+	recording bool
+	paused    bool
+	tapLost   bool // the stream failed; ResumeRecording re-attaches
+	filename  string
+	started   time.Time     // start of the running span
+	elapsed   time.Duration // recorded time of finished spans
+	lastError string
+	dropped   int // buffers lost because the disk couldn't keep up
+	samples   chan []int16
+	done      chan error
+}
+
+// NewAudioRecorder creates an audio-only recorder on the dictation stream
+func NewAudioRecorder(speech *SpeechService, publicDir string) *AudioRecorder {
+	return &AudioRecorder{
+		PublicDir: publicDir,
+		speech:    speech,
+		format:    AudioFormatWAV,
+	}
+}
+
+// SetFormat selects WAV or Opus for the next recording
+func (r *AudioRecorder) SetFormat(format string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.format = format
+}
+
+// Extension returns the file extension the next recording will have
+func (r *AudioRecorder) Extension() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.format == AudioFormatOpus && findOpusEncoder() != "" {
+		return ".opus"
+	}
+	return ".wav"
+}
+
+// IsRecording reports whether a recording is in progress (including paused)
+func (r *AudioRecorder) IsRecording() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.recording
+}
+
+// StartRecording starts writing the microphone to filename (relative to
+// PublicDir). The extension picks the format, see Extension.
+func (r *AudioRecorder) StartRecording(filename string) error {
+	r.opMu.Lock()
+	defer r.opMu.Unlock()
+
+	if r.IsRecording() {
+		return fmt.Errorf("recording already in progress")
+	}
+
+	path := filepath.Join(r.PublicDir, filename)
+	var sink audioSink
+	var err error
+	if strings.EqualFold(filepath.Ext(filename), ".opus") {
+		sink, err = startOpus(path)
+	} else {
+		sink, err = createWAV(path, audioSampleRate)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %v", filename, err)
+	}
+
+	r.mu.Lock()
+	r.filename = filename
+	r.elapsed = 0
+	r.dropped = 0
+	r.lastError = ""
+	r.samples = make(chan []int16, 100) // 10s of buffers
+	r.done = make(chan error, 1)
+	go r.write(sink, r.samples, r.done)
+	r.recording = true
+	r.paused = false
+	r.tapLost = false
+	r.started = time.Now()
+	r.mu.Unlock()
+
+	if err := r.attach(); err != nil {
+		r.finish()
+		os.Remove(path)
+		return err
+	}
+	log.Printf("🎙️ Audio-only recording started: %s", filename)
+	return nil
+}
+
+// PauseRecording stops writing samples until ResumeRecording
+func (r *AudioRecorder) PauseRecording() error {
+	r.opMu.Lock()
+	defer r.opMu.Unlock()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.recording {
+		return fmt.Errorf("no recording in progress")
+	}
+	if r.paused {
+		return fmt.Errorf("recording already paused")
+	}
+	r.paused = true
+	r.elapsed += time.Since(r.started)
+	return nil
+}
+
+// ResumeRecording continues a paused recording, re-attaching after a stream failure
+func (r *AudioRecorder) ResumeRecording() error {
+	r.opMu.Lock()
+	defer r.opMu.Unlock()
+
+	r.mu.Lock()
+	if !r.recording {
+		r.mu.Unlock()
+		return fmt.Errorf("no recording in progress")
+	}
+	if !r.paused {
+		r.mu.Unlock()
+		return fmt.Errorf("recording is not paused")
+	}
+	reattach := r.tapLost
+	r.mu.Unlock()
+
+	if reattach {
+		if err := r.attach(); err != nil {
+			return err
+		}
+	}
+
+	r.mu.Lock()
+	r.paused = false
+	r.tapLost = false
+	r.lastError = ""
+	r.started = time.Now()
+	r.mu.Unlock()
+	return nil
+}
+
+// StopRecording detaches from the stream and finalizes the file
+func (r *AudioRecorder) StopRecording() error {
+	r.opMu.Lock()
+	defer r.opMu.Unlock()
+
+	if !r.IsRecording() {
+		return fmt.Errorf("no recording in progress")
+	}
+	r.speech.removeAudioTap(audioTapName)
+
+	r.mu.Lock()
+	if !r.paused {
+		r.elapsed += time.Since(r.started)
+	}
+	dropped := r.dropped
+	r.mu.Unlock()
+
+	if dropped > 0 {
+		log.Printf("⚠️ Audio recording dropped %d buffers", dropped)
+	}
+	return r.finish()
+}
+
+// Status returns the current recorder state
+func (r *AudioRecorder) Status() recorder.RecorderStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	status := recorder.RecorderStatus{
+		State:     "idle",
+		Filename:  r.filename,
+		Takes:     1,
+		LastError: r.lastError,
+	}
+	if !r.recording {
+		return status
+	}
+
+	elapsed := r.elapsed
+	if r.paused {
+		status.State = "paused"
+	} else {
+		status.State = "recording"
+		elapsed += time.Since(r.started)
+	}
+	status.Elapsed = elapsed.Seconds()
+	return status
+}
+
+func (r *AudioRecorder) attach() error {
+	return r.speech.addAudioTap(audioTapName, AudioTap{
+		OnSamples: r.onSamples,
+		OnError:   r.onError,
+	})
+}
+
+// finish ends the recording and waits for the writer to close the file
+func (r *AudioRecorder) finish() error {
+	r.mu.Lock()
+	r.recording = false
+	r.paused = false
+	samples, done := r.samples, r.done
+	r.mu.Unlock()
+
+	// onSamples checks recording under mu, so nothing is sent after this
+	close(samples)
+	return <-done
+}
+
+// onSamples runs on the audio loop: copy and hand off, never block
+func (r *AudioRecorder) onSamples(buffer []int16) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.recording || r.paused {
+		return
+	}
+
+	samples := make([]int16, len(buffer))
+	copy(samples, buffer)
+	select {
+	case r.samples <- samples:
+	default:
+		r.dropped++
+	}
+}
+
+// onError pauses the recording when the microphone stream fails, like the
+// ffmpeg recorder does when ffmpeg dies, and tells the teacher right away
+func (r *AudioRecorder) onError(err error) {
+	r.mu.Lock()
+	if !r.recording {
+		r.mu.Unlock()
+		return
+	}
+	if !r.paused {
+		r.elapsed += time.Since(r.started)
+	}
+	r.paused = true
+	r.tapLost = true
+	r.lastError = fmt.Sprintf("microphone stream failed: %v", err)
+	r.mu.Unlock()
+
+	log.Printf("Audio recording failed: %v", err)
+	r.speech.emit("recording-error", map[string]interface{}{
+		"message": fmt.Sprintf("Mikrofon kaydı durdu: %v", err),
+		"status":  r.Status(),
+	})
+}
+
+// write drains samples into the sink and reports the first error on done
+func (r *AudioRecorder) write(sink audioSink, samples <-chan []int16, done chan<- error) {
+	var firstErr error
+	keep := func(err error) {
+		if err != nil && firstErr == nil {
+			firstErr = err
+			log.Printf("❌ Audio recording write error: %v", err)
+		}
+	}
+
+	ticker := time.NewTicker(audioFlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case buf, ok := <-samples:
+			if !ok {
+				keep(sink.Close())
+				done <- firstErr
+				return
+			}
+			if firstErr == nil {
+				keep(sink.WriteSamples(buf))
+			}
+		case <-ticker.C:
+			if firstErr == nil {
+				keep(sink.Flush())
+			}
+		}
+	}
+}
+
+// audioSink is where recorded samples go
+type audioSink interface {
+	WriteSamples(samples []int16) error
+	Flush() error
+	Close() error
+}
+
+// opusSink pipes raw PCM to opusenc, which writes an Ogg stream as it goes
+type opusSink struct {
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+}
+
+func startOpus(path string) (*opusSink, error) {
+	encoder := findOpusEncoder()
+	if encoder == "" {
+		return nil, fmt.Errorf("opusenc not found")
+	}
+	cmd := exec.Command(encoder,
+		"--quiet",
+		"--raw", "--raw-bits", "16", "--raw-rate", fmt.Sprint(audioSampleRate), "--raw-chan", "1",
+		"--speech", "--bitrate", "24",
+		"-", path,
+	)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	return &opusSink{cmd: cmd, stdin: stdin}, nil
+}
+
+func (o *opusSink) WriteSamples(samples []int16) error {
+	buf := make([]byte, len(samples)*2)
+	for i, sample := range samples {
+		binary.LittleEndian.PutUint16(buf[i*2:], uint16(sample))
+	}
+	_, err := o.stdin.Write(buf)
+	return err
+}
+
+func (o *opusSink) Flush() error { return nil }
+
+func (o *opusSink) Close() error {
+	o.stdin.Close()
+	if err := o.cmd.Wait(); err != nil {
+		return fmt.Errorf("opusenc failed: %v", err)
+	}
+	return nil
+}
+
+// findOpusEncoder looks for opusenc next to the executable (bundled), then on PATH
+func findOpusEncoder() string {
+	name := "opusenc"
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+	if exe, err := os.Executable(); err == nil {
+		bundled := filepath.Join(filepath.Dir(exe), name)
+		if _, err := os.Stat(bundled); err == nil {
+			return bundled
+		}
+	}
+	if path, err := exec.LookPath(name); err == nil {
+		return path
+	}
+	return ""
+}
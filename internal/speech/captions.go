@@ -0,0 +1,106 @@
+package speech
+
+import (
+	"log"
+	"time"
+)
+
+// captionBufferSize is how many lines GetCaptions keeps for a late viewer
+const captionBufferSize = 100
+
+// CaptionLine is one finished caption, the "caption-line" event
+type CaptionLine struct {
+	Seq      int       `json:"seq"`      // 1, 2, ... within the session
+	Session  time.Time `json:"session"`  // when captioning started
+	Start    float64   `json:"start"`    // seconds since the session started
+	End      float64   `json:"end"`      // seconds since the session started
+	SpokenAt time.Time `json:"spokenAt"` // wall clock at Start
+	Text     string    `json:"text"`
+}
+
+// StartCaptions captions the teacher continuously for the class. Nothing is
+// treated as a command, dictation doesn't stop on silence, and every line
+// goes to the OnCaption callback for the lesson transcript.
+func (s *SpeechService) StartCaptions() error {
+	if err := s.startListening(ModeCaption); err != nil {
+		return err
+	}
+	log.Println("💬 Live captions on")
+	return nil
+}
+
+// resetCaptionsLocked starts a new caption session. s.mu must be held.
+func (s *SpeechService) resetCaptionsLocked() {
+	now := time.Now()
+	s.captionSession = now
+	s.captionLast = now
+	s.captionSeq = 0
+	s.captions = s.captions[:0]
+}
+
+// GetCaptions returns the recent caption lines of the current or last session, oldest first
+func (s *SpeechService) GetCaptions() []CaptionLine {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]CaptionLine{}, s.captions...)
+}
+
+// OnCaption registers the callbacks s invokes for every finished caption line
+// and for the words of the line still being spoken (may be nil)
+func OnCaption(s *SpeechService, line func(CaptionLine), partial func(string)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onCaption = line
+	s.onPartial = partial
+}
+
+// handleCaptionPartial shows the words of the current line before it is finished.
+// Captions have their own overlay, so this isn't "speech-partial" for the text box.
+func (s *SpeechService) handleCaptionPartial(text string) {
+	s.mu.Lock()
+	onPartial := s.onPartial
+	s.mu.Unlock()
+
+	s.emit("caption-partial", text)
+	if onPartial != nil {
+		onPartial(text)
+	}
+}
+
+// handleCaption timestamps a recognized line, keeps it in the rolling buffer
+// and reports it. Captions are never commands.
+func (s *SpeechService) handleCaption(text string) {
+	if text = s.normalize(text); text == "" {
+		return
+	}
+
+	s.mu.Lock()
+	now := time.Now()
+	start := s.utteranceStart
+	// Long speech without a pause is split by Vosk; the next line starts where this one ended
+	if start.IsZero() || start.Before(s.captionLast) {
+		start = s.captionLast
+	}
+	s.captionSeq++
+	line := CaptionLine{
+		Seq:      s.captionSeq,
+		Session:  s.captionSession,
+		Start:    start.Sub(s.captionSession).Seconds(),
+		End:      now.Sub(s.captionSession).Seconds(),
+		SpokenAt: start,
+		Text:     text,
+	}
+	s.captionLast = now
+	s.utteranceStart = time.Time{}
+	s.captions = append(s.captions, line)
+	if len(s.captions) > captionBufferSize {
+		s.captions = s.captions[:copy(s.captions, s.captions[len(s.captions)-captionBufferSize:])]
+	}
+	onCaption := s.onCaption
+	s.mu.Unlock()
+
+	s.emit("caption-line", line)
+	if onCaption != nil {
+		onCaption(line)
+	}
+}
@@ -0,0 +1,486 @@
+package speech
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// VoiceCommand maps spoken patterns to an action emitted on "voice-command".
+// Patterns are words and <slot> references, e.g. "<renk> kalem" or "sayfa <sayı>".
+// Params become fields of the event; "{slot}" is replaced with the slot's value.
+type VoiceCommand struct {
+	Name     string            `json:"name"`
+	Patterns []string          `json:"patterns"`
+	Action   string            `json:"action"`
+	Params   map[string]string `json:"params,omitempty"`
+}
+
+// SlotDef is a variable part of a pattern
+type SlotDef struct {
+	Type   string            `json:"type"`             // "number" or "enum"
+	Values map[string]string `json:"values,omitempty"` // spoken word(s) -> value, for enums
+	Min    int               `json:"min,omitempty"`    // numbers outside Min..Max don't match (0 = no limit)
+	Max    int               `json:"max,omitempty"`
+}
+
+// CommandConfig is the content of voice-commands.json
+type CommandConfig struct {
+	// WakePhrase must precede a command, e.g. "Ders Dostu sayfa beş"
+	// (empty = DefaultWakePhrase). Push-to-talk doesn't need it.
+	WakePhrase string `json:"wakePhrase"`
+	// WakeWindowSeconds: after the wake phrase alone, a command is accepted this long
+	WakeWindowSeconds int `json:"wakeWindowSeconds"`
+	// Strict turns off fuzzy matching: every word must be recognized exactly
+	Strict   bool               `json:"strict"`
+	Slots    map[string]SlotDef `json:"slots"`
+	Commands []VoiceCommand     `json:"commands"`
+}
+
+// DefaultWakePhrase addresses the board
+const DefaultWakePhrase = "ders dostu"
+
+// defaultWakeWindow applies when WakeWindowSeconds is not set
+const defaultWakeWindow = 5 * time.Second
+
+// DefaultCommandConfig returns the built-in grammar, written to
+// voice-commands.json on first start so it can be edited
+func DefaultCommandConfig() CommandConfig {
+	return CommandConfig{
+		WakePhrase:        DefaultWakePhrase,
+		WakeWindowSeconds: 5,
+		Slots: map[string]SlotDef{
+			"renk": {Type: "enum", Values: map[string]string{
+				"kırmızı": "#FF0000", "mavi": "#0000FF", "yeşil": "#00A000", "sarı": "#FFD700",
+				"siyah": "#000000", "beyaz": "#FFFFFF", "turuncu": "#FF8C00", "mor": "#800080",
+				"pembe": "#FF69B4", "kahverengi": "#8B4513", "gri": "#808080", "lacivert": "#000080",
+				"açık mavi": "#87CEEB", "koyu yeşil": "#006400",
+			}},
+			"sayı":     {Type: "number", Min: 1, Max: 999},
+			"kalınlık": {Type: "number", Min: 1, Max: 50},
+		},
+		Commands: []VoiceCommand{
+			{Name: "kalem-rengi", Patterns: []string{"<renk> kalem", "kalem <renk>", "<renk> renk", "renk <renk>"},
+				Action: "tool-change", Params: map[string]string{"tool": "pencil", "color": "{renk}"}},
+			{Name: "kalem", Patterns: []string{"kalem"}, Action: "tool-change", Params: map[string]string{"tool": "pencil"}},
+			{Name: "silgi", Patterns: []string{"silgi"}, Action: "tool-change", Params: map[string]string{"tool": "eraser"}},
+			{Name: "temizle", Patterns: []string{"temizle", "sıfırla", "tahtayı temizle"}, Action: "canvas-clear"},
+			{Name: "kalinlik", Patterns: []string{"kalınlık <kalınlık>", "kalem kalınlığı <kalınlık>"},
+				Action: "brush-size", Params: map[string]string{"size": "{kalınlık}"}},
+			{Name: "sayfa", Patterns: []string{"sayfa <sayı>", "<sayı> numaralı sayfa", "<sayı> sayfaya git"},
+				Action: "page-go", Params: map[string]string{"page": "{sayı}"}},
+			{Name: "sonraki-sayfa", Patterns: []string{"sonraki sayfa", "ileri sayfa"}, Action: "page-next"},
+			{Name: "yeni-sayfa", Patterns: []string{"yeni sayfa", "sayfa ekle"}, Action: "page-add"},
+			{Name: "onceki-sayfa", Patterns: []string{"önceki sayfa", "geri sayfa"}, Action: "page-prev"},
+			{Name: "geri-al", Patterns: []string{"geri al"}, Action: "undo"},
+			{Name: "yinele", Patterns: []string{"yinele", "ileri al"}, Action: "redo"},
+			{Name: "kayit-baslat", Patterns: []string{"kaydı başlat", "kayda başla", "kayıt başlat"}, Action: "recording-start"},
+			{Name: "kayit-durdur", Patterns: []string{"kaydı durdur", "kaydı bitir", "kayıt durdur"}, Action: "recording-stop"},
+			{Name: "yoklama", Patterns: []string{"yoklama al", "yoklama"}, Action: "attendance"},
+		},
+	}
+}
+
+// LoadCommandConfig reads the grammar at path. A missing file is created with the defaults.
+func LoadCommandConfig(path string) (CommandConfig, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		cfg := DefaultCommandConfig()
+		if err := saveCommandConfig(path, cfg); err != nil {
+			log.Printf("⚠️ Could not write default voice commands: %v", err)
+		}
+		return cfg, nil
+	}
+	if err != nil {
+		return CommandConfig{}, fmt.Errorf("failed to read voice commands: %v", err)
+	}
+
+	var cfg CommandConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return CommandConfig{}, fmt.Errorf("failed to parse voice commands %s: %v", path, err)
+	}
+	return cfg, nil
+}
+
+func saveCommandConfig(path string, cfg CommandConfig) error {
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Clean(path))
+}
+
+// CommandMatch is a recognized command, ready to emit
+type CommandMatch struct {
+	Command VoiceCommand
+	Payload map[string]interface{}
+	Cost    int // total edit distance of fuzzy-matched words, 0 = exact
+}
+
+// CommandSet is a compiled grammar
+type CommandSet struct {
+	strict     bool
+	slots      map[string]compiledSlot
+	patterns   []compiledPattern
+	wake       []string // folded words of the wake phrase
+	wakeWindow time.Duration
+	vocabulary map[string]bool // every word the grammar can use, as the model spells it
+}
+
+type compiledSlot struct {
+	def    SlotDef
+	values []enumValue // folded spoken forms, for enums
+}
+
+type enumValue struct {
+	words []string
+	value string
+}
+
+type compiledPattern struct {
+	command *VoiceCommand
+	tokens  []patternToken
+}
+
+// patternToken is a literal word or, when slot is set, a slot reference
+type patternToken struct {
+	word string
+	slot string
+}
+
+// slotValue is what a slot matched: an int for numbers, a string for enums
+type slotValue struct {
+	value interface{}
+}
+
+// NewCommandSet validates and compiles a grammar
+func NewCommandSet(cfg CommandConfig) (*CommandSet, error) {
+	set := &CommandSet{
+		strict:     cfg.Strict,
+		slots:      map[string]compiledSlot{},
+		wakeWindow: time.Duration(cfg.WakeWindowSeconds) * time.Second,
+		vocabulary: map[string]bool{},
+	}
+	if set.wakeWindow <= 0 {
+		set.wakeWindow = defaultWakeWindow
+	}
+	wake := cfg.WakePhrase
+	if strings.TrimSpace(wake) == "" {
+		wake = DefaultWakePhrase
+	}
+	set.wake = strings.Fields(foldTurkish(wake))
+	set.addVocabulary(wake)
+
+	for name, def := range cfg.Slots {
+		slot := compiledSlot{def: def}
+		switch def.Type {
+		case "number":
+			set.addVocabulary(numberWords)
+		case "enum":
+			if len(def.Values) == 0 {
+				return nil, fmt.Errorf("slot <%s> has no values", name)
+			}
+			for spoken, value := range def.Values {
+				set.addVocabulary(spoken)
+				slot.values = append(slot.values, enumValue{words: strings.Fields(foldTurkish(spoken)), value: value})
+			}
+		default:
+			return nil, fmt.Errorf("slot <%s> has unknown type %q", name, def.Type)
+		}
+		set.slots[name] = slot
+	}
+
+	for i := range cfg.Commands {
+		cmd := &cfg.Commands[i]
+		if cmd.Action == "" || len(cmd.Patterns) == 0 {
+			return nil, fmt.Errorf("command %q needs an action and at least one pattern", cmd.Name)
+		}
+		for _, pattern := range cmd.Patterns {
+			compiled := compiledPattern{command: cmd}
+			for _, field := range strings.Fields(pattern) {
+				if strings.HasPrefix(field, "<") && strings.HasSuffix(field, ">") {
+					name := strings.Trim(field, "<>")
+					if _, ok := set.slots[name]; !ok {
+						return nil, fmt.Errorf("pattern %q uses unknown slot <%s>", pattern, name)
+					}
+					compiled.tokens = append(compiled.tokens, patternToken{slot: name})
+					continue
+				}
+				set.addVocabulary(field)
+				if word := foldTurkish(field); word != "" {
+					compiled.tokens = append(compiled.tokens, patternToken{word: word})
+				}
+			}
+			if len(compiled.tokens) == 0 {
+				return nil, fmt.Errorf("command %q has an empty pattern", cmd.Name)
+			}
+			set.patterns = append(set.patterns, compiled)
+		}
+	}
+	return set, nil
+}
+
+// numberWords are added to the vocabulary of grammars with number slots
+const numberWords = "sıfır bir iki üç dört beş altı yedi sekiz dokuz on yirmi otuz kırk elli altmış yetmiş seksen doksan yüz bin"
+
+// addVocabulary records the words of a phrase as the model spells them (lowercase, diacritics kept)
+func (set *CommandSet) addVocabulary(phrase string) {
+	for _, word := range strings.Fields(strings.ToLowerSpecial(unicode.TurkishCase, phrase)) {
+		word = strings.TrimFunc(word, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) })
+		if word != "" {
+			set.vocabulary[word] = true
+		}
+	}
+}
+
+// Grammar returns the vocabulary as a Vosk grammar (a JSON list of phrases),
+// keeping only words the model knows. "[unk]" absorbs everything else, so
+// ordinary speech isn't forced onto the nearest command word.
+func (set *CommandSet) Grammar(known func(word string) bool) string {
+	words := []string{}
+	for word := range set.vocabulary {
+		if known == nil || known(word) {
+			words = append(words, word)
+		} else {
+			log.Printf("⚠️ Voice command word not in model, ignored: %s", word)
+		}
+	}
+	sort.Strings(words)
+	words = append(words, "[unk]")
+	data, _ := json.Marshal(words)
+	return string(data)
+}
+
+// StripWake removes a leading wake phrase and reports whether it was there
+func (set *CommandSet) StripWake(text string) (string, bool) {
+	words := strings.Fields(text)
+	if len(words) < len(set.wake) {
+		return text, false
+	}
+	for i, want := range set.wake {
+		if _, ok := set.wordDistance(foldTurkish(words[i]), want); !ok {
+			return text, false
+		}
+	}
+	return strings.Join(words[len(set.wake):], " "), true
+}
+
+// WakeWindow is how long a lone wake phrase keeps the board listening for a command
+func (set *CommandSet) WakeWindow() time.Duration {
+	return set.wakeWindow
+}
+
+// Match finds the command the whole utterance says. Among several matches the
+// one with the fewest corrected letters wins, then the first in the file.
+func (set *CommandSet) Match(text string) (CommandMatch, bool) {
+	words := strings.Fields(foldTurkish(text))
+	if len(words) == 0 {
+		return CommandMatch{}, false
+	}
+
+	var best *compiledPattern
+	var bestValues map[string]slotValue
+	bestCost := -1
+	for i := range set.patterns {
+		p := &set.patterns[i]
+		cost, values, ok := set.matchFrom(p.tokens, words, 0, 0)
+		if ok && (bestCost < 0 || cost < bestCost) {
+			best, bestValues, bestCost = p, values, cost
+		}
+	}
+	if best == nil {
+		return CommandMatch{}, false
+	}
+
+	payload := map[string]interface{}{"action": best.command.Action, "text": text}
+	if best.command.Name != "" {
+		payload["command"] = best.command.Name
+	}
+	for key, param := range best.command.Params {
+		payload[key] = fillParam(param, bestValues)
+	}
+	return CommandMatch{Command: *best.command, Payload: payload, Cost: bestCost}, true
+}
+
+// matchFrom matches tokens[ti:] against words[wi:] and returns the cheapest way
+func (set *CommandSet) matchFrom(tokens []patternToken, words []string, ti, wi int) (int, map[string]slotValue, bool) {
+	if ti == len(tokens) {
+		if wi == len(words) {
+			return 0, map[string]slotValue{}, true
+		}
+		return 0, nil, false
+	}
+	if wi == len(words) {
+		return 0, nil, false
+	}
+
+	tok := tokens[ti]
+	if tok.slot == "" {
+		d, ok := set.wordDistance(words[wi], tok.word)
+		if !ok {
+			return 0, nil, false
+		}
+		cost, values, ok := set.matchFrom(tokens, words, ti+1, wi+1)
+		return cost + d, values, ok
+	}
+
+	bestCost := -1
+	var bestValues map[string]slotValue
+	try := func(consumed, cost int, value interface{}) {
+		rest, values, ok := set.matchFrom(tokens, words, ti+1, wi+consumed)
+		if ok && (bestCost < 0 || rest+cost < bestCost) {
+			values[tok.slot] = slotValue{value: value}
+			bestCost, bestValues = rest+cost, values
+		}
+	}
+
+	slot := set.slots[tok.slot]
+	switch slot.def.Type {
+	case "number":
+		// Spoken numbers span up to five words: "bin dokuz yüz seksen dört"
+		for n := 1; n <= 5 && wi+n <= len(words); n++ {
+			value, ok := ParseTurkishNumber(words[wi : wi+n])
+			if !ok || (slot.def.Min != 0 && value < slot.def.Min) || (slot.def.Max != 0 && value > slot.def.Max) {
+				continue
+			}
+			try(n, 0, value)
+		}
+	case "enum":
+		for _, v := range slot.values {
+			if wi+len(v.words) > len(words) {
+				continue
+			}
+			cost, ok := 0, true
+			for k, w := range v.words {
+				d, match := set.wordDistance(words[wi+k], w)
+				if !match {
+					ok = false
+					break
+				}
+				cost += d
+			}
+			if ok {
+				try(len(v.words), cost, v.value)
+			}
+		}
+	}
+	return bestCost, bestValues, bestCost >= 0
+}
+
+// wordDistance compares a heard word with an expected one. Short words must
+// match exactly; longer ones may be off by a letter or two, which covers the
+// usual small-model slips ("kırmız", "kalen").
+func (set *CommandSet) wordDistance(heard, want string) (int, bool) {
+	if heard == want {
+		return 0, true
+	}
+	if set.strict {
+		return 0, false
+	}
+
+	allowed := 0
+	switch n := utf8.RuneCountInString(want); {
+	case n >= 8:
+		allowed = 2
+	case n >= 4:
+		allowed = 1
+	}
+	d := levenshtein(heard, want)
+	return d, d <= allowed
+}
+
+// fillParam substitutes slot values; a param that is exactly "{slot}" keeps the value's type
+func fillParam(param string, values map[string]slotValue) interface{} {
+	for name, v := range values {
+		placeholder := "{" + name + "}"
+		if param == placeholder {
+			return v.value
+		}
+		param = strings.ReplaceAll(param, placeholder, fmt.Sprint(v.value))
+	}
+	return param
+}
+
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
+
+// LoadCommands loads the voice command grammar of s from path (created with
+// the defaults if missing). On error the previous grammar stays active.
+func LoadCommands(s *SpeechService, path string) error {
+	s.mu.Lock()
+	s.commandsPath = path
+	s.mu.Unlock()
+
+	cfg, err := LoadCommandConfig(path)
+	if err != nil {
+		return err
+	}
+	set, err := NewCommandSet(cfg)
+	if err != nil {
+		return fmt.Errorf("invalid voice commands in %s: %v", path, err)
+	}
+
+	s.mu.Lock()
+	s.commands = set
+	// A running command mode switches to the new vocabulary right away
+	if s.isListening && s.mode == ModeCommand && s.recognizer != nil {
+		s.recognizer.SetGrm(set.Grammar(s.knownWordLocked))
+	}
+	s.mu.Unlock()
+	log.Printf("🗣️ Loaded %d voice commands from %s", len(cfg.Commands), path)
+	return nil
+}
+
+// ReloadCommands re-reads voice-commands.json after the teacher edited it
+func (s *SpeechService) ReloadCommands() error {
+	s.mu.Lock()
+	path := s.commandsPath
+	s.mu.Unlock()
+	if path == "" {
+		return fmt.Errorf("no voice command file configured")
+	}
+	return LoadCommands(s, path)
+}
+
+// commandSet returns the active grammar, the built-in one until LoadCommands succeeds
+func (s *SpeechService) commandSet() *CommandSet {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.commandSetLocked()
+}
+
+func (s *SpeechService) commandSetLocked() *CommandSet {
+	if s.commands == nil {
+		s.commands, _ = NewCommandSet(DefaultCommandConfig())
+	}
+	return s.commands
+}
@@ -0,0 +1,120 @@
+package speech
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDefaultGrammarMatchesCommands(t *testing.T) {
+	set, err := NewCommandSet(DefaultCommandConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		said   string
+		action string
+		params map[string]interface{}
+	}{
+		{"kırmızı kalem", "tool-change", map[string]interface{}{"tool": "pencil", "color": "#FF0000"}},
+		{"kalem açık mavi", "tool-change", map[string]interface{}{"color": "#87CEEB"}},
+		{"kırmız kalem", "tool-change", map[string]interface{}{"color": "#FF0000"}},
+		{"silgi", "tool-change", map[string]interface{}{"tool": "eraser"}},
+		{"kalınlık on iki", "brush-size", map[string]interface{}{"size": 12}},
+		{"sayfa yirmi üç", "page-go", map[string]interface{}{"page": 23}},
+		{"beş numaralı sayfa", "page-go", map[string]interface{}{"page": 5}},
+		{"sonraki sayfa", "page-next", nil},
+		{"ileri sayfa", "page-next", nil},
+		{"yeni sayfa", "page-add", nil},
+		{"sayfa ekle", "page-add", nil},
+		{"önceki sayfa", "page-prev", nil},
+		{"geri al", "undo", nil},
+		{"ileri al", "redo", nil},
+		{"yinele", "redo", nil},
+		{"Kaydı Başlat", "recording-start", nil},
+		{"yoklama al", "attendance", nil},
+	} {
+		m, ok := set.Match(tt.said)
+		if !ok || m.Payload["action"] != tt.action {
+			t.Errorf("%q = %v, want %s", tt.said, m.Payload, tt.action)
+			continue
+		}
+		for key, want := range tt.params {
+			if m.Payload[key] != want {
+				t.Errorf("%q: %s = %v, want %v", tt.said, key, m.Payload[key], want)
+			}
+		}
+	}
+
+	for _, said := range []string{
+		"",
+		"sayfa",
+		"sayfa bin",            // above the slot's maximum
+		"kırmızı kalem lütfen", // the whole utterance must match
+		"bugün türev konusuna geçiyoruz",
+		"al", // short words must be heard exactly
+	} {
+		if m, ok := set.Match(said); ok {
+			t.Errorf("%q matched %v", said, m.Payload)
+		}
+	}
+}
+
+// Commands that differ in one word must not be corrected into each other
+func TestSimilarCommandsStayApart(t *testing.T) {
+	set, err := NewCommandSet(DefaultCommandConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, pair := range [][2]string{
+		{"ileri al", "ileri sayfa"},
+		{"geri al", "geri sayfa"},
+		{"geri al", "ileri al"},
+		{"yeni sayfa", "sonraki sayfa"},
+	} {
+		a, b := strings.Fields(pair[0]), strings.Fields(pair[1])
+		for i := range a {
+			if a[i] == b[i] {
+				continue
+			}
+			if d, ok := set.wordDistance(foldTurkish(a[i]), foldTurkish(b[i])); ok {
+				t.Errorf("%q and %q are %d letters apart and would be confused", a[i], b[i], d)
+			}
+		}
+	}
+	// A slip on the shared word still finds the right command
+	if m, ok := set.Match("ileri sayf"); !ok || m.Payload["action"] != "page-next" {
+		t.Errorf("ileri sayf = %v, want page-next", m.Payload)
+	}
+}
+
+func TestParseTurkishNumber(t *testing.T) {
+	for _, tt := range []struct {
+		said string
+		want int
+		ok   bool
+	}{
+		{"sıfır", 0, true},
+		{"beş", 5, true},
+		{"on iki", 12, true},
+		{"yirmi üç", 23, true},
+		{"Kırk Dört", 44, true},
+		{"yüz", 100, true},
+		{"iki yüz elli", 250, true},
+		{"bin dokuz yüz seksen dört", 1984, true},
+		{"iki bin yirmi altı", 2026, true},
+		{"bir milyon iki yüz bin", 1200000, true},
+		{"42", 42, true},
+		{"üç iki", 0, false},
+		{"on yirmi", 0, false},
+		{"yirmi yüz", 0, false},
+		{"bin bin", 0, false},
+		{"bin milyon", 0, false},
+		{"elma", 0, false},
+		{"", 0, false},
+	} {
+		got, ok := ParseTurkishNumber(strings.Fields(tt.said))
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("ParseTurkishNumber(%q) = %d, %v; want %d, %v", tt.said, got, ok, tt.want, tt.ok)
+		}
+	}
+}
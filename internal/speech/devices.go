@@ -0,0 +1,131 @@
+package speech
+
+import (
+	"fmt"
+	"log"
+	"math"
+
+	"github.com/gordonklaus/portaudio"
+)
+
+// InputDevice is a microphone PortAudio can open
+type InputDevice struct {
+	Name       string  `json:"name"`
+	HostAPI    string  `json:"hostApi"`
+	Channels   int     `json:"channels"`
+	SampleRate float64 `json:"sampleRate"` // native rate
+	Default    bool    `json:"default"`
+	Selected   bool    `json:"selected"`
+}
+
+// ListInputDevices enumerates the microphones. Devices are selected by name:
+// PortAudio's indexes change when a USB microphone is plugged in.
+func (s *SpeechService) ListInputDevices() ([]InputDevice, error) {
+	devices, err := portaudio.Devices()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audio devices: %v", err)
+	}
+	defaultName := ""
+	if def, err := portaudio.DefaultInputDevice(); err == nil && def != nil {
+		defaultName = def.Name
+	}
+
+	s.mu.Lock()
+	selected := s.deviceName
+	s.mu.Unlock()
+
+	inputs := []InputDevice{}
+	for _, dev := range devices {
+		if dev.MaxInputChannels < 1 {
+			continue
+		}
+		device := InputDevice{
+			Name:       dev.Name,
+			Channels:   dev.MaxInputChannels,
+			SampleRate: dev.DefaultSampleRate,
+			Default:    dev.Name == defaultName,
+			Selected:   dev.Name == selected,
+		}
+		if dev.HostApi != nil {
+			device.HostAPI = dev.HostApi.Name
+		}
+		inputs = append(inputs, device)
+	}
+	return inputs, nil
+}
+
+// SetInputDevice selects the microphone by name; empty means the system default.
+// A running stream keeps its device until dictation and recording have stopped.
+func (s *SpeechService) SetInputDevice(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if name == s.deviceName {
+		return
+	}
+	s.deviceName = name
+	s.reopenSource = true
+	log.Printf("🎙️ Microphone set to: %q", name)
+}
+
+// openMicrophone opens the selected microphone, falling back to the default
+// one if it was unplugged. Devices that can't deliver 16 kHz mono themselves
+// (most USB and built-in microphones only do 44.1/48 kHz) are captured in
+// their own format and converted. s.mu is held by the caller (startStreamLocked).
+func (s *SpeechService) openMicrophone(buffer []int16) (AudioSource, error) {
+	dev, err := portaudio.DefaultInputDevice()
+	if s.deviceName != "" {
+		if selected, findErr := findInputDevice(s.deviceName); findErr == nil {
+			dev, err = selected, nil
+		} else {
+			log.Printf("⚠️ %v, using the default microphone", findErr)
+		}
+	}
+	if err != nil || dev == nil {
+		// No device information at all: let PortAudio pick
+		return openPortAudio(buffer)
+	}
+
+	// The recognizer's own format needs no conversion
+	params := portaudio.StreamParameters{
+		Input: portaudio.StreamDeviceParameters{
+			Device:   dev,
+			Channels: 1,
+			Latency:  dev.DefaultLowInputLatency,
+		},
+		SampleRate:      audioSampleRate,
+		FramesPerBuffer: len(buffer),
+	}
+	if portaudio.IsFormatSupported(params, buffer) == nil {
+		if stream, err := portaudio.OpenStream(params, buffer); err == nil {
+			return stream, nil
+		}
+	}
+
+	// Native rate and channels, same buffer duration
+	channels := min(max(dev.MaxInputChannels, 1), 2)
+	rate := dev.DefaultSampleRate
+	frames := int(math.Round(float64(len(buffer)) * rate / audioSampleRate))
+	raw := make([]int16, frames*channels)
+	params.Input.Channels = channels
+	params.SampleRate = rate
+	params.FramesPerBuffer = frames
+	stream, err := portaudio.OpenStream(params, raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s at %.0f Hz: %v", dev.Name, rate, err)
+	}
+	log.Printf("🎙️ %s: capturing %.0f Hz, %d channel(s), converting to %d Hz mono", dev.Name, rate, channels, audioSampleRate)
+	return newResamplingSource(stream, raw, channels, rate, buffer), nil
+}
+
+func findInputDevice(name string) (*portaudio.DeviceInfo, error) {
+	devices, err := portaudio.Devices()
+	if err != nil {
+		return nil, err
+	}
+	for _, dev := range devices {
+		if dev.Name == name && dev.MaxInputChannels > 0 {
+			return dev, nil
+		}
+	}
+	return nil, fmt.Errorf("microphone %q not found", name)
+}
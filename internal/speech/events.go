@@ -0,0 +1,30 @@
+package speech
+
+import (
+	"context"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// EventSink receives the events SpeechService reports to the frontend
+// ("speech-text", "voice-command", ...)
+type EventSink interface {
+	Emit(event string, data ...interface{})
+}
+
+// wailsEvents forwards events to the frontend
+type wailsEvents struct {
+	ctx context.Context
+}
+
+func (w wailsEvents) Emit(event string, data ...interface{}) {
+	runtime.EventsEmit(w.ctx, event, data...)
+}
+
+// emit reports an event; before Startup there is nobody to tell.
+// s.events is only set before capture starts, so s.mu may or may not be held.
+func (s *SpeechService) emit(event string, data ...interface{}) {
+	if s.events != nil {
+		s.events.Emit(event, data...)
+	}
+}
@@ -0,0 +1,276 @@
+package speech
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ModelInfo describes an installed Vosk model
+type ModelInfo struct {
+	Name     string  `json:"name"` // folder name, e.g. "vosk-model-small-tr-0.3"
+	Path     string  `json:"path"`
+	Language string  `json:"language"` // from the folder name, e.g. "tr", "en-us"
+	SizeMB   float64 `json:"sizeMB"`
+	Bundled  bool    `json:"bundled"` // shipped next to the executable, read-only
+	Valid    bool    `json:"valid"`
+	Problem  string  `json:"problem,omitempty"` // why the model can't be loaded
+	Active   bool    `json:"active"`
+}
+
+// Limits of a model archive, so a broken or hostile zip can't fill the disk.
+// The largest Vosk models unpack to about 3 GB in a few hundred files.
+const (
+	maxModelSize  = 6 << 30
+	maxModelFiles = 10000
+)
+
+// ModelManager finds, validates, installs and switches speech models.
+// Models are folders in the data directory's models folder; models bundled
+// by the installer next to the executable are listed too.
+type ModelManager struct {
+	speech     *SpeechService
+	dir        string // installs go here
+	bundledDir string
+	mu         sync.Mutex // serializes installs and switches
+}
+
+// NewModelManager creates a manager installing into dir
+func NewModelManager(speech *SpeechService, dir string) *ModelManager {
+	m := &ModelManager{speech: speech, dir: dir}
+	if exe, err := os.Executable(); err == nil {
+		m.bundledDir = filepath.Join(filepath.Dir(exe), "models")
+	}
+	return m
+}
+
+// modelLanguage matches "vosk-model-small-tr-0.3", "vosk-model-en-us-0.22-lgraph"
+var modelLanguage = regexp.MustCompile(`^vosk-model-(?:small-)?([a-z]{2}(?:-[a-z]{2})?)-\d`)
+
+// ListModels returns the installed models, valid ones first
+func (m *ModelManager) ListModels() []ModelInfo {
+	active := filepath.Clean(m.speech.ModelPath())
+	models := []ModelInfo{}
+	seen := map[string]bool{}
+	for _, dir := range []string{m.dir, m.bundledDir} {
+		if dir == "" {
+			continue
+		}
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			// ".install-*" folders are unfinished installs
+			if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") || seen[entry.Name()] {
+				continue
+			}
+			seen[entry.Name()] = true
+			info := ModelInfo{
+				Name:    entry.Name(),
+				Path:    filepath.Join(dir, entry.Name()),
+				Bundled: dir == m.bundledDir,
+			}
+			if match := modelLanguage.FindStringSubmatch(info.Name); match != nil {
+				info.Language = match[1]
+			}
+			if err := ValidateModel(info.Path); err != nil {
+				info.Problem = err.Error()
+			} else {
+				info.Valid = true
+			}
+			info.SizeMB = float64(dirSize(info.Path)) / (1024 * 1024)
+			info.Active = filepath.Clean(info.Path) == active
+			models = append(models, info)
+		}
+	}
+	sort.SliceStable(models, func(i, j int) bool { return models[i].Valid && !models[j].Valid })
+	return models
+}
+
+// ValidateModel checks that a folder has the files Vosk needs. Vosk itself
+// only logs a C++ error for a broken model, so this explains what is missing.
+func ValidateModel(path string) error {
+	info, err := os.Stat(path)
+	if err != nil || !info.IsDir() {
+		return fmt.Errorf("model folder not found: %s", path)
+	}
+	for _, required := range []string{"am/final.mdl", "conf/mfcc.conf"} {
+		if !fileExists(filepath.Join(path, required)) {
+			return fmt.Errorf("missing %s", required)
+		}
+	}
+	// Static graph (large models) or lookahead graph (small models)
+	hclg := fileExists(filepath.Join(path, "graph", "HCLG.fst"))
+	lookahead := fileExists(filepath.Join(path, "graph", "HCLr.fst")) && fileExists(filepath.Join(path, "graph", "Gr.fst"))
+	if !hclg && !lookahead {
+		return fmt.Errorf("missing graph/HCLG.fst or graph/HCLr.fst + graph/Gr.fst")
+	}
+	return nil
+}
+
+// Resolve picks the model to load at startup: the named one if usable,
+// otherwise the first valid Turkish model, otherwise any valid model.
+// Returns "" when nothing is installed.
+func (m *ModelManager) Resolve(name string) string {
+	models := m.ListModels()
+	if name != "" {
+		for _, model := range models {
+			if model.Name == name && model.Valid {
+				return model.Path
+			}
+		}
+		log.Printf("⚠️ Speech model %s not usable, picking another", name)
+	}
+	for _, model := range models {
+		if model.Valid && strings.HasPrefix(model.Language, "tr") {
+			return model.Path
+		}
+	}
+	for _, model := range models {
+		if model.Valid {
+			return model.Path
+		}
+	}
+	return ""
+}
+
+// SwitchModel loads an installed model by name
+func (m *ModelManager) SwitchModel(name string) (ModelInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, model := range m.ListModels() {
+		if model.Name != name {
+			continue
+		}
+		if !model.Valid {
+			return model, fmt.Errorf("model %s is incomplete: %s", name, model.Problem)
+		}
+		if err := m.speech.LoadModel(model.Path); err != nil {
+			return model, err
+		}
+		model.Active = true
+		return model, nil
+	}
+	return ModelInfo{}, fmt.Errorf("model not found: %s", name)
+}
+
+// InstallZip unpacks a model archive as downloaded from alphacephei.com into
+// the models folder. The archive is validated before anything is moved into
+// place, so a truncated download never shows up as an installed model.
+func (m *ModelManager) InstallZip(zipPath string) (ModelInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	archive, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return ModelInfo{}, fmt.Errorf("failed to open model archive: %v", err)
+	}
+	defer archive.Close()
+	if len(archive.File) > maxModelFiles {
+		return ModelInfo{}, fmt.Errorf("model archive has too many files (%d, at most %d)", len(archive.File), maxModelFiles)
+	}
+
+	tmp, err := os.MkdirTemp(m.dir, ".install-")
+	if err != nil {
+		return ModelInfo{}, fmt.Errorf("failed to install model: %v", err)
+	}
+	defer os.RemoveAll(tmp)
+
+	// Sizes in the archive can lie, so the budget counts the bytes actually written
+	budget := int64(maxModelSize)
+	for _, file := range archive.File {
+		written, err := extractZipFile(file, tmp, budget)
+		if err != nil {
+			return ModelInfo{}, fmt.Errorf("failed to extract %s: %v", file.Name, err)
+		}
+		budget -= written
+	}
+
+	// Archives usually hold a single "vosk-model-..." folder, but accept a bare model too
+	root, name := tmp, strings.TrimSuffix(filepath.Base(zipPath), filepath.Ext(zipPath))
+	if entries, err := os.ReadDir(tmp); err == nil && len(entries) == 1 && entries[0].IsDir() {
+		root, name = filepath.Join(tmp, entries[0].Name()), entries[0].Name()
+	}
+	if err := ValidateModel(root); err != nil {
+		return ModelInfo{}, fmt.Errorf("not a Vosk model: %v", err)
+	}
+
+	target := filepath.Join(m.dir, name)
+	if fileExists(target) {
+		return ModelInfo{}, fmt.Errorf("model %s is already installed", name)
+	}
+	if err := os.Rename(root, target); err != nil {
+		return ModelInfo{}, fmt.Errorf("failed to install model: %v", err)
+	}
+	log.Printf("📦 Installed speech model: %s", name)
+
+	for _, model := range m.ListModels() {
+		if model.Name == name {
+			return model, nil
+		}
+	}
+	return ModelInfo{Name: name, Path: target, Valid: true}, nil
+}
+
+// extractZipFile writes one archive entry below dir, refusing paths that
+// escape it and content beyond limit bytes. Returns the bytes written.
+func extractZipFile(file *zip.File, dir string, limit int64) (int64, error) {
+	path := filepath.Join(dir, filepath.FromSlash(file.Name))
+	if !strings.HasPrefix(path, filepath.Clean(dir)+string(os.PathSeparator)) {
+		return 0, fmt.Errorf("illegal path in archive")
+	}
+	if file.FileInfo().IsDir() {
+		return 0, os.MkdirAll(path, 0755)
+	}
+	if file.UncompressedSize64 > uint64(limit) {
+		return 0, fmt.Errorf("model archive unpacks to more than %d GB", maxModelSize>>30)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return 0, err
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return 0, err
+	}
+	defer src.Close()
+
+	dst, err := os.Create(path)
+	if err != nil {
+		return 0, err
+	}
+	written, err := io.Copy(dst, io.LimitReader(src, limit+1))
+	if err == nil && written > limit {
+		err = fmt.Errorf("model archive unpacks to more than %d GB", maxModelSize>>30)
+	}
+	if err != nil {
+		dst.Close()
+		return written, err
+	}
+	return written, dst.Close()
+}
+
+func dirSize(dir string) int64 {
+	var size int64
+	filepath.Walk(dir, func(_ string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
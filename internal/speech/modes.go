@@ -0,0 +1,160 @@
+package speech
+
+import (
+	"encoding/json"
+	"log"
+	"time"
+
+	vosk "github.com/alphacep/vosk-api/go"
+)
+
+// Listening modes
+const (
+	// ModeDictation recognizes free text; commands need the wake phrase or push-to-talk
+	ModeDictation = "dictation"
+	// ModeCommand only listens for the command vocabulary with a grammar-constrained
+	// recognizer: more accurate and much lighter on i3 boards than free dictation
+	ModeCommand = "command"
+	// ModeCaption transcribes continuously for live captions and never runs commands
+	ModeCaption = "caption"
+)
+
+// StartCommandMode listens for "Ders Dostu <command>" without dictating text
+func (s *SpeechService) StartCommandMode() error {
+	return s.startListening(ModeCommand)
+}
+
+// GetMode returns the current listening mode, empty when not listening
+func (s *SpeechService) GetMode() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.isListening {
+		return ""
+	}
+	return s.mode
+}
+
+// SetPushToTalk is called when the push-to-talk key or button is pressed and
+// released. While held, utterances are commands without the wake phrase. If
+// nothing was listening, command mode runs for as long as it is held.
+func (s *SpeechService) SetPushToTalk(held bool) error {
+	if held {
+		s.mu.Lock()
+		already := s.pushToTalk
+		listening := s.isListening
+		s.pushToTalk = true
+		s.mu.Unlock()
+		if already {
+			return nil
+		}
+
+		if !listening {
+			if err := s.startListening(ModeCommand); err != nil {
+				s.mu.Lock()
+				s.pushToTalk = false
+				s.mu.Unlock()
+				return err
+			}
+			s.mu.Lock()
+			s.pttStarted = true
+			s.mu.Unlock()
+		}
+		s.emit("speech-ptt", true)
+		return nil
+	}
+
+	// Released: finish the utterance now instead of waiting for a pause
+	s.mu.Lock()
+	if !s.pushToTalk {
+		s.mu.Unlock()
+		return nil
+	}
+	text := ""
+	if s.isListening && s.recognizer != nil {
+		var result VoskResult
+		if err := json.Unmarshal([]byte(s.recognizer.FinalResult()), &result); err == nil {
+			text = result.Text
+		}
+	}
+	started := s.pttStarted
+	s.pushToTalk, s.pttStarted = false, false
+	s.mu.Unlock()
+
+	if text != "" {
+		s.handleResult(text, true)
+	}
+	if started {
+		s.StopDictation()
+	}
+	s.emit("speech-ptt", false)
+	return nil
+}
+
+// handleCommand runs text as a command if it was addressed to the board: it
+// starts with the wake phrase, push-to-talk is held, or the wake phrase was
+// said alone just before. Returns true if the text was consumed.
+func (s *SpeechService) handleCommand(text string, pushToTalk bool) bool {
+	set := s.commandSet()
+	rest, woke := set.StripWake(text)
+
+	s.mu.Lock()
+	held := s.pushToTalk || pushToTalk
+	armed := time.Now().Before(s.armedUntil)
+	s.mu.Unlock()
+
+	if !woke && !held && !armed {
+		return false
+	}
+
+	if rest == "" {
+		if woke {
+			s.mu.Lock()
+			s.armedUntil = time.Now().Add(set.WakeWindow())
+			s.mu.Unlock()
+			log.Println("👂 Wake phrase heard, waiting for a command")
+			s.emit("voice-wake", true)
+		}
+		return true
+	}
+
+	s.mu.Lock()
+	s.armedUntil = time.Time{}
+	s.mu.Unlock()
+
+	match, ok := set.Match(rest)
+	if !ok {
+		log.Printf("🗣️ Unknown command: %s", rest)
+		// After a lone wake phrase the teacher may simply have gone on talking
+		return woke || held
+	}
+
+	s.emit("voice-command", match.Payload)
+	log.Printf("🗣️ Command: %s (%s)", match.Command.Action, match.Command.Name)
+	return true
+}
+
+// newRecognizerLocked creates the recognizer for a listening mode: free
+// dictation, or limited to the command vocabulary. s.mu must be held.
+func (s *SpeechService) newRecognizerLocked(mode string) (speechRecognizer, error) {
+	if s.newRecognizer != nil {
+		return s.newRecognizer(mode)
+	}
+
+	var rec *vosk.VoskRecognizer
+	var err error
+	if mode == ModeCommand {
+		rec, err = vosk.NewRecognizerGrm(s.model, 16000.0, s.commandSetLocked().Grammar(s.knownWordLocked))
+	} else {
+		rec, err = vosk.NewRecognizer(s.model, 16000.0)
+	}
+	if err != nil {
+		return nil, err
+	}
+	rec.SetWords(1)
+	return rec, nil
+}
+
+// knownWordLocked reports whether the loaded model can recognize a word. s.mu must be held.
+func (s *SpeechService) knownWordLocked(word string) bool {
+	return s.model != nil && s.model.FindWord(word) >= 0
+}
@@ -0,0 +1,308 @@
+package speech
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// DictationConfig is the content of dictation.json: how recognized text is
+// cleaned up before it reaches the textbox
+type DictationConfig struct {
+	// SpokenPunctuation turns "nokta", "virgül", "yeni satır"... into marks
+	SpokenPunctuation bool `json:"spokenPunctuation"`
+	// DigitNumbers writes "yirmi üç" as 23. A lone "bir", "on", "altı", "yedi",
+	// "yüz" or "bin" stays a word.
+	DigitNumbers bool `json:"digitNumbers"`
+	// Replacements maps spoken phrases to how they are written, e.g. "a be de" -> "ABD"
+	Replacements map[string]string `json:"replacements"`
+}
+
+// DefaultDictationConfig returns the built-in settings, written to
+// dictation.json on first start so the vocabulary can be extended
+func DefaultDictationConfig() DictationConfig {
+	return DictationConfig{
+		SpokenPunctuation: true,
+		DigitNumbers:      true,
+		Replacements: map[string]string{
+			"atatürk":    "Atatürk",
+			"türkiye":    "Türkiye",
+			"ankara":     "Ankara",
+			"istanbul":   "İstanbul",
+			"ders dostu": "Ders Dostu",
+			"a be de":    "ABD",
+			"de en a":    "DNA",
+		},
+	}
+}
+
+// LoadDictationConfig reads dictation.json, creating it with the defaults if missing
+func LoadDictationConfig(path string) (DictationConfig, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		cfg := DefaultDictationConfig()
+		if err := saveDictationConfig(path, cfg); err != nil {
+			log.Printf("⚠️ Could not write default dictation settings: %v", err)
+		}
+		return cfg, nil
+	}
+	if err != nil {
+		return DictationConfig{}, fmt.Errorf("failed to read dictation settings: %v", err)
+	}
+
+	// Switches missing from the file keep their defaults
+	cfg := DefaultDictationConfig()
+	cfg.Replacements = nil
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return DictationConfig{}, fmt.Errorf("failed to parse dictation settings %s: %v", path, err)
+	}
+	return cfg, nil
+}
+
+func saveDictationConfig(path string, cfg DictationConfig) error {
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Clean(path))
+}
+
+// spokenPunctuation maps folded spoken phrases to marks
+var spokenPunctuation = map[string]string{
+	"nokta":              ".",
+	"virgul":             ",",
+	"soru isareti":       "?",
+	"unlem isareti":      "!",
+	"unlem":              "!",
+	"iki nokta ust uste": ":",
+	"noktali virgul":     ";",
+	"yeni satir":         "\n",
+	"yeni paragraf":      "\n\n",
+	"parantez ac":        "(",
+	"parantez kapat":     ")",
+	"parantez kapa":      ")",
+	"tire":               "-",
+}
+
+// ambiguousNumbers are ordinary words when said alone: "bir gün", "masanın altı",
+// "yüz yüze", "otobüse bin", "yemek yedi". A lone "on" is kept too, as "ön" is
+// often heard without its dots.
+var ambiguousNumbers = map[string]bool{"bir": true, "on": true, "altı": true, "yedi": true, "yüz": true, "bin": true}
+
+// spelledNumbers are the number words as Turkish spells them. Numbers are
+// matched with diacritics, so "ön" and "uç" aren't read as 10 and 3.
+var spelledNumbers = map[string]bool{
+	"sıfır": true, "bir": true, "iki": true, "üç": true, "dört": true,
+	"beş": true, "altı": true, "yedi": true, "sekiz": true, "dokuz": true,
+	"on": true, "yirmi": true, "otuz": true, "kırk": true, "elli": true,
+	"altmış": true, "yetmiş": true, "seksen": true, "doksan": true,
+	"yüz": true, "bin": true, "milyon": true,
+}
+
+// phrase is a spoken word sequence and what it is written as
+type phrase struct {
+	spoken  []string // folded words
+	written string
+}
+
+// Normalizer turns raw Vosk output into text ready for a textbox
+type Normalizer struct {
+	digits  bool
+	phrases []phrase // replacements and punctuation, longest first
+}
+
+// NewNormalizer compiles the settings
+func NewNormalizer(cfg DictationConfig) *Normalizer {
+	n := &Normalizer{digits: cfg.DigitNumbers}
+	if cfg.SpokenPunctuation {
+		for spoken, mark := range spokenPunctuation {
+			n.phrases = append(n.phrases, phrase{strings.Fields(spoken), mark})
+		}
+	}
+	for spoken, written := range cfg.Replacements {
+		if words := strings.Fields(foldTurkish(spoken)); len(words) > 0 {
+			n.phrases = append(n.phrases, phrase{words, written})
+		}
+	}
+	// Longest first, so "iki nokta üst üste" wins over "iki" and "nokta"
+	sort.SliceStable(n.phrases, func(i, j int) bool {
+		if len(n.phrases[i].spoken) != len(n.phrases[j].spoken) {
+			return len(n.phrases[i].spoken) > len(n.phrases[j].spoken)
+		}
+		return strings.Join(n.phrases[i].spoken, " ") < strings.Join(n.phrases[j].spoken, " ")
+	})
+	return n
+}
+
+// Normalize punctuates, capitalizes and rewrites one recognized utterance.
+// sentenceStart tells whether the previous utterance ended a sentence; the
+// returned bool is the same for this one, to be passed to the next call.
+func (n *Normalizer) Normalize(text string, sentenceStart bool) (string, bool) {
+	words := strings.Fields(text)
+	folded := make([]string, len(words))
+	lowered := make([]string, len(words))
+	for i, word := range words {
+		folded[i] = foldTurkish(word)
+		lowered[i] = lowerTurkish(word)
+	}
+
+	tokens := []string{}
+	for i := 0; i < len(words); {
+		if written, size := n.matchPhrase(folded[i:]); size > 0 {
+			tokens = append(tokens, written)
+			i += size
+			continue
+		}
+		if n.digits {
+			if value, size := matchNumber(lowered[i:]); size > 0 {
+				tokens = append(tokens, strconv.Itoa(value))
+				i += size
+				continue
+			}
+		}
+		tokens = append(tokens, words[i])
+		i++
+	}
+
+	return joinTokens(tokens, sentenceStart)
+}
+
+func (n *Normalizer) matchPhrase(words []string) (string, int) {
+	for _, p := range n.phrases {
+		if len(p.spoken) > len(words) {
+			continue
+		}
+		match := true
+		for i, word := range p.spoken {
+			if words[i] != word {
+				match = false
+				break
+			}
+		}
+		if match {
+			return p.written, len(p.spoken)
+		}
+	}
+	return "", 0
+}
+
+// matchNumber reads the longest number at the start of words, which are
+// lowercased but keep their diacritics
+func matchNumber(words []string) (int, int) {
+	run := 0
+	for run < len(words) && isNumberWord(words[run]) {
+		run++
+	}
+	for size := run; size > 0; size-- {
+		if size == 1 && ambiguousNumbers[words[0]] {
+			return 0, 0
+		}
+		if value, ok := ParseTurkishNumber(words[:size]); ok {
+			return value, size
+		}
+	}
+	return 0, 0
+}
+
+func isNumberWord(word string) bool {
+	return spelledNumbers[word]
+}
+
+// joinTokens spaces words and marks and capitalizes sentence starts
+func joinTokens(tokens []string, sentenceStart bool) (string, bool) {
+	var b strings.Builder
+	glue := false // the next token attaches without a space
+	for i, token := range tokens {
+		if strings.HasPrefix(token, "\n") {
+			b.WriteString(token)
+			sentenceStart, glue = true, true
+			continue
+		}
+		if isClosingMark(token) {
+			b.WriteString(token)
+			// "üç virgül beş" -> 3,5
+			glue = token == "," && i > 0 && i+1 < len(tokens) && isDigits(tokens[i-1]) && isDigits(tokens[i+1])
+			if token == "." || token == "?" || token == "!" {
+				sentenceStart = true
+			}
+			continue
+		}
+
+		if b.Len() > 0 && !glue {
+			b.WriteByte(' ')
+		}
+		glue = token == "("
+		if sentenceStart && token != "(" && token != "-" {
+			token = capitalizeTurkish(token)
+			sentenceStart = false
+		}
+		b.WriteString(token)
+	}
+	return b.String(), sentenceStart
+}
+
+func isClosingMark(token string) bool {
+	switch token {
+	case ".", ",", "?", "!", ":", ";", ")":
+		return true
+	}
+	return false
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// capitalizeTurkish uppercases the first letter with Turkish rules: i -> İ, ı -> I
+func capitalizeTurkish(word string) string {
+	r, size := utf8.DecodeRuneInString(word)
+	if r == utf8.RuneError || !unicode.IsLower(r) {
+		return word
+	}
+	return strings.ToUpperSpecial(unicode.TurkishCase, string(r)) + word[size:]
+}
+
+// LoadDictation loads dictation.json into s; until then the defaults apply
+func LoadDictation(s *SpeechService, path string) error {
+	cfg, err := LoadDictationConfig(path)
+	if err != nil {
+		return err
+	}
+	normalizer := NewNormalizer(cfg)
+
+	s.mu.Lock()
+	s.normalizer = normalizer
+	s.mu.Unlock()
+	log.Printf("📝 Loaded dictation settings (%d replacements) from %s", len(cfg.Replacements), path)
+	return nil
+}
+
+// normalize runs the dictation pipeline on a final result, keeping track of sentence boundaries
+func (s *SpeechService) normalize(text string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.normalizer == nil {
+		s.normalizer = NewNormalizer(DefaultDictationConfig())
+	}
+	text, s.sentenceStart = s.normalizer.Normalize(text, s.sentenceStart)
+	return text
+}
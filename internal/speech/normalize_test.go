@@ -0,0 +1,32 @@
+package speech
+
+import "testing"
+
+func TestNormalizeNumbers(t *testing.T) {
+	n := NewNormalizer(DefaultDictationConfig())
+	for _, tt := range []struct {
+		said string
+		want string
+	}{
+		{"yirmi üç öğrenci", "23 öğrenci"},
+		{"on iki kalem", "12 kalem"},
+		{"yedi yüz", "700"},
+		{"iki bin yirmi altı yılı", "2026 yılı"},
+		{"üç virgül beş", "3,5"},
+		// Words that only look like numbers once their dots are gone
+		{"ön tarafa bak", "ön tarafa bak"},
+		{"kalemin uç kısmı", "kalemin uç kısmı"},
+		{"dört köşe", "4 köşe"},
+		// Lone number words that are usually something else
+		{"bir gün", "bir gün"},
+		{"masanın altı", "masanın altı"},
+		{"yüz yüze", "yüz yüze"},
+		{"otobüse bin", "otobüse bin"},
+		{"yemek yedi", "yemek yedi"},
+		{"on dakika", "on dakika"},
+	} {
+		if got, _ := n.Normalize(tt.said, false); got != tt.want {
+			t.Errorf("Normalize(%q) = %q, want %q", tt.said, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package speech
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// turkishNumbers maps folded number words to their value
+var turkishNumbers = map[string]int{
+	"sifir": 0, "bir": 1, "iki": 2, "uc": 3, "dort": 4,
+	"bes": 5, "alti": 6, "yedi": 7, "sekiz": 8, "dokuz": 9,
+	"on": 10, "yirmi": 20, "otuz": 30, "kirk": 40, "elli": 50,
+	"altmis": 60, "yetmis": 70, "seksen": 80, "doksan": 90,
+}
+
+// ParseTurkishNumber reads a number said in words, e.g. "yirmi üç" -> 23 or
+// "bin dokuz yüz" -> 1900. A single token of digits is accepted as well.
+func ParseTurkishNumber(words []string) (int, bool) {
+	if len(words) == 0 {
+		return 0, false
+	}
+	if len(words) == 1 {
+		if n, err := strconv.Atoi(words[0]); err == nil {
+			return n, true
+		}
+	}
+
+	total, current := 0, 0
+	// unit is the size of the previous word, so "üç iki" isn't read as 5
+	unit := 1 << 30
+	seenBin, seenMilyon := false, false
+	for _, word := range words {
+		word = foldTurkish(word)
+		switch word {
+		case "yuz":
+			// Only a single digit may precede yüz: "iki yüz", not "yirmi yüz"
+			if current >= 10 {
+				return 0, false
+			}
+			current = max(current, 1) * 100
+			unit = 100
+			continue
+		case "bin":
+			if seenBin {
+				return 0, false
+			}
+			total += max(current, 1) * 1000
+			current, unit, seenBin = 0, 1<<30, true
+			continue
+		case "milyon":
+			if seenMilyon || seenBin {
+				return 0, false
+			}
+			total += max(current, 1) * 1000000
+			current, unit, seenMilyon = 0, 1<<30, true
+			continue
+		}
+
+		n, ok := turkishNumbers[word]
+		if !ok {
+			return 0, false
+		}
+		size := 1
+		if n >= 10 {
+			size = 10
+		}
+		if size >= unit {
+			return 0, false
+		}
+		current += n
+		unit = size
+	}
+	return total + current, true
+}
+
+// lowerTurkish lowercases with Turkish rules and strips punctuation, keeping
+// diacritics: "Üç," -> "üç"
+func lowerTurkish(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsSpace(r) {
+			return r
+		}
+		return -1
+	}, strings.ToLowerSpecial(unicode.TurkishCase, s))
+}
+
+// foldTurkish lowercases with Turkish rules and strips diacritics and
+// punctuation, so "Kırmızı" and "kirmizi" compare equal
+func foldTurkish(s string) string {
+	s = strings.ToLowerSpecial(unicode.TurkishCase, s)
+	var b strings.Builder
+	for _, r := range s {
+		switch r {
+		case 'ı', 'î':
+			r = 'i'
+		case 'ğ':
+			r = 'g'
+		case 'ü', 'û':
+			r = 'u'
+		case 'ş':
+			r = 's'
+		case 'ö':
+			r = 'o'
+		case 'ç':
+			r = 'c'
+		case 'â':
+			r = 'a'
+		}
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsSpace(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,207 @@
+package speech
+
+import (
+	"math"
+)
+
+// Resampler quality: zero crossings of the sinc kernel on each side, and
+// kernel table resolution per input sample
+const (
+	resampleZeroCrossings = 16
+	resampleTableSteps    = 256
+)
+
+// resampler converts a mono stream between sample rates with a windowed-sinc
+// low-pass filter, so 48 kHz speech isn't aliased into the 8 kHz band Vosk hears.
+// It keeps state between calls; feed it consecutive chunks.
+type resampler struct {
+	step    float64   // input samples per output sample
+	cutoff  float64   // filter cutoff relative to the input Nyquist frequency
+	half    int       // kernel half-width in input samples
+	table   []float64 // kernel from 0 to half, resampleTableSteps points per sample
+	history []float32 // input not yet fully used
+	pos     float64   // position of the next output sample in history
+}
+
+func newResampler(inRate, outRate float64) *resampler {
+	r := &resampler{step: inRate / outRate, cutoff: 1}
+	if outRate < inRate {
+		// Leave a little room below the output Nyquist frequency for the filter slope
+		r.cutoff = 0.95 * outRate / inRate
+	}
+	r.half = int(math.Ceil(resampleZeroCrossings / r.cutoff))
+	r.table = make([]float64, r.half*resampleTableSteps+2)
+	for i := range r.table {
+		t := float64(i) / resampleTableSteps
+		r.table[i] = r.cutoff * sinc(t*r.cutoff) * blackman(t/float64(r.half))
+	}
+	// Start with silence so the first output sample has a full kernel behind it
+	r.history = make([]float32, r.half)
+	r.pos = float64(r.half)
+	return r
+}
+
+func sinc(x float64) float64 {
+	if x == 0 {
+		return 1
+	}
+	return math.Sin(math.Pi*x) / (math.Pi * x)
+}
+
+// blackman is the window for |x| <= 1, centred on 0
+func blackman(x float64) float64 {
+	if x >= 1 || x <= -1 {
+		return 0
+	}
+	return 0.42 + 0.5*math.Cos(math.Pi*x) + 0.08*math.Cos(2*math.Pi*x)
+}
+
+// kernel looks the filter up at distance t input samples
+func (r *resampler) kernel(t float64) float64 {
+	t = math.Abs(t) * resampleTableSteps
+	i := int(t)
+	if i >= len(r.table)-1 {
+		return 0
+	}
+	frac := t - float64(i)
+	return r.table[i] + frac*(r.table[i+1]-r.table[i])
+}
+
+// Process resamples in and appends the output samples that are complete to out
+func (r *resampler) Process(in []float32, out []int16) []int16 {
+	r.history = append(r.history, in...)
+
+	for r.pos+float64(r.half) < float64(len(r.history)) {
+		center := int(r.pos)
+		var sum float64
+		for k := center - r.half + 1; k <= center+r.half; k++ {
+			sum += float64(r.history[k]) * r.kernel(r.pos-float64(k))
+		}
+		out = append(out, clampSample(sum))
+		r.pos += r.step
+	}
+
+	// Drop input the kernel will never reach again
+	if drop := int(r.pos) - r.half; drop > 0 {
+		r.history = r.history[:copy(r.history, r.history[drop:])]
+		r.pos -= float64(drop)
+	}
+	return out
+}
+
+func clampSample(v float64) int16 {
+	v = math.Round(v)
+	if v > math.MaxInt16 {
+		return math.MaxInt16
+	}
+	if v < math.MinInt16 {
+		return math.MinInt16
+	}
+	return int16(v)
+}
+
+// resamplingSource captures at the device's own rate and channel count and
+// delivers 16 kHz mono like any other AudioSource
+type resamplingSource struct {
+	stream   AudioSource
+	raw      []int16 // interleaved frames the stream fills
+	channels int
+	rate     float64
+	rs       *resampler // nil when only the channels differ
+	mono     []float32
+	energy   []float64 // per channel, for downmix
+	used     []bool
+	fifo     []int16 // converted samples not yet handed out
+	buffer   []int16 // filled on every Read
+}
+
+func newResamplingSource(stream AudioSource, raw []int16, channels int, rate float64, buffer []int16) *resamplingSource {
+	src := &resamplingSource{
+		stream:   stream,
+		raw:      raw,
+		channels: channels,
+		rate:     rate,
+		buffer:   buffer,
+		energy:   make([]float64, channels),
+		used:     make([]bool, channels),
+		fifo:     make([]int16, 0, 2*len(buffer)),
+	}
+	if rate != audioSampleRate {
+		src.rs = newResampler(rate, audioSampleRate)
+	}
+	return src
+}
+
+func (src *resamplingSource) Start() error {
+	// Samples from before a Stop would put a jump into the audio
+	src.fifo = src.fifo[:0]
+	if src.rs != nil {
+		src.rs = newResampler(src.rate, audioSampleRate)
+	}
+	return src.stream.Start()
+}
+
+func (src *resamplingSource) Read() error {
+	for len(src.fifo) < len(src.buffer) {
+		if err := src.stream.Read(); err != nil {
+			return err
+		}
+		src.downmix()
+		if src.rs != nil {
+			src.fifo = src.rs.Process(src.mono, src.fifo)
+		} else {
+			for _, v := range src.mono {
+				src.fifo = append(src.fifo, clampSample(float64(v)))
+			}
+		}
+	}
+	copy(src.buffer, src.fifo)
+	src.fifo = src.fifo[:copy(src.fifo, src.fifo[len(src.buffer):])]
+	return nil
+}
+
+// downmix averages the interleaved channels into src.mono. A channel that is
+// silent while another carries sound (a mono microphone on a stereo input) is
+// left out, so it doesn't halve the level.
+func (src *resamplingSource) downmix() {
+	src.mono = src.mono[:0]
+	channels := src.channels
+	if channels <= 1 {
+		for _, sample := range src.raw {
+			src.mono = append(src.mono, float32(sample))
+		}
+		return
+	}
+
+	loudest := 0.0
+	for c := range src.energy {
+		src.energy[c] = 0
+	}
+	for i, sample := range src.raw {
+		src.energy[i%channels] += float64(sample) * float64(sample)
+	}
+	for _, e := range src.energy {
+		loudest = math.Max(loudest, e)
+	}
+	count := 0
+	for c, e := range src.energy {
+		// 30 dB quieter than the loudest channel: nothing connected
+		src.used[c] = e >= loudest/1000
+		if src.used[c] {
+			count++
+		}
+	}
+
+	for frame := 0; frame+channels <= len(src.raw); frame += channels {
+		var sum float32
+		for c := 0; c < channels; c++ {
+			if src.used[c] {
+				sum += float32(src.raw[frame+c])
+			}
+		}
+		src.mono = append(src.mono, sum/float32(count))
+	}
+}
+
+func (src *resamplingSource) Stop() error  { return src.stream.Stop() }
+func (src *resamplingSource) Close() error { return src.stream.Close() }
@@ -0,0 +1,86 @@
+package speech
+
+import (
+	"math"
+	"testing"
+)
+
+// toneStream is a native-format microphone playing a tone on its first channel only
+type toneStream struct {
+	raw       []int16
+	channels  int
+	rate      float64
+	frequency float64
+	offset    int
+}
+
+func (t *toneStream) Start() error { return nil }
+func (t *toneStream) Stop() error  { return nil }
+func (t *toneStream) Close() error { return nil }
+
+func (t *toneStream) Read() error {
+	for i := 0; i < len(t.raw); i += t.channels {
+		phase := 2 * math.Pi * t.frequency * float64(t.offset) / t.rate
+		t.raw[i] = int16(10000 * math.Sin(phase))
+		t.offset++
+	}
+	return nil
+}
+
+// readTone converts a second of a tone and returns its RMS and zero crossings,
+// skipping the filter's start-up
+func readTone(t *testing.T, rate float64, channels int, frequency float64) (float64, int) {
+	t.Helper()
+	buffer := make([]int16, 1600)
+	frames := int(math.Round(float64(len(buffer)) * rate / audioSampleRate))
+	stream := &toneStream{raw: make([]int16, frames*channels), channels: channels, rate: rate, frequency: frequency}
+	src := newResamplingSource(stream, stream.raw, channels, rate, buffer)
+	if err := src.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	var sum float64
+	crossings, count := 0, 0
+	prev := int16(0)
+	for read := 0; read < 11; read++ {
+		if err := src.Read(); err != nil {
+			t.Fatal(err)
+		}
+		if read == 0 {
+			prev = buffer[len(buffer)-1]
+			continue
+		}
+		for _, v := range buffer {
+			sum += float64(v) * float64(v)
+			if (prev < 0) != (v < 0) {
+				crossings++
+			}
+			prev = v
+			count++
+		}
+	}
+	return math.Sqrt(sum / float64(count)), crossings
+}
+
+func TestResamplePreservesSpeechBand(t *testing.T) {
+	want := 10000 / math.Sqrt2
+	for _, rate := range []float64{48000, 44100, 22050, 16000} {
+		// Stereo input with a dead right channel must not lose half the level
+		rms, crossings := readTone(t, rate, 2, 1000)
+		if math.Abs(rms-want)/want > 0.02 {
+			t.Errorf("%.0f Hz: RMS %.0f, want %.0f", rate, rms, want)
+		}
+		// 1 kHz over one second crosses zero 2000 times
+		if crossings < 1990 || crossings > 2010 {
+			t.Errorf("%.0f Hz: %d zero crossings, want about 2000", rate, crossings)
+		}
+	}
+}
+
+func TestResampleRemovesAliasing(t *testing.T) {
+	// 12 kHz can't be represented at 16 kHz and would fold down to 4 kHz
+	rms, _ := readTone(t, 48000, 1, 12000)
+	if db := 20 * math.Log10(rms/(10000/math.Sqrt2)); db > -40 {
+		t.Errorf("12 kHz tone only attenuated by %.1f dB", -db)
+	}
+}
@@ -3,19 +3,22 @@ package speech
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"strings"
 	"sync"
+	"time"
 
 	vosk "github.com/alphacep/vosk-api/go"
 	"github.com/gordonklaus/portaudio"
-	"github.com/wailsapp/wails/v2/pkg/runtime"
 )
 
 // VoskResult represents the JSON result from Vosk
 type VoskResult struct {
-	Text string `json:"text"`
+	Text   string     `json:"text"`
+	Result []VoskWord `json:"result"` // word timestamps, see SetWords
 }
 
 // VoskPartialResult represents partial recognition results
@@ -23,6 +26,16 @@ type VoskPartialResult struct {
 	Partial string `json:"partial"`
 }
 
+// speechRecognizer is the part of *vosk.VoskRecognizer the service uses
+type speechRecognizer interface {
+	AcceptWaveform(buffer []byte) int
+	Result() string
+	PartialResult() string
+	FinalResult() string
+	SetGrm(grammar string)
+	Free()
+}
+
 // SpeechService handles offline speech recognition using Vosk
 type SpeechService struct {
 	ctx         context.Context
@@ -30,23 +43,76 @@ type SpeechService struct {
 	mu          sync.Mutex
 	wg          sync.WaitGroup
 	model       *vosk.VoskModel
-	recognizer  *vosk.VoskRecognizer
-	stream      *portaudio.Stream
-	buffer      []int16 // Shared buffer for persistent stream
+	recognizer  speechRecognizer
+	source      AudioSource     // persistent microphone stream, opened on first use
+	openSource  AudioSourceFunc // PortAudio unless replaced, see NewSpeechServiceWith
+	events      EventSink       // Wails events unless replaced
+	buffer      []int16         // Shared buffer for persistent stream
+	pcm         []byte          // buffer converted for the recognizer
 	stopChan    chan bool
+	// newRecognizer replaces the Vosk recognizer in tests
+	newRecognizer func(mode string) (speechRecognizer, error)
+	// streamRunning is true between stream.Start and stream.Stop; starting a
+	// running stream fails, and dictation and taps start it independently
+	streamRunning  bool
+	capturing      bool                // processAudio is running
+	taps           map[string]AudioTap // other consumers of the stream, see addAudioTap
+	commands       *CommandSet         // voice command grammar, see LoadCommands
+	commandsPath   string
+	normalizer     *Normalizer // dictation clean-up, see LoadDictation
+	sentenceStart  bool        // the next dictated word starts a sentence
+	mode           string      // ModeDictation, ModeCommand or ModeCaption while listening
+	pushToTalk     bool        // push-to-talk is held
+	pttStarted     bool        // listening was started by push-to-talk and ends with it
+	armedUntil     time.Time   // the wake phrase was said alone; the next utterance is a command
+	vad            *voiceDetector
+	utteranceStart time.Time     // when the voice detector heard the current utterance begin
+	preroll        []int16       // last buffer before speech, fed when speech starts
+	silenceStop    time.Duration // dictation stops after this much silence, 0 = never
+	autoStopping   bool
+	noSignalWarned bool // warned about a muted microphone this session
+	deviceName     string
+	reopenSource   bool          // the microphone changed; reopen when the stream next starts
+	captions       []CaptionLine // rolling caption buffer, see GetCaptions
+	captionSession time.Time
+	captionLast    time.Time // end of the last caption line
+	captionSeq     int
+	onCaption      func(CaptionLine)
+	onPartial      func(string) // caption words still being spoken
+	modelPath      string
+	retired        []*vosk.VoskModel // replaced models still used by batch jobs, freed on shutdown
+	jobs           sync.WaitGroup    // batch jobs using the model, see acquireModel
+	activeJobs     int
+	closing        bool
 }
 
 // NewSpeechService creates a new speech service instance
 func NewSpeechService() *SpeechService {
-	return &SpeechService{
+	return NewSpeechServiceWith(nil, nil)
+}
+
+// NewSpeechServiceWith creates a speech service reading from another audio
+// source (a WAV file, a synthetic signal) and reporting to another event sink.
+// nil selects the microphone and Wails events.
+func NewSpeechServiceWith(open AudioSourceFunc, events EventSink) *SpeechService {
+	s := &SpeechService{
 		isListening: false,
 		stopChan:    make(chan bool, 1), // Buffered to prevent blocking
+		openSource:  open,
+		events:      events,
+	}
+	if s.openSource == nil {
+		s.openSource = s.openMicrophone
 	}
+	return s
 }
 
 // Startup initializes the service and loads the Vosk model
 func (s *SpeechService) Startup(ctx context.Context) {
 	s.ctx = ctx
+	if s.events == nil {
+		s.events = wailsEvents{ctx}
+	}
 	log.Println("🎤 SpeechService starting up...")
 
 	// Initialize PortAudio ONCE on startup
@@ -54,33 +120,84 @@ func (s *SpeechService) Startup(ctx context.Context) {
 		log.Printf("⚠️ Failed to initialize PortAudio: %v", err)
 	}
 
-	// Load Vosk model
-	modelPath := "./models/vosk-model-small-tr-0.3"
+	s.mu.Lock()
+	modelPath := s.modelPath
+	s.mu.Unlock()
+	if modelPath == "" {
+		log.Println("⚠️ No speech model installed - dictation and voice commands are off")
+		log.Println("📥 Install a Vosk model (e.g. vosk-model-small-tr-0.3) into the models folder of the data directory")
+		return
+	}
 	if err := s.LoadModel(modelPath); err != nil {
 		log.Printf("⚠️ Failed to load Vosk model: %v", err)
-		log.Println("📥 Model should be at: ./models/vosk-model-small-tr-0.3")
 		return
 	}
 
 	log.Println("✅ SpeechService ready - Vosk and PortAudio loaded")
 }
 
-// LoadModel loads the Vosk language model
+// SetModelPath selects the model s.Startup loads, see ModelManager.Resolve.
+// Setup that only main does (this, LoadCommands, LoadDictation, OnCaption)
+// are functions, not methods, so Wails doesn't bind them for the frontend.
+func SetModelPath(s *SpeechService, modelPath string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.modelPath = modelPath
+}
+
+// ModelPath returns the folder of the selected model
+func (s *SpeechService) ModelPath() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.modelPath
+}
+
+// LoadModel loads the Vosk language model, replacing the current one.
+// Dictation must be stopped first; running transcriptions finish on the old model.
 func (s *SpeechService) LoadModel(modelPath string) error {
+	s.mu.Lock()
+	listening := s.isListening
+	s.mu.Unlock()
+	if listening {
+		return fmt.Errorf("stop dictation before switching models")
+	}
+
 	log.Printf("📂 Loading model from: %s", modelPath)
 
+	// Loading takes seconds for large models; don't hold the lock meanwhile
 	model, err := vosk.NewModel(modelPath)
 	if err != nil {
 		return fmt.Errorf("failed to load model: %w", err)
 	}
 
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.isListening || s.closing {
+		model.Free()
+		return fmt.Errorf("speech service busy, model not switched")
+	}
+	if s.model != nil {
+		if s.activeJobs > 0 {
+			// A batch job may still create recognizers from it
+			s.retired = append(s.retired, s.model)
+		} else {
+			s.model.Free()
+		}
+	}
 	s.model = model
+	s.modelPath = modelPath
 	log.Println("✅ Vosk model loaded successfully")
 	return nil
 }
 
-// StartDictation starts listening to microphone and transcribing
+// StartDictation starts listening to microphone and transcribing.
+// Commands only run after the wake phrase, so dictating "temizle" mid-sentence is just text.
 func (s *SpeechService) StartDictation() error {
+	return s.startListening(ModeDictation)
+}
+
+// startListening creates the recognizer for the mode and starts the stream
+func (s *SpeechService) startListening(mode string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -88,49 +205,129 @@ func (s *SpeechService) StartDictation() error {
 		return fmt.Errorf("already listening")
 	}
 
-	if s.model == nil {
+	if s.model == nil && s.newRecognizer == nil {
 		return fmt.Errorf("model not loaded - check logs")
 	}
 
-	log.Println("🎤 Starting dictation...")
+	log.Printf("🎤 Starting %s...", mode)
 
 	// Create recognizer (16kHz sample rate)
-	var err error
 	if s.recognizer == nil {
-		s.recognizer, err = vosk.NewRecognizer(s.model, 16000.0)
+		recognizer, err := s.newRecognizerLocked(mode)
 		if err != nil {
 			return fmt.Errorf("failed to create recognizer: %w", err)
 		}
-		s.recognizer.SetWords(1)
+		s.recognizer = recognizer
+	}
+
+	if err := s.startStreamLocked(); err != nil {
+		return err
+	}
+
+	s.mode = mode
+	s.sentenceStart = true
+	s.vad = newVoiceDetector()
+	s.preroll = s.preroll[:0]
+	s.utteranceStart = time.Time{}
+	if mode == ModeCaption {
+		s.resetCaptionsLocked()
+	}
+	s.noSignalWarned, s.autoStopping = false, false
+	s.isListening = true
+	s.startCaptureLocked()
+
+	log.Println("✅ Dictation started - speak now!")
+	s.emit("speech-started", true)
+
+	return nil
+}
+
+// StopDictation stops the data flow but keeps the stream open for reuse.
+// The stream keeps running while an audio tap (e.g. the audio recorder) uses it.
+func (s *SpeechService) StopDictation() error {
+	s.mu.Lock()
+	if !s.isListening {
+		s.mu.Unlock()
+		return nil
+	}
+
+	log.Println("🛑 Stopping dictation data flow...")
+	s.isListening = false
+	s.mode = ""
+	s.armedUntil = time.Time{}
+	stopped := s.stopCaptureIfIdleLocked()
+	s.mu.Unlock()
+
+	if stopped {
+		s.wg.Wait()
 	}
 
+	// Keep recognizer alive for reuse or free and recreate
+	// Let's recreate recognizer to avoid buffer accumulation between sessions
+	s.mu.Lock()
+	if s.recognizer != nil {
+		s.recognizer.Free()
+		s.recognizer = nil
+	}
+	s.mu.Unlock()
+
+	s.emit("speech-stopped", true)
+
+	log.Println("✅ Dictation data flow stopped (Stream reserved)")
+	return nil
+}
+
+// startStreamLocked opens the persistent stream on first use and starts it. s.mu must be held.
+func (s *SpeechService) startStreamLocked() error {
+	if s.streamRunning {
+		return nil
+	}
+
+	// A newly selected microphone replaces the stopped stream
+	if s.reopenSource && s.source != nil {
+		s.source.Close()
+		s.source = nil
+	}
+	s.reopenSource = false
+
+	var err error
 	// Lazy init persistent stream
-	if s.stream == nil {
-		const sampleRate = 16000
+	if s.source == nil {
 		const framesPerBuffer = 1600
 		s.buffer = make([]int16, framesPerBuffer)
 
 		log.Println("🎙️ Opening persistent microphone stream...")
-		s.stream, err = portaudio.OpenDefaultStream(1, 0, sampleRate, framesPerBuffer, s.buffer)
+		s.source, err = s.openSource(s.buffer)
 		if err != nil {
 			return fmt.Errorf("failed to open stream: %w", err)
 		}
 	}
 
 	// Start (or resume) stream
-	if err := s.stream.Start(); err != nil {
+	if err := s.source.Start(); err != nil {
 		log.Printf("⚠️ Stream start error (attempting re-open): %v", err)
-		s.stream.Close()
-		s.stream = nil
+		s.source.Close()
+		s.source = nil
 		// Re-attempt open once
-		s.stream, err = portaudio.OpenDefaultStream(1, 0, 16000, 1600, s.buffer)
+		s.source, err = s.openSource(s.buffer)
 		if err != nil {
 			return fmt.Errorf("failed to re-open stream: %w", err)
 		}
-		if err := s.stream.Start(); err != nil {
+		if err := s.source.Start(); err != nil {
+			s.source.Close()
+			s.source = nil
 			return fmt.Errorf("fatal stream start failure: %w", err)
 		}
 	}
+	s.streamRunning = true
+	return nil
+}
+
+// startCaptureLocked starts processAudio unless it is already running. s.mu must be held.
+func (s *SpeechService) startCaptureLocked() {
+	if s.capturing {
+		return
+	}
 
 	// Drain stop channel
 	select {
@@ -139,55 +336,29 @@ func (s *SpeechService) StartDictation() error {
 	default:
 	}
 
-	s.isListening = true
+	s.capturing = true
 	s.wg.Add(1)
 	go s.processAudio(s.buffer)
-
-	log.Println("✅ Dictation started - speak now!")
-	runtime.EventsEmit(s.ctx, "speech-started", true)
-
-	return nil
 }
 
-// StopDictation stops the data flow but keeps the stream open for reuse
-func (s *SpeechService) StopDictation() error {
-	s.mu.Lock()
-	if !s.isListening {
-		s.mu.Unlock()
-		return nil
+// stopCaptureIfIdleLocked stops the stream once neither dictation nor a tap needs it.
+// Returns true if processAudio was told to stop. s.mu must be held.
+func (s *SpeechService) stopCaptureIfIdleLocked() bool {
+	if s.isListening || len(s.taps) > 0 {
+		return false
 	}
 
-	log.Println("🛑 Stopping dictation data flow...")
-	s.isListening = false
-
 	select {
 	case s.stopChan <- true:
 	default:
 	}
 
 	// Only STOP the stream, do not close it (prevents ALSA descriptor issues)
-	if s.stream != nil {
-		s.stream.Stop()
+	if s.source != nil && s.streamRunning {
+		s.source.Stop()
 	}
-	s.mu.Unlock()
-
-	s.wg.Wait()
-
-	// Keep recognizer alive for reuse or free and recreate
-	// Let's recreate recognizer to avoid buffer accumulation between sessions
-	s.mu.Lock()
-	if s.recognizer != nil {
-		s.recognizer.Free()
-		s.recognizer = nil
-	}
-	s.mu.Unlock()
-
-	if s.ctx != nil {
-		runtime.EventsEmit(s.ctx, "speech-stopped", true)
-	}
-
-	log.Println("✅ Dictation data flow stopped (Stream reserved)")
-	return nil
+	s.streamRunning = false
+	return true
 }
 
 // Shutdown releases all hardware resources (call on app exit)
@@ -195,18 +366,22 @@ func (s *SpeechService) Shutdown() {
 	s.mu.Lock()
 	log.Println("🤫 SpeechService shutting down...")
 	s.isListening = false
+	s.closing = true
+	s.taps = nil
 	s.mu.Unlock()
 
 	// Wait for any active processing to stop
 	s.wg.Wait()
+	s.jobs.Wait()
 
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if s.stream != nil {
-		s.stream.Stop()
-		s.stream.Close()
-		s.stream = nil
+	if s.source != nil {
+		s.source.Stop()
+		s.source.Close()
+		s.source = nil
+		s.streamRunning = false
 	}
 
 	if s.recognizer != nil {
@@ -218,142 +393,242 @@ func (s *SpeechService) Shutdown() {
 		s.model.Free()
 		s.model = nil
 	}
+	for _, model := range s.retired {
+		model.Free()
+	}
+	s.retired = nil
 
 	portaudio.Terminate()
 	log.Println("👋 SpeechService hardware released")
 }
 
-// processAudio processes incoming audio stream
+// processAudio reads the shared stream, hands every buffer to the audio taps
+// and, while dictating, to the recognizer. It runs until both are done.
 func (s *SpeechService) processAudio(buffer []int16) {
 	defer s.wg.Done()
 
 	for {
 		// Thread-safe check if we should continue
 		s.mu.Lock()
-		listening := s.isListening
-		stream := s.stream
-		recognizer := s.recognizer
-		s.mu.Unlock()
-
-		if !listening || stream == nil || recognizer == nil {
+		stream := s.source
+		if (!s.isListening && len(s.taps) == 0) || stream == nil {
+			s.capturing = false
+			s.mu.Unlock()
 			log.Println("ℹ️ processAudio: stopping (state mismatch or nil resource)")
 			return
 		}
+		s.mu.Unlock()
 
 		select {
 		case <-s.stopChan:
+			// A tap or dictation may have started again since the signal was sent
+			s.mu.Lock()
+			if (s.isListening || len(s.taps) > 0) && s.streamRunning {
+				s.mu.Unlock()
+				continue
+			}
+			s.capturing = false
+			s.mu.Unlock()
 			log.Println("ℹ️ processAudio: received stop signal")
 			return
 		default:
 			// Read audio from microphone
 			err := stream.Read()
+			if errors.Is(err, io.EOF) {
+				// A WAV file ran out: finish the last sentence instead of dropping it
+				log.Println("ℹ️ processAudio: audio source ended")
+				s.flushResult()
+				s.failCapture(stream, err)
+				return
+			}
 			if err != nil {
 				log.Printf("❌ Stream read error: %v", err)
-
-				// Attempt recovery if it's a "File descriptor in bad state" or similar ALSA error
-				if strings.Contains(err.Error(), "bad state") || strings.Contains(err.Error(), "underrun") {
-					log.Println("🔄 Attempting ALSA recovery...")
-					s.mu.Lock()
-					stream.Stop()
-					stream.Close()
-					s.stream = nil // Trigger re-open on next dictation
-					s.isListening = false
-					s.mu.Unlock()
-
-					runtime.EventsEmit(s.ctx, "speech-stopped", true)
-					return
-				}
+				s.failCapture(stream, err)
 				return
 			}
 
-			// Feed to recognizer (convert int16 buffer to bytes)
-			bufBytes := make([]byte, len(buffer)*2)
-			for i, sample := range buffer {
-				bufBytes[i*2] = byte(sample)
-				bufBytes[i*2+1] = byte(sample >> 8)
+			s.mu.Lock()
+			taps := make([]AudioTap, 0, len(s.taps))
+			for _, tap := range s.taps {
+				taps = append(taps, tap)
+			}
+			s.mu.Unlock()
+			for _, tap := range taps {
+				tap.OnSamples(buffer)
 			}
 
 			s.mu.Lock()
 			// Double check recognizer state after blocking Read()
-			if s.recognizer != nil {
-				if s.recognizer.AcceptWaveform(bufBytes) == 1 {
-					// Final result
-					resultJSON := s.recognizer.Result()
-					s.mu.Unlock() // Unlock before processing result
-
-					var result VoskResult
-					if err := json.Unmarshal([]byte(resultJSON), &result); err != nil {
-						log.Println("❌ JSON parse error:", err)
-						continue
-					}
+			if !s.isListening || s.recognizer == nil {
+				s.mu.Unlock()
+				continue
+			}
 
-					if result.Text != "" {
-						s.handleResult(result.Text)
-					}
-				} else {
-					// Partial result
-					partialJSON := s.recognizer.PartialResult()
-					s.mu.Unlock() // Unlock before emitting event
-
-					var partial VoskPartialResult
-					if err := json.Unmarshal([]byte(partialJSON), &partial); err == nil {
-						if partial.Partial != "" {
-							runtime.EventsEmit(s.ctx, "speech-partial", partial.Partial)
-						}
-					}
+			// Only speech reaches the recognizer; the end of a pause ends the utterance
+			rms, peak := measureLevel(buffer)
+			feed, started, ended := s.vad.Update(rms, peak, time.Duration(len(buffer))*time.Second/audioSampleRate)
+			level := AudioLevel{RMS: rms, Peak: peak, Speech: s.vad.Speaking()}
+			noSignal := s.checkSilenceLocked()
+			if !feed {
+				resultJSON := ""
+				if ended {
+					resultJSON = s.recognizer.FinalResult()
 				}
-			} else {
+				// Keep the buffer before speech starts, so the first syllable isn't clipped
+				s.preroll = append(s.preroll[:0], buffer...)
 				s.mu.Unlock()
-				return
+
+				s.emitLevel(level, noSignal)
+				if resultJSON != "" {
+					s.handleResultJSON(resultJSON)
+				}
+				continue
+			}
+			if started {
+				s.utteranceStart = time.Now().Add(-time.Duration(len(buffer)+len(s.preroll)) * time.Second / audioSampleRate)
+				if len(s.preroll) > 0 {
+					s.recognizer.AcceptWaveform(s.pcmBytesLocked(s.preroll))
+				}
 			}
+			s.mu.Unlock()
+			s.emitLevel(level, noSignal)
+			s.mu.Lock()
+			if !s.isListening || s.recognizer == nil {
+				s.mu.Unlock()
+				continue
+			}
+
+			if s.recognizer.AcceptWaveform(s.pcmBytesLocked(buffer)) == 1 {
+				// Final result
+				resultJSON := s.recognizer.Result()
+				s.mu.Unlock() // Unlock before processing result
+				s.handleResultJSON(resultJSON)
+			} else if s.mode == ModeCommand {
+				// Command words aren't text; don't show them as they are spoken
+				s.mu.Unlock()
+			} else {
+				// Partial result
+				partialJSON := s.recognizer.PartialResult()
+				captioning := s.mode == ModeCaption
+				s.mu.Unlock() // Unlock before emitting event
+
+				var partial VoskPartialResult
+				if err := json.Unmarshal([]byte(partialJSON), &partial); err == nil {
+					if partial.Partial != "" && captioning {
+						s.handleCaptionPartial(partial.Partial)
+					} else if partial.Partial != "" {
+						s.emit("speech-partial", partial.Partial)
+					}
+				}
+			}
+		}
+	}
+}
+
+// handleResultJSON parses a final Vosk result and handles its text
+func (s *SpeechService) handleResultJSON(resultJSON string) {
+	var result VoskResult
+	if err := json.Unmarshal([]byte(resultJSON), &result); err != nil {
+		log.Println("❌ JSON parse error:", err)
+		return
+	}
+	if result.Text != "" {
+		s.handleResult(result.Text, false)
+	}
+}
+
+// pcmBytesLocked converts samples to the little-endian bytes Vosk reads,
+// reusing one buffer instead of allocating per read. s.mu must be held.
+func (s *SpeechService) pcmBytesLocked(samples []int16) []byte {
+	if cap(s.pcm) < len(samples)*2 {
+		s.pcm = make([]byte, len(samples)*2)
+	}
+	data := s.pcm[:len(samples)*2]
+	for i, sample := range samples {
+		data[i*2] = byte(sample)
+		data[i*2+1] = byte(sample >> 8)
+	}
+	return data
+}
+
+// flushResult handles the words the recognizer still holds as a final result
+func (s *SpeechService) flushResult() {
+	s.mu.Lock()
+	if !s.isListening || s.recognizer == nil {
+		s.mu.Unlock()
+		return
+	}
+	resultJSON := s.recognizer.FinalResult()
+	s.mu.Unlock()
+	s.handleResultJSON(resultJSON)
+}
+
+// failCapture ends processAudio after a read error. Dictation stops and every
+// tap is removed and told, so a recording never silently stops growing.
+func (s *SpeechService) failCapture(stream AudioSource, err error) {
+	s.mu.Lock()
+	wasListening := s.isListening
+	taps := s.taps
+	s.taps = nil
+	s.isListening = false
+	s.capturing = false
+
+	// Attempt recovery if it's a "File descriptor in bad state" or similar ALSA error
+	if strings.Contains(err.Error(), "bad state") || strings.Contains(err.Error(), "underrun") {
+		log.Println("🔄 Attempting ALSA recovery...")
+		stream.Stop()
+		stream.Close()
+		s.source = nil // Trigger re-open on next dictation
+		s.streamRunning = false
+	} else if errors.Is(err, io.EOF) {
+		// Re-opening rewinds a file source
+		stream.Close()
+		s.source = nil
+		s.streamRunning = false
+	}
+	s.mu.Unlock()
+
+	for _, tap := range taps {
+		if tap.OnError != nil {
+			tap.OnError(err)
 		}
 	}
+	if wasListening {
+		s.emit("speech-stopped", true)
+	}
 }
 
-// handleResult processes recognized text and checks for voice commands
-func (s *SpeechService) handleResult(text string) {
-	text = strings.TrimSpace(text)
+// handleResult processes recognized text and checks for voice commands.
+// pushToTalk is true for the utterance finished by releasing push-to-talk.
+func (s *SpeechService) handleResult(text string, pushToTalk bool) {
+	text = strings.TrimSpace(strings.ReplaceAll(text, "[unk]", ""))
 	if text == "" {
 		return
 	}
 
 	log.Printf("🎤 Recognized: %s", text)
 
+	s.mu.Lock()
+	mode := s.mode
+	s.mu.Unlock()
+	if mode == ModeCaption {
+		s.handleCaption(text)
+		return
+	}
+
 	// Voice command detection
-	lowerText := strings.ToLower(text)
-	switch lowerText {
-	case "kırmızı kalem", "kirmizi kalem":
-		runtime.EventsEmit(s.ctx, "voice-command", map[string]interface{}{
-			"action": "tool-change",
-			"tool":   "pencil",
-			"color":  "#FF0000",
-		})
-		log.Println("🎨 Command: Red Pencil")
-
-	case "mavi kalem":
-		runtime.EventsEmit(s.ctx, "voice-command", map[string]interface{}{
-			"action": "tool-change",
-			"tool":   "pencil",
-			"color":  "#0000FF",
-		})
-		log.Println("🎨 Command: Blue Pencil")
-
-	case "silgi":
-		runtime.EventsEmit(s.ctx, "voice-command", map[string]interface{}{
-			"action": "tool-change",
-			"tool":   "eraser",
-		})
-		log.Println("🧹 Command: Eraser")
-
-	case "temizle", "sıfırla":
-		runtime.EventsEmit(s.ctx, "voice-command", map[string]interface{}{
-			"action": "canvas-clear",
-		})
-		log.Println("🗑️ Command: Clear Canvas")
+	if s.handleCommand(text, pushToTalk) {
+		return
+	}
 
-	default:
-		// Regular text - emit to frontend
-		runtime.EventsEmit(s.ctx, "speech-text", text)
+	if mode == ModeCommand {
+		log.Printf("🗣️ Ignored (no wake phrase): %s", text)
+		return
+	}
+
+	// Regular text - punctuate and capitalize, then emit to frontend
+	if text = s.normalize(text); text != "" {
+		s.emit("speech-text", text)
 	}
 }
 
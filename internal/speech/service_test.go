@@ -0,0 +1,567 @@
+package speech
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// eventRecorder is an EventSink that keeps everything it is told
+type eventRecorder struct {
+	mu     sync.Mutex
+	events []recordedEvent
+}
+
+type recordedEvent struct {
+	name string
+	data interface{}
+}
+
+func (r *eventRecorder) Emit(event string, data ...interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var payload interface{}
+	if len(data) > 0 {
+		payload = data[0]
+	}
+	r.events = append(r.events, recordedEvent{event, payload})
+}
+
+// named returns the events called name, in order
+func (r *eventRecorder) named(name string) []recordedEvent {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	found := []recordedEvent{}
+	for _, e := range r.events {
+		if e.name == name {
+			found = append(found, e)
+		}
+	}
+	return found
+}
+
+// waitFor waits until count events called name have arrived
+func (r *eventRecorder) waitFor(t *testing.T, name string, count int) []recordedEvent {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if found := r.named(name); len(found) >= count {
+			return found
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("timed out waiting for %d %q events, got %d", count, name, len(r.named(name)))
+	return nil
+}
+
+// fakeRecognizer stands in for Vosk: a stretch of loud samples followed by
+// silence is one utterance, recognized as the next line of its script
+type fakeRecognizer struct {
+	script   []string
+	speaking bool
+}
+
+func (f *fakeRecognizer) AcceptWaveform(buffer []byte) int {
+	loud := false
+	for i := 0; i+1 < len(buffer); i += 2 {
+		if v := int16(binary.LittleEndian.Uint16(buffer[i:])); v > 1000 || v < -1000 {
+			loud = true
+			break
+		}
+	}
+	if loud {
+		f.speaking = true
+		return 0
+	}
+	if f.speaking {
+		f.speaking = false
+		return 1
+	}
+	return 0
+}
+
+func (f *fakeRecognizer) next() string {
+	text := ""
+	if len(f.script) > 0 {
+		text, f.script = f.script[0], f.script[1:]
+	}
+	data, _ := json.Marshal(VoskResult{Text: text})
+	return string(data)
+}
+
+func (f *fakeRecognizer) Result() string { return f.next() }
+
+func (f *fakeRecognizer) PartialResult() string {
+	partial := ""
+	if f.speaking && len(f.script) > 0 {
+		partial = f.script[0]
+	}
+	data, _ := json.Marshal(VoskPartialResult{Partial: partial})
+	return string(data)
+}
+
+func (f *fakeRecognizer) FinalResult() string {
+	if !f.speaking {
+		return `{"text":""}`
+	}
+	f.speaking = false
+	return f.next()
+}
+
+func (f *fakeRecognizer) SetGrm(string) {}
+func (f *fakeRecognizer) Free()         {}
+
+// newTestService wires a service to source and a fake recognizer reading script
+func newTestService(open AudioSourceFunc, script ...string) (*SpeechService, *eventRecorder) {
+	events := &eventRecorder{}
+	s := NewSpeechServiceWith(open, events)
+	s.newRecognizer = func(mode string) (speechRecognizer, error) {
+		return &fakeRecognizer{script: script}, nil
+	}
+	return s, events
+}
+
+// writeTestWAV writes a 16 kHz recording: a 440 Hz tone for every true
+// segment and silence for every false one, each 0.5s long
+func writeTestWAV(t *testing.T, segments ...bool) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "test.wav")
+	w, err := createWAV(path, audioSampleRate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tone := SineWave(440, 8000)
+	half := make([]int16, audioSampleRate/2)
+	var offset int64
+	for _, loud := range segments {
+		for i := range half {
+			half[i] = 0
+		}
+		if loud {
+			tone(half, offset)
+		}
+		offset += int64(len(half))
+		if err := w.WriteSamples(half); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestReplayWAVEmitsTextAndCommands(t *testing.T) {
+	wav := writeTestWAV(t, false, true, false, true, false)
+	s, events := newTestService(NewWAVSource(wav, false), "yirmi üç nisan nokta", "ders dostu sayfa beş")
+
+	if err := s.StartDictation(); err != nil {
+		t.Fatal(err)
+	}
+	events.waitFor(t, "speech-stopped", 1)
+
+	if got := events.named("speech-started"); len(got) != 1 {
+		t.Errorf("speech-started sent %d times", len(got))
+	}
+	texts := events.named("speech-text")
+	if len(texts) != 1 || texts[0].data != "23 nisan." {
+		t.Errorf("speech-text = %v, want [23 nisan.]", texts)
+	}
+	commands := events.named("voice-command")
+	if len(commands) != 1 {
+		t.Fatalf("voice-command sent %d times, want 1", len(commands))
+	}
+	payload := commands[0].data.(map[string]interface{})
+	if payload["action"] != "page-go" || payload["page"] != 5 {
+		t.Errorf("voice-command payload = %v", payload)
+	}
+	if s.IsListening() {
+		t.Error("still listening after the file ended")
+	}
+}
+
+func TestReplayFlushesLastUtteranceAtEOF(t *testing.T) {
+	// The recording stops mid-sentence: no pause ends the utterance
+	wav := writeTestWAV(t, false, true)
+	s, events := newTestService(NewWAVSource(wav, false), "son cümle")
+
+	if err := s.StartDictation(); err != nil {
+		t.Fatal(err)
+	}
+	events.waitFor(t, "speech-stopped", 1)
+
+	texts := events.named("speech-text")
+	if len(texts) != 1 || texts[0].data != "Son cümle" {
+		t.Errorf("speech-text = %v, want [Son cümle]", texts)
+	}
+}
+
+func TestCommandModeIgnoresTextWithoutWakePhrase(t *testing.T) {
+	wav := writeTestWAV(t, true, false, true, false)
+	s, events := newTestService(NewWAVSource(wav, false), "temizle", "ders dostu temizle")
+
+	if err := s.StartCommandMode(); err != nil {
+		t.Fatal(err)
+	}
+	events.waitFor(t, "speech-stopped", 1)
+
+	if got := events.named("speech-text"); len(got) != 0 {
+		t.Errorf("command mode dictated %v", got)
+	}
+	commands := events.named("voice-command")
+	if len(commands) != 1 || commands[0].data.(map[string]interface{})["action"] != "canvas-clear" {
+		t.Errorf("voice-command = %v, want one canvas-clear", commands)
+	}
+}
+
+// scriptedSource is a microphone whose reads can block like a real blocking
+// stream, or fail with a given error
+type scriptedSource struct {
+	mu      sync.Mutex
+	buffer  []int16
+	reads   int
+	block   bool // reads block until Stop
+	failAt  int  // read number that returns failErr, 0 = never
+	failErr error
+	reading chan struct{} // receives when a read blocks
+	release chan struct{}
+
+	starts, stops, closes int
+}
+
+func (src *scriptedSource) Start() error {
+	src.mu.Lock()
+	defer src.mu.Unlock()
+	src.starts++
+	src.release = make(chan struct{})
+	return nil
+}
+
+func (src *scriptedSource) Read() error {
+	src.mu.Lock()
+	src.reads++
+	reads, release := src.reads, src.release
+	src.mu.Unlock()
+
+	if src.failAt > 0 && reads == src.failAt {
+		return src.failErr
+	}
+	if src.block {
+		select {
+		case src.reading <- struct{}{}:
+		default:
+		}
+		<-release
+		return errors.New("Stream is stopped")
+	}
+	time.Sleep(time.Millisecond)
+	return nil
+}
+
+func (src *scriptedSource) Stop() error {
+	src.mu.Lock()
+	defer src.mu.Unlock()
+	src.stops++
+	close(src.release)
+	return nil
+}
+
+func (src *scriptedSource) Close() error {
+	src.mu.Lock()
+	defer src.mu.Unlock()
+	src.closes++
+	return nil
+}
+
+func (src *scriptedSource) counts() (starts, stops, closes int) {
+	src.mu.Lock()
+	defer src.mu.Unlock()
+	return src.starts, src.stops, src.closes
+}
+
+// scriptedOpener returns an AudioSourceFunc handing out sources made by make, and the sources opened so far
+func scriptedOpener(make func() *scriptedSource) (AudioSourceFunc, func() []*scriptedSource) {
+	var mu sync.Mutex
+	opened := []*scriptedSource{}
+	open := func(buffer []int16) (AudioSource, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		src := make()
+		src.buffer = buffer
+		opened = append(opened, src)
+		return src, nil
+	}
+	return open, func() []*scriptedSource {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]*scriptedSource(nil), opened...)
+	}
+}
+
+func TestCaptionsNeverRunCommands(t *testing.T) {
+	wav := writeTestWAV(t, true, false, true, false)
+	s, events := newTestService(NewWAVSource(wav, false), "ders dostu temizle", "türev nokta")
+	saved := []CaptionLine{}
+	OnCaption(s, func(line CaptionLine) { saved = append(saved, line) }, nil)
+	s.SetAutoStopSilence(1)
+
+	if err := s.StartCaptions(); err != nil {
+		t.Fatal(err)
+	}
+	events.waitFor(t, "speech-stopped", 1)
+
+	if got := events.named("voice-command"); len(got) != 0 {
+		t.Errorf("captions ran commands: %v", got)
+	}
+	if got := events.named("speech-text"); len(got) != 0 {
+		t.Errorf("captions went to the text box: %v", got)
+	}
+	lines := s.GetCaptions()
+	if len(lines) != 2 || lines[0].Text != "Ders Dostu temizle" || lines[1].Text != "türev." {
+		t.Fatalf("captions = %+v", lines)
+	}
+	if len(saved) != 2 || len(events.named("caption-line")) != 2 {
+		t.Errorf("%d lines saved, %d caption-line events, want 2", len(saved), len(events.named("caption-line")))
+	}
+	for i, line := range lines {
+		if line.Seq != i+1 || line.Start > line.End || (i > 0 && line.Start < lines[i-1].End) {
+			t.Errorf("line %d badly timed: %+v", i, line)
+		}
+	}
+}
+
+func TestStopWhileReading(t *testing.T) {
+	reading := make(chan struct{}, 1)
+	open, opened := scriptedOpener(func() *scriptedSource {
+		return &scriptedSource{block: true, reading: reading}
+	})
+	s, events := newTestService(open)
+
+	for round := 1; round <= 2; round++ {
+		if err := s.StartDictation(); err != nil {
+			t.Fatalf("round %d: %v", round, err)
+		}
+		select {
+		case <-reading:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("round %d: no read started", round)
+		}
+
+		done := make(chan struct{})
+		go func() {
+			s.StopDictation()
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("round %d: StopDictation blocked on a pending read", round)
+		}
+		// The read interrupted by Stop is not a failure
+		if got := events.named("speech-stopped"); len(got) != round {
+			t.Errorf("round %d: speech-stopped sent %d times", round, len(got))
+		}
+	}
+
+	// The stream is stopped and restarted, never reopened
+	sources := opened()
+	if len(sources) != 1 {
+		t.Fatalf("opened %d streams, want 1", len(sources))
+	}
+	if starts, stops, closes := sources[0].counts(); starts != 2 || stops != 2 || closes != 0 {
+		t.Errorf("starts=%d stops=%d closes=%d, want 2 2 0", starts, stops, closes)
+	}
+}
+
+func TestALSARecoveryReopensStream(t *testing.T) {
+	open, opened := scriptedOpener(func() *scriptedSource {
+		return &scriptedSource{failAt: 3, failErr: errors.New("Unanticipated host error: File descriptor in bad state")}
+	})
+	s, events := newTestService(open)
+
+	tapErr := make(chan error, 1)
+	err := s.addAudioTap("test", AudioTap{
+		OnSamples: func([]int16) {},
+		OnError:   func(err error) { tapErr <- err },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.StartDictation(); err != nil {
+		t.Fatal(err)
+	}
+	events.waitFor(t, "speech-stopped", 1)
+
+	select {
+	case <-tapErr:
+	case <-time.After(5 * time.Second):
+		t.Fatal("audio tap was not told about the failure")
+	}
+	if s.IsListening() {
+		t.Error("still listening after the stream failed")
+	}
+	if _, _, closes := opened()[0].counts(); closes != 1 {
+		t.Errorf("broken stream closed %d times, want 1", closes)
+	}
+
+	// The next dictation gets a fresh stream
+	if err := s.StartDictation(); err != nil {
+		t.Fatal(err)
+	}
+	defer s.StopDictation()
+	if got := len(opened()); got != 2 {
+		t.Errorf("opened %d streams, want 2", got)
+	}
+}
+
+func TestTapKeepsStreamAfterDictationStops(t *testing.T) {
+	open, opened := scriptedOpener(func() *scriptedSource { return &scriptedSource{} })
+	s, _ := newTestService(open)
+
+	samples := make(chan struct{}, 1)
+	err := s.addAudioTap("recorder", AudioTap{OnSamples: func([]int16) {
+		select {
+		case samples <- struct{}{}:
+		default:
+		}
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.StartDictation(); err != nil {
+		t.Fatal(err)
+	}
+	s.StopDictation()
+
+	// Drain, then expect fresh buffers
+	select {
+	case <-samples:
+	default:
+	}
+	select {
+	case <-samples:
+	case <-time.After(5 * time.Second):
+		t.Fatal("tap stopped receiving samples when dictation stopped")
+	}
+	if _, stops, _ := opened()[0].counts(); stops != 0 {
+		t.Errorf("stream stopped %d times while the tap needed it", stops)
+	}
+
+	s.removeAudioTap("recorder")
+	if _, stops, _ := opened()[0].counts(); stops != 1 {
+		t.Errorf("stream stopped %d times after the last user left, want 1", stops)
+	}
+}
+
+// TestReplayRecordingsWithModel runs real recordings through Vosk. Point
+// DERSDOSTU_TEST_MODEL at a model and DERSDOSTU_TEST_AUDIO at a folder of
+// WAV files, each with a .txt file holding the expected dictation output.
+func TestReplayRecordingsWithModel(t *testing.T) {
+	modelPath, audioDir := os.Getenv("DERSDOSTU_TEST_MODEL"), os.Getenv("DERSDOSTU_TEST_AUDIO")
+	if modelPath == "" || audioDir == "" {
+		t.Skip("DERSDOSTU_TEST_MODEL and DERSDOSTU_TEST_AUDIO not set")
+	}
+	wavs, _ := filepath.Glob(filepath.Join(audioDir, "*.wav"))
+	if len(wavs) == 0 {
+		t.Skipf("no recordings in %s", audioDir)
+	}
+
+	for _, wav := range wavs {
+		t.Run(filepath.Base(wav), func(t *testing.T) {
+			expected, err := os.ReadFile(strings.TrimSuffix(wav, ".wav") + ".txt")
+			if err != nil {
+				t.Skip("no expected transcript")
+			}
+
+			events := &eventRecorder{}
+			s := NewSpeechServiceWith(NewWAVSource(wav, false), events)
+			if err := s.LoadModel(modelPath); err != nil {
+				t.Fatal(err)
+			}
+			defer s.Shutdown()
+
+			if err := s.StartDictation(); err != nil {
+				t.Fatal(err)
+			}
+			events.waitFor(t, "speech-stopped", 1)
+
+			texts := []string{}
+			for _, e := range events.named("speech-text") {
+				texts = append(texts, e.data.(string))
+			}
+			got, want := strings.Join(texts, " "), strings.TrimSpace(string(expected))
+			if foldTurkish(got) != foldTurkish(want) {
+				t.Errorf("got %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestSilenceAutoStopsAndWarnsAboutMutedMicrophone(t *testing.T) {
+	silence := func(buffer []int16, offset int64) {
+		for i := range buffer {
+			buffer[i] = 0
+		}
+	}
+	s, events := newTestService(NewSyntheticSource(silence, false))
+	fed := 0
+	s.newRecognizer = func(mode string) (speechRecognizer, error) {
+		return &countingRecognizer{fed: &fed}, nil
+	}
+	s.SetAutoStopSilence(5)
+
+	if err := s.StartDictation(); err != nil {
+		t.Fatal(err)
+	}
+	events.waitFor(t, "speech-stopped", 1)
+
+	if got := events.named("speech-no-signal"); len(got) != 1 {
+		t.Errorf("speech-no-signal sent %d times, want 1", len(got))
+	}
+	if len(events.named("speech-level")) == 0 {
+		t.Error("no speech-level events")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if fed != 0 {
+		t.Errorf("recognizer was fed %d silent buffers", fed)
+	}
+}
+
+func TestSteadyNoiseStillAutoStops(t *testing.T) {
+	s, events := newTestService(NewSyntheticSource(SineWave(100, 2000), false)) // a fan hum well above vadMinSpeech
+	fed := 0
+	s.newRecognizer = func(mode string) (speechRecognizer, error) {
+		return &countingRecognizer{fed: &fed}, nil
+	}
+	s.SetAutoStopSilence(5)
+
+	if err := s.StartDictation(); err != nil {
+		t.Fatal(err)
+	}
+	events.waitFor(t, "speech-stopped", 1)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// The hum passes for speech only until the noise floor is re-estimated
+	if limit := int((vadMaxSpeech + vadHangover) / (100 * time.Millisecond)); fed > limit+1 {
+		t.Errorf("recognizer was fed %d buffers of noise, want at most %d", fed, limit+1)
+	}
+}
+
+// countingRecognizer counts the buffers it is fed
+type countingRecognizer struct {
+	fakeRecognizer
+	fed *int
+}
+
+func (c *countingRecognizer) AcceptWaveform(buffer []byte) int {
+	*c.fed++
+	return 0
+}
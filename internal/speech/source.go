@@ -0,0 +1,147 @@
+package speech
+
+import (
+	"encoding/binary"
+	"io"
+	"math"
+	"time"
+
+	"github.com/gordonklaus/portaudio"
+)
+
+// AudioSource delivers 16 kHz mono samples. Each Read fills the buffer the
+// source was opened with; a source that runs out returns io.EOF.
+// *portaudio.Stream is one.
+type AudioSource interface {
+	Start() error
+	Read() error
+	Stop() error
+	Close() error
+}
+
+// AudioSourceFunc opens a source that fills buffer on every Read
+type AudioSourceFunc func(buffer []int16) (AudioSource, error)
+
+// openPortAudio opens the default microphone
+func openPortAudio(buffer []int16) (AudioSource, error) {
+	stream, err := portaudio.OpenDefaultStream(1, 0, audioSampleRate, len(buffer), buffer)
+	if err != nil {
+		return nil, err
+	}
+	return stream, nil
+}
+
+// NewWAVSource replays a recording as if it were spoken into the microphone.
+// Files other than 16 kHz mono WAV are decoded with ffmpeg. With realtime
+// the samples arrive at speaking pace, otherwise as fast as they are read.
+func NewWAVSource(path string, realtime bool) AudioSourceFunc {
+	return func(buffer []int16) (AudioSource, error) {
+		return &wavSource{path: path, buffer: buffer, realtime: realtime, raw: make([]byte, len(buffer)*2)}, nil
+	}
+}
+
+type wavSource struct {
+	path     string
+	buffer   []int16
+	raw      []byte
+	realtime bool
+	pcm      io.Reader
+	closePCM func(abort bool) error
+	next     time.Time // when the next buffer is due, in realtime mode
+}
+
+// Start opens the file on first use; after Stop it continues where it was
+func (w *wavSource) Start() error {
+	if w.pcm == nil {
+		pcm, closePCM, err := openPCM(w.path)
+		if err != nil {
+			return err
+		}
+		w.pcm, w.closePCM = pcm, closePCM
+	}
+	w.next = time.Now()
+	return nil
+}
+
+func (w *wavSource) Read() error {
+	if w.pcm == nil {
+		return io.EOF
+	}
+	n, err := io.ReadFull(w.pcm, w.raw)
+	if err == io.EOF {
+		return io.EOF
+	}
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return err
+	}
+	// Pad the last, partial buffer with silence
+	for i := n; i < len(w.raw); i++ {
+		w.raw[i] = 0
+	}
+	for i := range w.buffer {
+		w.buffer[i] = int16(binary.LittleEndian.Uint16(w.raw[i*2:]))
+	}
+
+	if w.realtime {
+		w.next = w.next.Add(time.Duration(len(w.buffer)) * time.Second / audioSampleRate)
+		time.Sleep(time.Until(w.next))
+	}
+	return nil
+}
+
+func (w *wavSource) Stop() error {
+	return nil
+}
+
+func (w *wavSource) Close() error {
+	if w.closePCM == nil {
+		return nil
+	}
+	err := w.closePCM(true)
+	w.pcm, w.closePCM = nil, nil
+	return err
+}
+
+// NewSyntheticSource generates the signal with fn, which fills buffer with
+// the samples starting at sample offset. It never runs out.
+func NewSyntheticSource(fn func(buffer []int16, offset int64), realtime bool) AudioSourceFunc {
+	return func(buffer []int16) (AudioSource, error) {
+		return &syntheticSource{fn: fn, buffer: buffer, realtime: realtime}, nil
+	}
+}
+
+// SineWave is a test tone for NewSyntheticSource
+func SineWave(frequency float64, amplitude int16) func(buffer []int16, offset int64) {
+	return func(buffer []int16, offset int64) {
+		for i := range buffer {
+			t := float64(offset+int64(i)) / audioSampleRate
+			buffer[i] = int16(float64(amplitude) * math.Sin(2*math.Pi*frequency*t))
+		}
+	}
+}
+
+type syntheticSource struct {
+	fn       func(buffer []int16, offset int64)
+	buffer   []int16
+	offset   int64
+	realtime bool
+	next     time.Time
+}
+
+func (g *syntheticSource) Start() error {
+	g.next = time.Now()
+	return nil
+}
+
+func (g *syntheticSource) Read() error {
+	g.fn(g.buffer, g.offset)
+	g.offset += int64(len(g.buffer))
+	if g.realtime {
+		g.next = g.next.Add(time.Duration(len(g.buffer)) * time.Second / audioSampleRate)
+		time.Sleep(time.Until(g.next))
+	}
+	return nil
+}
+
+func (g *syntheticSource) Stop() error  { return nil }
+func (g *syntheticSource) Close() error { return nil }
@@ -0,0 +1,63 @@
+package speech
+
+import (
+	"fmt"
+	"log"
+)
+
+// AudioTap receives the microphone buffers of the dictation stream, so another
+// feature (e.g. the audio recorder) can use the microphone while the teacher
+// dictates. PortAudio can't reliably open the same ALSA device twice.
+type AudioTap struct {
+	// OnSamples is called from the audio loop with 16kHz mono samples.
+	// It must not block and must copy the slice if it keeps it.
+	OnSamples func(samples []int16)
+	// OnError is called once when the stream fails; the tap is already removed
+	OnError func(err error)
+}
+
+// addAudioTap starts the stream if needed and registers a consumer under name
+func (s *SpeechService) addAudioTap(name string, tap AudioTap) error {
+	if tap.OnSamples == nil {
+		return fmt.Errorf("audio tap %s has no OnSamples", name)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closing {
+		return fmt.Errorf("speech service is shutting down")
+	}
+	if _, ok := s.taps[name]; ok {
+		return fmt.Errorf("audio tap %s already registered", name)
+	}
+	if err := s.startStreamLocked(); err != nil {
+		return err
+	}
+
+	if s.taps == nil {
+		s.taps = map[string]AudioTap{}
+	}
+	s.taps[name] = tap
+	s.startCaptureLocked()
+	log.Printf("🎙️ Audio tap added: %s", name)
+	return nil
+}
+
+// removeAudioTap unregisters a consumer; the stream stops when nobody needs it.
+// Must not be called from OnSamples or OnError.
+func (s *SpeechService) removeAudioTap(name string) {
+	s.mu.Lock()
+	if _, ok := s.taps[name]; !ok {
+		s.mu.Unlock()
+		return
+	}
+	delete(s.taps, name)
+	stopped := s.stopCaptureIfIdleLocked()
+	s.mu.Unlock()
+
+	if stopped {
+		s.wg.Wait()
+	}
+	log.Printf("🎙️ Audio tap removed: %s", name)
+}
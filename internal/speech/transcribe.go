@@ -0,0 +1,349 @@
+package speech
+
+import (
+	"DersDostu/internal/db"
+	"DersDostu/internal/recorder"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	vosk "github.com/alphacep/vosk-api/go"
+)
+
+const (
+	// transcribeChunk is 0.25s of 16kHz mono s16le
+	transcribeChunk = 8000
+	// A subtitle line is cut after this long or this many characters (two lines)
+	maxSegmentDuration = 6.0
+	maxSegmentChars    = 84
+	// A pause this long between words starts a new line
+	segmentPause = 1.0
+)
+
+// VoskWord is one word of a result with SetWords(1)
+type VoskWord struct {
+	Word  string  `json:"word"`
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+	Conf  float64 `json:"conf"`
+}
+
+// TranscriptionService transcribes finished recordings offline with the
+// dictation model, one at a time, into subtitles and a searchable transcript.
+type TranscriptionService struct {
+	PublicDir string
+	speech    *SpeechService
+	db        *db.DBService // nil when the database failed to open
+
+	mu            sync.Mutex
+	pending       []string      // recordings waiting, oldest first
+	wake          chan struct{} // signals the worker that pending grew
+	onTranscribed func(filename string, segments []db.TranscriptSegment)
+}
+
+// NewTranscriptionService creates the service and starts its worker
+func NewTranscriptionService(speech *SpeechService, publicDir string, database *db.DBService) *TranscriptionService {
+	t := &TranscriptionService{
+		PublicDir: publicDir,
+		speech:    speech,
+		db:        database,
+		wake:      make(chan struct{}, 1),
+	}
+	go t.worker()
+	return t
+}
+
+// OnTranscribed registers a callback run after each recording is transcribed
+func (t *TranscriptionService) OnTranscribed(fn func(filename string, segments []db.TranscriptSegment)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.onTranscribed = fn
+}
+
+// Enqueue schedules a recording (filename relative to PublicDir). The queue
+// has no limit, so a recording is never left without subtitles.
+func (t *TranscriptionService) Enqueue(filename string) {
+	t.mu.Lock()
+	t.pending = append(t.pending, filename)
+	t.mu.Unlock()
+
+	select {
+	case t.wake <- struct{}{}:
+	default: // the worker is already awake
+	}
+}
+
+func (t *TranscriptionService) worker() {
+	for range t.wake {
+		for {
+			filename, ok := t.next()
+			if !ok {
+				break
+			}
+			started := time.Now()
+			segments, err := t.transcribe(filename)
+			if err != nil {
+				log.Printf("Transcription failed for %s: %v", filename, err)
+				continue
+			}
+			log.Printf("Transcribed %s: %d lines in %s", filename, len(segments), time.Since(started).Round(time.Second))
+
+			t.mu.Lock()
+			onTranscribed := t.onTranscribed
+			t.mu.Unlock()
+			if onTranscribed != nil {
+				onTranscribed(filename, segments)
+			}
+		}
+	}
+}
+
+// next takes the oldest pending recording
+func (t *TranscriptionService) next() (string, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.pending) == 0 {
+		return "", false
+	}
+	filename := t.pending[0]
+	t.pending = t.pending[1:]
+	return filename, true
+}
+
+func (t *TranscriptionService) transcribe(filename string) ([]db.TranscriptSegment, error) {
+	segments, err := t.speech.transcribeFile(filepath.Join(t.PublicDir, filename))
+	if err != nil {
+		return nil, err
+	}
+
+	srt, vtt := recorder.SubtitlePaths(filename)
+	if err := writeAtomic(filepath.Join(t.PublicDir, srt), formatSRT(segments)); err != nil {
+		return nil, err
+	}
+	if err := writeAtomic(filepath.Join(t.PublicDir, vtt), formatVTT(segments)); err != nil {
+		return nil, err
+	}
+
+	if t.db != nil {
+		if err := t.db.SaveTranscript(filepath.ToSlash(filename), segments); err != nil {
+			return nil, err
+		}
+	}
+	return segments, nil
+}
+
+// transcribeFile runs the audio track of a media file through the model with a
+// recognizer of its own, so it can run while the teacher dictates. ffmpeg
+// decodes to 16kHz mono PCM, whatever the recording profile was.
+func (s *SpeechService) transcribeFile(path string) ([]db.TranscriptSegment, error) {
+	model, release, err := s.acquireModel()
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	recognizer, err := vosk.NewRecognizer(model, 16000.0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create recognizer: %w", err)
+	}
+	defer recognizer.Free()
+	recognizer.SetWords(1)
+
+	pcm, closePCM, err := openPCM(path)
+	if err != nil {
+		return nil, err
+	}
+
+	builder := &segmentBuilder{}
+	buf := make([]byte, transcribeChunk)
+	for {
+		if s.isClosing() {
+			closePCM(true)
+			return nil, fmt.Errorf("transcription cancelled")
+		}
+
+		n, err := io.ReadFull(pcm, buf)
+		if n > 0 && recognizer.AcceptWaveform(buf[:n]) == 1 {
+			builder.add(recognizer.Result())
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			closePCM(true)
+			return nil, fmt.Errorf("failed to read audio: %v", err)
+		}
+	}
+	builder.add(recognizer.FinalResult())
+
+	if err := closePCM(false); err != nil {
+		return nil, err
+	}
+	return builder.segments, nil
+}
+
+// openPCM returns the audio of a media file as 16kHz mono s16le. WAVs from the
+// audio recorder are already in that format and are read without ffmpeg.
+// close(true) aborts a decode that is still running.
+func openPCM(path string) (io.Reader, func(abort bool) error, error) {
+	if f, err := os.Open(path); err == nil {
+		header := make([]byte, wavHeaderSize)
+		if _, err := io.ReadFull(f, header); err == nil && isSpeechWAV(header) {
+			return f, func(bool) error { return f.Close() }, nil
+		}
+		f.Close()
+	}
+
+	cmd := exec.Command("ffmpeg", "-hide_banner", "-loglevel", "error", "-i", path, "-vn", "-ac", "1", "-ar", "16000", "-f", "s16le", "-")
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, nil, fmt.Errorf("failed to start ffmpeg: %v", err)
+	}
+
+	closeFn := func(abort bool) error {
+		if abort {
+			cmd.Process.Kill()
+		}
+		if err := cmd.Wait(); err != nil && !abort {
+			return fmt.Errorf("ffmpeg failed: %v: %s", err, strings.TrimSpace(stderr.String()))
+		}
+		return nil
+	}
+	return stdout, closeFn, nil
+}
+
+// isSpeechWAV reports a plain 44-byte header for 16kHz mono 16-bit PCM
+func isSpeechWAV(header []byte) bool {
+	return string(header[0:4]) == "RIFF" && string(header[8:12]) == "WAVE" &&
+		string(header[36:40]) == "data" &&
+		binary.LittleEndian.Uint16(header[20:]) == 1 &&
+		binary.LittleEndian.Uint16(header[22:]) == 1 &&
+		binary.LittleEndian.Uint32(header[24:]) == audioSampleRate &&
+		binary.LittleEndian.Uint16(header[34:]) == 16
+}
+
+// acquireModel hands out the model for a batch job; Shutdown waits for release
+func (s *SpeechService) acquireModel() (*vosk.VoskModel, func(), error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closing {
+		return nil, nil, fmt.Errorf("speech service is shutting down")
+	}
+	if s.model == nil {
+		return nil, nil, fmt.Errorf("model not loaded - check logs")
+	}
+	s.jobs.Add(1)
+	s.activeJobs++
+	release := func() {
+		s.mu.Lock()
+		s.activeJobs--
+		s.mu.Unlock()
+		s.jobs.Done()
+	}
+	return s.model, release, nil
+}
+
+func (s *SpeechService) isClosing() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.closing
+}
+
+// segmentBuilder cuts Vosk results into subtitle-sized lines
+type segmentBuilder struct {
+	segments []db.TranscriptSegment
+	words    []VoskWord
+}
+
+// add consumes one final result; an utterance never shares a line with the next
+func (b *segmentBuilder) add(resultJSON string) {
+	var result VoskResult
+	if err := json.Unmarshal([]byte(resultJSON), &result); err != nil {
+		log.Println("❌ JSON parse error:", err)
+		return
+	}
+	for _, w := range result.Result {
+		if len(b.words) > 0 {
+			first, last := b.words[0], b.words[len(b.words)-1]
+			if w.End-first.Start > maxSegmentDuration || w.Start-last.End > segmentPause || b.length()+1+utf8.RuneCountInString(w.Word) > maxSegmentChars {
+				b.flush()
+			}
+		}
+		b.words = append(b.words, w)
+	}
+	b.flush()
+}
+
+func (b *segmentBuilder) length() int {
+	n := -1
+	for _, w := range b.words {
+		n += utf8.RuneCountInString(w.Word) + 1
+	}
+	return n
+}
+
+func (b *segmentBuilder) flush() {
+	if len(b.words) == 0 {
+		return
+	}
+	text := make([]string, len(b.words))
+	for i, w := range b.words {
+		text[i] = w.Word
+	}
+	b.segments = append(b.segments, db.TranscriptSegment{
+		Start: b.words[0].Start,
+		End:   b.words[len(b.words)-1].End,
+		Text:  strings.Join(text, " "),
+	})
+	b.words = nil
+}
+
+// formatSRT renders segments as SubRip subtitles
+func formatSRT(segments []db.TranscriptSegment) string {
+	var b strings.Builder
+	for i, seg := range segments {
+		fmt.Fprintf(&b, "%d\n%s --> %s\n%s\n\n", i+1, subtitleTime(seg.Start, ","), subtitleTime(seg.End, ","), seg.Text)
+	}
+	return b.String()
+}
+
+// formatVTT renders segments as WebVTT subtitles
+func formatVTT(segments []db.TranscriptSegment) string {
+	escape := strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+	var b strings.Builder
+	b.WriteString("WEBVTT\n\n")
+	for _, seg := range segments {
+		fmt.Fprintf(&b, "%s --> %s\n%s\n\n", subtitleTime(seg.Start, "."), subtitleTime(seg.End, "."), escape.Replace(seg.Text))
+	}
+	return b.String()
+}
+
+// subtitleTime formats seconds as hh:mm:ss,mmm (SRT) or hh:mm:ss.mmm (WebVTT)
+func subtitleTime(seconds float64, sep string) string {
+	ms := int64(seconds*1000 + 0.5)
+	return fmt.Sprintf("%02d:%02d:%02d%s%03d", ms/3600000, ms/60000%60, ms/1000%60, sep, ms%1000)
+}
+
+// writeAtomic writes through a temp file so the LAN page never serves half a file
+func writeAtomic(path, content string) error {
+	tmp := path + ".part"
+	if err := os.WriteFile(tmp, []byte(content), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,417 @@
+package speech
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/gordonklaus/portaudio"
+)
+
+// Kinds of read-aloud text, reported with the tts events
+const (
+	SpeakText         = "text"         // board text
+	SpeakName         = "name"         // a student called during attendance
+	SpeakAnnouncement = "announcement" // timer and bell
+)
+
+// maxSpeechQueue stops a stuck speaker from piling up a whole lesson of text
+const maxSpeechQueue = 100
+
+// announcements are read for timer and bell events; %d is the minutes left
+var announcements = map[string]string{
+	"lesson-start":  "Ders başlıyor.",
+	"lesson-end":    "Ders bitti.",
+	"break":         "Teneffüs zamanı.",
+	"bell":          "Zil çaldı.",
+	"timer-warning": "Son %d dakika.",
+	"timer-end":     "Süre doldu.",
+}
+
+// Utterance is a queued text, sent with "tts-started" and "tts-finished"
+type Utterance struct {
+	ID          int    `json:"id"`
+	Text        string `json:"text"`
+	Kind        string `json:"kind"`
+	Interrupted bool   `json:"interrupted,omitempty"` // stopped before the end
+}
+
+// AudioSink plays mono samples: each Write plays the buffer it was opened
+// with. *portaudio.Stream is one.
+type AudioSink interface {
+	Start() error
+	Write() error
+	Stop() error // after the written samples have played
+	Abort() error
+	Close() error
+}
+
+// AudioSinkFunc opens an output playing buffer, at rate, on every Write
+type AudioSinkFunc func(rate float64, buffer []int16) (AudioSink, error)
+
+// TTSService reads text aloud with an offline Turkish voice. Texts are queued
+// and played one after another on the speaker through PortAudio, which
+// SpeechService initializes; shut this service down first.
+type TTSService struct {
+	mu        sync.Mutex
+	cond      *sync.Cond // signals the worker: queue or closing changed
+	events    EventSink
+	voice     synthesizer
+	voiceErr  error
+	voiceDirs []string
+	openSink  AudioSinkFunc
+	queue     []Utterance
+	nextID    int
+	current   *Utterance
+	cancel    context.CancelFunc // interrupts the current utterance
+	volume    float64            // 0..1
+	started   bool
+	closing   bool
+	done      chan struct{}
+}
+
+// NewTTSService creates a service looking for voices in voicesDir and next to the executable
+func NewTTSService(voicesDir string) *TTSService {
+	return NewTTSServiceWith(nil, nil, voicesDir)
+}
+
+// NewTTSServiceWith creates a service playing to another output and reporting
+// to another event sink. nil selects the speaker and Wails events.
+func NewTTSServiceWith(open AudioSinkFunc, events EventSink, voicesDir string) *TTSService {
+	t := &TTSService{
+		openSink:  open,
+		events:    events,
+		voiceDirs: []string{voicesDir},
+		volume:    1,
+		done:      make(chan struct{}),
+	}
+	if exe, err := os.Executable(); err == nil {
+		t.voiceDirs = append(t.voiceDirs, filepath.Join(filepath.Dir(exe), "voices"))
+	}
+	t.cond = sync.NewCond(&t.mu)
+	if t.openSink == nil {
+		t.openSink = openSpeaker
+	}
+	return t
+}
+
+// StartTTS finds the voice and starts the playback queue of t. Like
+// SetModelPath it is kept off the bound service.
+func StartTTS(ctx context.Context, t *TTSService) {
+	t.mu.Lock()
+	if t.events == nil {
+		t.events = wailsEvents{ctx}
+	}
+	if t.voice == nil {
+		t.voice, t.voiceErr = findVoice(t.voiceDirs...)
+	}
+	voice, voiceErr := t.voice, t.voiceErr
+	t.startLocked()
+	t.mu.Unlock()
+
+	if voiceErr != nil {
+		log.Printf("⚠️ Read-aloud is off: %v", voiceErr)
+		return
+	}
+	log.Printf("🔊 Read-aloud voice: %s", voice.Name())
+}
+
+// startLocked starts the worker once. t.mu must be held.
+func (t *TTSService) startLocked() {
+	if t.started {
+		return
+	}
+	t.started = true
+	go t.worker()
+}
+
+// ShutdownTTS interrupts playback and stops the queue of t. Call it before
+// SpeechService.Shutdown, which terminates PortAudio.
+func ShutdownTTS(t *TTSService) {
+	t.mu.Lock()
+	t.closing = true
+	t.queue = nil
+	if t.cancel != nil {
+		t.cancel()
+	}
+	started := t.started
+	t.cond.Broadcast()
+	t.mu.Unlock()
+
+	if started {
+		<-t.done
+	}
+}
+
+// Speak queues text to be read after what is already queued and returns its ID
+func (t *TTSService) Speak(text string) (int, error) {
+	return t.enqueue(text, SpeakText, false)
+}
+
+// SpeakNow interrupts whatever is being read, drops the queue and reads text
+func (t *TTSService) SpeakNow(text string) (int, error) {
+	t.Stop()
+	return t.enqueue(text, SpeakText, false)
+}
+
+// SpeakNames calls out students one by one, e.g. for attendance
+func (t *TTSService) SpeakNames(names []string) error {
+	for _, name := range names {
+		if _, err := t.enqueue(name, SpeakName, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Announce reads a timer or bell event ahead of the queue without cutting
+// off the current sentence. minutes is used by "timer-warning".
+func (t *TTSService) Announce(event string, minutes int) (int, error) {
+	template, ok := announcements[event]
+	if !ok {
+		return 0, fmt.Errorf("unknown announcement: %s", event)
+	}
+	text := template
+	if strings.Contains(template, "%d") {
+		text = fmt.Sprintf(template, minutes)
+	}
+	return t.enqueue(text, SpeakAnnouncement, true)
+}
+
+// Stop interrupts the current text and clears the queue
+func (t *TTSService) Stop() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.queue = nil
+	if t.cancel != nil {
+		t.cancel()
+	}
+}
+
+// SetVolume sets the read-aloud volume in percent, 0..100. It applies to the text being read too.
+func (t *TTSService) SetVolume(percent int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.volume = float64(min(max(percent, 0), 100)) / 100
+}
+
+// GetVolume returns the read-aloud volume in percent
+func (t *TTSService) GetVolume() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return int(t.volume*100 + 0.5)
+}
+
+// IsSpeaking reports whether something is being read or waits to be
+func (t *TTSService) IsSpeaking() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.current != nil || len(t.queue) > 0
+}
+
+// VoiceName describes the voice in use, empty if none was found
+func (t *TTSService) VoiceName() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.voice == nil {
+		return ""
+	}
+	return t.voice.Name()
+}
+
+func (t *TTSService) enqueue(text, kind string, front bool) (int, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return 0, fmt.Errorf("nothing to read")
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.voice == nil {
+		if t.voiceErr != nil {
+			return 0, t.voiceErr
+		}
+		return 0, fmt.Errorf("read-aloud not started")
+	}
+	if t.closing {
+		return 0, fmt.Errorf("read-aloud is shutting down")
+	}
+	if len(t.queue) >= maxSpeechQueue {
+		return 0, fmt.Errorf("read-aloud queue is full")
+	}
+
+	t.nextID++
+	u := Utterance{ID: t.nextID, Text: text, Kind: kind}
+	if front {
+		t.queue = append([]Utterance{u}, t.queue...)
+	} else {
+		t.queue = append(t.queue, u)
+	}
+	t.startLocked()
+	t.cond.Signal()
+	return u.ID, nil
+}
+
+// worker reads the queue aloud, one utterance at a time
+func (t *TTSService) worker() {
+	defer close(t.done)
+	for {
+		t.mu.Lock()
+		for len(t.queue) == 0 && !t.closing {
+			t.cond.Wait()
+		}
+		if t.closing {
+			t.mu.Unlock()
+			return
+		}
+		u := t.queue[0]
+		t.queue = t.queue[1:]
+		ctx, cancel := context.WithCancel(context.Background())
+		t.current, t.cancel = &u, cancel
+		voice := t.voice
+		t.mu.Unlock()
+
+		t.emit("tts-started", u)
+		err := t.play(ctx, voice, u.Text)
+		u.Interrupted = ctx.Err() != nil
+		cancel()
+
+		t.mu.Lock()
+		t.current, t.cancel = nil, nil
+		t.mu.Unlock()
+
+		if err != nil && !u.Interrupted {
+			log.Printf("❌ Read-aloud failed: %v", err)
+			t.emit("tts-error", err.Error())
+		}
+		t.emit("tts-finished", u)
+	}
+}
+
+// play synthesizes text and plays it, checking for interruption between buffers
+func (t *TTSService) play(ctx context.Context, voice synthesizer, text string) error {
+	samples, rate, err := voice.Synthesize(ctx, text)
+	if err != nil {
+		return err
+	}
+
+	// 50 ms buffers: an interruption is heard almost at once
+	buffer := make([]int16, int(rate/20))
+	sink, err := t.openSink(rate, buffer)
+	if err != nil {
+		return fmt.Errorf("failed to open speaker: %v", err)
+	}
+	defer sink.Close()
+	if err := sink.Start(); err != nil {
+		return fmt.Errorf("failed to start speaker: %v", err)
+	}
+
+	for offset := 0; offset < len(samples); offset += len(buffer) {
+		if ctx.Err() != nil {
+			sink.Abort()
+			return nil
+		}
+		t.mu.Lock()
+		gain := t.volume
+		t.mu.Unlock()
+
+		n := copy(buffer, samples[offset:])
+		for i := range buffer[:n] {
+			buffer[i] = clampSample(float64(buffer[i]) * gain)
+		}
+		clear(buffer[n:])
+		if err := sink.Write(); err != nil {
+			sink.Abort()
+			return fmt.Errorf("playback failed: %v", err)
+		}
+	}
+	return sink.Stop()
+}
+
+func (t *TTSService) emit(event string, data ...interface{}) {
+	if t.events != nil {
+		t.events.Emit(event, data...)
+	}
+}
+
+// openSpeaker opens the default output at the voice's rate, or at the
+// device's own rate (many only do 44.1/48 kHz) with the samples converted
+func openSpeaker(rate float64, buffer []int16) (AudioSink, error) {
+	stream, err := portaudio.OpenDefaultStream(0, 1, rate, len(buffer), buffer)
+	if err == nil {
+		return stream, nil
+	}
+	dev, devErr := portaudio.DefaultOutputDevice()
+	if devErr != nil || dev == nil || dev.DefaultSampleRate == rate {
+		return nil, err
+	}
+
+	out := make([]int16, int(float64(len(buffer))*dev.DefaultSampleRate/rate))
+	stream, err = portaudio.OpenDefaultStream(0, 1, dev.DefaultSampleRate, len(out), out)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s at %.0f Hz: %v", dev.Name, dev.DefaultSampleRate, err)
+	}
+	return newResamplingSink(stream, out, rate, dev.DefaultSampleRate, buffer), nil
+}
+
+// resamplingSink plays buffer at inRate on a stream running at another rate
+type resamplingSink struct {
+	stream AudioSink
+	out    []int16 // the stream plays this on every Write
+	buffer []int16 // filled by the caller
+	inRate float64
+	rate   float64
+	rs     *resampler
+	in     []float32
+	fifo   []int16 // converted samples not yet played
+}
+
+func newResamplingSink(stream AudioSink, out []int16, inRate, rate float64, buffer []int16) *resamplingSink {
+	return &resamplingSink{stream: stream, out: out, buffer: buffer, inRate: inRate, rate: rate, rs: newResampler(inRate, rate)}
+}
+
+func (r *resamplingSink) Start() error {
+	r.rs = newResampler(r.inRate, r.rate)
+	r.fifo = r.fifo[:0]
+	return r.stream.Start()
+}
+
+func (r *resamplingSink) Write() error {
+	r.in = r.in[:0]
+	for _, v := range r.buffer {
+		r.in = append(r.in, float32(v))
+	}
+	r.fifo = r.rs.Process(r.in, r.fifo)
+	return r.drain()
+}
+
+// drain plays every full output buffer in the fifo
+func (r *resamplingSink) drain() error {
+	for len(r.fifo) >= len(r.out) {
+		copy(r.out, r.fifo)
+		r.fifo = r.fifo[:copy(r.fifo, r.fifo[len(r.out):])]
+		if err := r.stream.Write(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Stop plays what the filter still holds, padded with silence, then stops
+func (r *resamplingSink) Stop() error {
+	r.fifo = r.rs.Process(make([]float32, 2*r.rs.half), r.fifo)
+	if rest := len(r.fifo) % len(r.out); rest > 0 {
+		r.fifo = append(r.fifo, make([]int16, len(r.out)-rest)...)
+	}
+	if err := r.drain(); err != nil {
+		return err
+	}
+	return r.stream.Stop()
+}
+
+func (r *resamplingSink) Abort() error { return r.stream.Abort() }
+func (r *resamplingSink) Close() error { return r.stream.Close() }
@@ -0,0 +1,141 @@
+package speech
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeVoice "says" every text as a constant tone lasting a fixed time
+type fakeVoice struct {
+	samples int
+}
+
+func (f *fakeVoice) Name() string { return "fake" }
+
+func (f *fakeVoice) Synthesize(ctx context.Context, text string) ([]int16, float64, error) {
+	samples := make([]int16, f.samples)
+	for i := range samples {
+		samples[i] = 10000
+	}
+	return samples, 16000, nil
+}
+
+// speakerLog collects what the fake speakers played, in real time
+type speakerLog struct {
+	mu      sync.Mutex
+	played  []int16
+	aborted int
+}
+
+type fakeSpeaker struct {
+	log    *speakerLog
+	buffer []int16
+}
+
+func (f *fakeSpeaker) Start() error { return nil }
+func (f *fakeSpeaker) Stop() error  { return nil }
+func (f *fakeSpeaker) Close() error { return nil }
+
+func (f *fakeSpeaker) Write() error {
+	time.Sleep(time.Duration(len(f.buffer)) * time.Second / 16000)
+	f.log.mu.Lock()
+	defer f.log.mu.Unlock()
+	f.log.played = append(f.log.played, f.buffer...)
+	return nil
+}
+
+func (f *fakeSpeaker) Abort() error {
+	f.log.mu.Lock()
+	defer f.log.mu.Unlock()
+	f.log.aborted++
+	return nil
+}
+
+func newTestTTS(t *testing.T, seconds float64) (*TTSService, *eventRecorder, *speakerLog) {
+	t.Helper()
+	events := &eventRecorder{}
+	speaker := &speakerLog{}
+	open := func(rate float64, buffer []int16) (AudioSink, error) {
+		return &fakeSpeaker{log: speaker, buffer: buffer}, nil
+	}
+	tts := NewTTSServiceWith(open, events, "")
+	tts.voice = &fakeVoice{samples: int(seconds * 16000)}
+	StartTTS(context.Background(), tts)
+	t.Cleanup(func() { ShutdownTTS(tts) })
+	return tts, events, speaker
+}
+
+func TestSpeechQueueKeepsOrderAndAnnouncementsGoFirst(t *testing.T) {
+	tts, events, _ := newTestTTS(t, 0.1)
+
+	if _, err := tts.Speak("Türev"); err != nil {
+		t.Fatal(err)
+	}
+	if err := tts.SpeakNames([]string{"Ali", "Ayşe"}); err != nil {
+		t.Fatal(err)
+	}
+	events.waitFor(t, "tts-started", 1)
+	if _, err := tts.Announce("timer-warning", 5); err != nil {
+		t.Fatal(err)
+	}
+	finished := events.waitFor(t, "tts-finished", 4)
+
+	want := []string{"Türev", "Son 5 dakika.", "Ali", "Ayşe"}
+	for i, event := range finished {
+		u := event.data.(Utterance)
+		if u.Text != want[i] || u.Interrupted {
+			t.Errorf("utterance %d = %+v, want %q read to the end", i, u, want[i])
+		}
+	}
+	if tts.IsSpeaking() {
+		t.Error("still speaking after the queue ran out")
+	}
+}
+
+func TestSpeakNowInterruptsAndClearsQueue(t *testing.T) {
+	tts, events, speaker := newTestTTS(t, 2)
+
+	tts.Speak("uzun bir metin")
+	tts.Speak("sırada bekleyen")
+	events.waitFor(t, "tts-started", 1)
+	time.Sleep(100 * time.Millisecond)
+
+	start := time.Now()
+	if _, err := tts.SpeakNow("hemen"); err != nil {
+		t.Fatal(err)
+	}
+	finished := events.waitFor(t, "tts-finished", 2)
+	if !finished[0].data.(Utterance).Interrupted {
+		t.Error("first text wasn't interrupted")
+	}
+	if got := finished[1].data.(Utterance).Text; got != "hemen" {
+		t.Errorf("read %q after the interruption, want the queue dropped", got)
+	}
+	if elapsed := time.Since(start); elapsed > 2500*time.Millisecond {
+		t.Errorf("interruption took %v", elapsed)
+	}
+	speaker.mu.Lock()
+	defer speaker.mu.Unlock()
+	if speaker.aborted != 1 {
+		t.Errorf("speaker aborted %d times, want 1", speaker.aborted)
+	}
+}
+
+func TestVolumeScalesPlayback(t *testing.T) {
+	tts, events, speaker := newTestTTS(t, 0.1)
+	tts.SetVolume(25)
+
+	tts.Speak("sessiz")
+	events.waitFor(t, "tts-finished", 1)
+
+	speaker.mu.Lock()
+	defer speaker.mu.Unlock()
+	if len(speaker.played) < 1600 || speaker.played[0] != 2500 {
+		t.Errorf("played %d samples starting at %v, want 1600 at 2500", len(speaker.played), speaker.played[:1])
+	}
+	if tts.GetVolume() != 25 {
+		t.Errorf("volume = %d", tts.GetVolume())
+	}
+}
@@ -0,0 +1,149 @@
+package speech
+
+import (
+	"log"
+	"math"
+	"time"
+)
+
+// Voice activity detection thresholds, as RMS relative to full scale
+const (
+	// vadMinSpeech is about -40 dBFS: quieter buffers are never speech
+	vadMinSpeech = 0.01
+	// vadRatio: speech must be this much louder than the background noise
+	vadRatio = 3.0
+	// vadHangover keeps an utterance open over short pauses between words
+	vadHangover = 400 * time.Millisecond
+	// vadMaxSpeech: nobody speaks this long without a single quiet buffer, so
+	// a sound that does is steady noise above vadMinSpeech (fan, air conditioner)
+	vadMaxSpeech = 15 * time.Second
+	// noSignalLevel: a peak below this is a muted or disconnected microphone
+	noSignalLevel = 0.0005
+	// noSignalAfter is how long dictation waits before warning about it
+	noSignalAfter = 3 * time.Second
+)
+
+// AudioLevel is the "speech-level" event for the VU meter, both 0..1
+type AudioLevel struct {
+	RMS    float64 `json:"rms"`
+	Peak   float64 `json:"peak"`
+	Speech bool    `json:"speech"` // the voice detector hears speech
+}
+
+// measureLevel returns the RMS and peak of a buffer, relative to full scale
+func measureLevel(buffer []int16) (float64, float64) {
+	if len(buffer) == 0 {
+		return 0, 0
+	}
+	var sum float64
+	var peak int
+	for _, sample := range buffer {
+		v := int(sample)
+		sum += float64(v * v)
+		if v < 0 {
+			v = -v
+		}
+		if v > peak {
+			peak = v
+		}
+	}
+	return math.Sqrt(sum/float64(len(buffer))) / 32768, float64(peak) / 32768
+}
+
+// voiceDetector is an energy-based VAD with an adaptive noise floor. It
+// decides per buffer whether the recognizer should hear it.
+type voiceDetector struct {
+	floor    float64       // background noise RMS
+	active   bool          // inside an utterance
+	hangover time.Duration // left before a pause ends the utterance
+	quietFor time.Duration // since the last speech, for auto-stop
+	loudFor  time.Duration // since the last buffer that wasn't speech
+	deadFor  time.Duration // since the microphone last carried any signal
+}
+
+func newVoiceDetector() *voiceDetector {
+	return &voiceDetector{floor: vadMinSpeech / vadRatio}
+}
+
+// Update classifies a buffer lasting d. feed: pass it to the recognizer;
+// started: it begins an utterance; ended: the utterance before it is over.
+func (v *voiceDetector) Update(rms, peak float64, d time.Duration) (feed, started, ended bool) {
+	if peak < noSignalLevel {
+		v.deadFor += d
+	} else {
+		v.deadFor = 0
+	}
+
+	speech := rms >= vadMinSpeech && rms >= v.floor*vadRatio
+	if speech {
+		v.loudFor += d
+		if v.loudFor >= vadMaxSpeech {
+			// The floor never got a quiet buffer to learn from; start again from this level
+			v.floor, v.loudFor = rms, 0
+			speech = false
+		}
+	} else {
+		v.loudFor = 0
+		// Track the background slowly, so a fan or projector doesn't count as speech
+		v.floor = 0.95*v.floor + 0.05*rms
+	}
+
+	switch {
+	case speech:
+		v.quietFor = 0
+		v.hangover = vadHangover
+		started = !v.active
+		v.active = true
+		return true, started, false
+	case v.active && v.hangover > 0:
+		// The pause may just be between words; Vosk needs it to place word ends
+		v.hangover -= d
+		v.quietFor += d
+		return true, false, false
+	case v.active:
+		v.active = false
+		v.quietFor += d
+		return false, false, true
+	default:
+		v.quietFor += d
+		return false, false, false
+	}
+}
+
+// Speaking reports whether an utterance is in progress
+func (v *voiceDetector) Speaking() bool {
+	return v.active
+}
+
+// SetAutoStopSilence stops dictation after this many seconds without speech, 0 = never
+func (s *SpeechService) SetAutoStopSilence(seconds int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.silenceStop = time.Duration(max(seconds, 0)) * time.Second
+}
+
+// checkSilenceLocked stops dictation after the configured silence and reports
+// whether the microphone just turned out to be dead. s.mu must be held.
+func (s *SpeechService) checkSilenceLocked() bool {
+	// Captions run for the whole lesson, pauses included
+	if s.silenceStop > 0 && s.vad.quietFor >= s.silenceStop && !s.autoStopping && !s.pushToTalk && s.mode != ModeCaption {
+		s.autoStopping = true
+		log.Printf("🤫 No speech for %v, stopping dictation", s.silenceStop)
+		// StopDictation waits for the audio loop, so it can't run on it
+		go s.StopDictation()
+	}
+	if s.vad.deadFor >= noSignalAfter && !s.noSignalWarned {
+		s.noSignalWarned = true
+		log.Println("⚠️ Microphone delivers no signal - muted or wrong device?")
+		return true
+	}
+	return false
+}
+
+// emitLevel feeds the VU meter and warns about a muted microphone
+func (s *SpeechService) emitLevel(level AudioLevel, noSignal bool) {
+	s.emit("speech-level", level)
+	if noSignal {
+		s.emit("speech-no-signal", true)
+	}
+}
@@ -0,0 +1,184 @@
+package speech
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// synthesizer turns text into mono 16-bit samples at the returned rate
+type synthesizer interface {
+	Name() string
+	Synthesize(ctx context.Context, text string) ([]int16, float64, error)
+}
+
+// piperVoice runs Piper (github.com/rhasspy/piper), a small offline neural
+// TTS with Turkish voices such as tr_TR-dfki-medium
+type piperVoice struct {
+	binary string
+	model  string // .onnx, with its .onnx.json next to it
+	rate   float64
+}
+
+func (p *piperVoice) Name() string {
+	return "piper " + strings.TrimSuffix(filepath.Base(p.model), ".onnx")
+}
+
+func (p *piperVoice) Synthesize(ctx context.Context, text string) ([]int16, float64, error) {
+	cmd := exec.CommandContext(ctx, p.binary, "--model", p.model, "--output_raw", "--quiet")
+	cmd.Stdin = strings.NewReader(text + "\n")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, 0, ctx.Err()
+		}
+		return nil, 0, fmt.Errorf("piper failed: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return bytesToSamples(out), p.rate, nil
+}
+
+// espeakVoice is the fallback: robotic, but installed almost everywhere
+type espeakVoice struct {
+	binary string
+}
+
+func (e *espeakVoice) Name() string { return "espeak-ng tr" }
+
+func (e *espeakVoice) Synthesize(ctx context.Context, text string) ([]int16, float64, error) {
+	// The text goes on stdin so a line starting with "-" isn't read as an option
+	cmd := exec.CommandContext(ctx, e.binary, "-v", "tr", "-s", "150", "--stdout", "--stdin")
+	cmd.Stdin = strings.NewReader(text + "\n")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, 0, ctx.Err()
+		}
+		return nil, 0, fmt.Errorf("espeak-ng failed: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return decodeWAV(out)
+}
+
+// findVoice picks the best Turkish voice: a Piper voice from the voices
+// folders, else espeak-ng. dirs are searched in order.
+func findVoice(dirs ...string) (synthesizer, error) {
+	for _, dir := range dirs {
+		if dir == "" {
+			continue
+		}
+		binary := findProgram("piper", filepath.Join(dir, "piper"), dir)
+		if binary == "" {
+			continue
+		}
+		models, _ := filepath.Glob(filepath.Join(dir, "tr_TR-*.onnx"))
+		sort.Strings(models)
+		for _, model := range models {
+			rate, err := piperSampleRate(model + ".json")
+			if err != nil {
+				continue
+			}
+			return &piperVoice{binary: binary, model: model, rate: rate}, nil
+		}
+	}
+	if binary := findProgram("espeak-ng", dirs...); binary != "" {
+		return &espeakVoice{binary: binary}, nil
+	}
+	return nil, fmt.Errorf("no Turkish voice found - install Piper with a tr_TR voice into the voices folder, or espeak-ng")
+}
+
+// findProgram looks for an executable in dirs, then on PATH
+func findProgram(name string, dirs ...string) string {
+	file := name
+	if runtime.GOOS == "windows" {
+		file += ".exe"
+	}
+	for _, dir := range dirs {
+		if dir == "" {
+			continue
+		}
+		path := filepath.Join(dir, file)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path
+		}
+	}
+	if path, err := exec.LookPath(name); err == nil {
+		return path
+	}
+	return ""
+}
+
+// piperSampleRate reads the output rate from a voice's config
+func piperSampleRate(configPath string) (float64, error) {
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return 0, err
+	}
+	var cfg struct {
+		Audio struct {
+			SampleRate float64 `json:"sample_rate"`
+		} `json:"audio"`
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return 0, fmt.Errorf("invalid voice config %s: %v", configPath, err)
+	}
+	if cfg.Audio.SampleRate <= 0 {
+		return 0, fmt.Errorf("voice config %s has no sample rate", configPath)
+	}
+	return cfg.Audio.SampleRate, nil
+}
+
+func bytesToSamples(data []byte) []int16 {
+	samples := make([]int16, len(data)/2)
+	for i := range samples {
+		samples[i] = int16(binary.LittleEndian.Uint16(data[i*2:]))
+	}
+	return samples
+}
+
+// decodeWAV reads 16-bit mono PCM. espeak-ng streams its WAV with a
+// placeholder data size, so the data chunk runs to the end of the file.
+func decodeWAV(data []byte) ([]int16, float64, error) {
+	if len(data) < 12 || string(data[0:4]) != "RIFF" || string(data[8:12]) != "WAVE" {
+		return nil, 0, fmt.Errorf("not a WAV file")
+	}
+	rate := 0.0
+	for pos := 12; pos+8 <= len(data); {
+		id := string(data[pos : pos+4])
+		size := int(binary.LittleEndian.Uint32(data[pos+4:]))
+		body := data[pos+8:]
+		if size < 0 || size > len(body) {
+			size = len(body)
+		}
+		switch id {
+		case "fmt ":
+			if size < 16 {
+				return nil, 0, fmt.Errorf("invalid WAV format chunk")
+			}
+			format := binary.LittleEndian.Uint16(body[0:])
+			channels := binary.LittleEndian.Uint16(body[2:])
+			bits := binary.LittleEndian.Uint16(body[14:])
+			if format != 1 || channels != 1 || bits != 16 {
+				return nil, 0, fmt.Errorf("unsupported WAV: format %d, %d channels, %d bits", format, channels, bits)
+			}
+			rate = float64(binary.LittleEndian.Uint32(body[4:]))
+		case "data":
+			if rate == 0 {
+				return nil, 0, fmt.Errorf("WAV data before format")
+			}
+			return bytesToSamples(body[:size]), rate, nil
+		}
+		pos += 8 + size + size%2
+	}
+	return nil, 0, fmt.Errorf("WAV has no data")
+}
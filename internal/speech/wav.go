@@ -0,0 +1,80 @@
+package speech
+
+import (
+	"encoding/binary"
+	"os"
+)
+
+const wavHeaderSize = 44
+
+// wavWriter streams 16-bit mono PCM into a WAV file. The header sizes are
+// refreshed on every flush, so a power cut leaves a playable file behind.
+type wavWriter struct {
+	f          *os.File
+	sampleRate int
+	dataBytes  uint32
+}
+
+func createWAV(path string, sampleRate int) (*wavWriter, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	w := &wavWriter{f: f, sampleRate: sampleRate}
+	// Written once through the file offset so samples follow it
+	if _, err := f.Write(w.header()); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return w, nil
+}
+
+// WriteSamples appends samples as little-endian PCM
+func (w *wavWriter) WriteSamples(samples []int16) error {
+	buf := make([]byte, len(samples)*2)
+	for i, sample := range samples {
+		binary.LittleEndian.PutUint16(buf[i*2:], uint16(sample))
+	}
+	n, err := w.f.Write(buf)
+	w.dataBytes += uint32(n)
+	return err
+}
+
+// Flush updates the header and syncs the file to disk
+func (w *wavWriter) Flush() error {
+	if err := w.writeHeader(); err != nil {
+		return err
+	}
+	return w.f.Sync()
+}
+
+func (w *wavWriter) Close() error {
+	if err := w.Flush(); err != nil {
+		w.f.Close()
+		return err
+	}
+	return w.f.Close()
+}
+
+// writeHeader refreshes the sizes; WriteAt leaves the append offset alone
+func (w *wavWriter) writeHeader() error {
+	_, err := w.f.WriteAt(w.header(), 0)
+	return err
+}
+
+func (w *wavWriter) header() []byte {
+	header := make([]byte, wavHeaderSize)
+	copy(header[0:], "RIFF")
+	binary.LittleEndian.PutUint32(header[4:], 36+w.dataBytes)
+	copy(header[8:], "WAVEfmt ")
+	binary.LittleEndian.PutUint32(header[16:], 16)                     // fmt chunk size
+	binary.LittleEndian.PutUint16(header[20:], 1)                      // PCM
+	binary.LittleEndian.PutUint16(header[22:], 1)                      // mono
+	binary.LittleEndian.PutUint32(header[24:], uint32(w.sampleRate))   // sample rate
+	binary.LittleEndian.PutUint32(header[28:], uint32(w.sampleRate*2)) // byte rate
+	binary.LittleEndian.PutUint16(header[32:], 2)                      // block align
+	binary.LittleEndian.PutUint16(header[34:], 16)                     // bits per sample
+	copy(header[36:], "data")
+	binary.LittleEndian.PutUint32(header[40:], w.dataBytes)
+	return header
+}
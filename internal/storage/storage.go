@@ -6,14 +6,33 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"strings"
+	"unicode"
 )
 
 // StorageManager handles file system paths and directory creation
 type StorageManager struct {
-	BaseDir   string
-	PublicDir string
-	LogDir    string
-	DBPath    string
+	BaseDir    string
+	PublicDir  string
+	LogDir     string
+	LessonsDir string
+	DBPath     string
+	ConfigPath string
+	// ModelsDir holds the installed speech models, one folder each
+	ModelsDir string
+	// VoicesDir holds the read-aloud engine (Piper) and its voices
+	VoicesDir string
+	// FontsDir holds TrueType fonts for exported PDFs, see lessons.ExportPDF
+	FontsDir string
+	// AutosaveDir holds the journal of the board, see lessons.Autosaver
+	AutosaveDir string
+	// WorkDir holds half-written files outside PublicDir, on the same disk
+	// so finished ones can be renamed into place
+	WorkDir string
+	// VoiceCommandsPath is the teacher-editable voice command grammar
+	VoiceCommandsPath string
+	// DictationPath holds the dictation clean-up settings and vocabulary
+	DictationPath string
 }
 
 // NewStorageManager initializes the storage paths
@@ -35,10 +54,20 @@ func NewStorageManager() (*StorageManager, error) {
 	}
 
 	sm := &StorageManager{
-		BaseDir:   baseDir,
-		PublicDir: filepath.Join(baseDir, "public"),
-		LogDir:    filepath.Join(baseDir, "logs"),
-		DBPath:    filepath.Join(baseDir, "dersdostu.db"),
+		BaseDir:    baseDir,
+		PublicDir:  filepath.Join(baseDir, "public"),
+		LogDir:     filepath.Join(baseDir, "logs"),
+		LessonsDir: filepath.Join(baseDir, "lessons"),
+		DBPath:     filepath.Join(baseDir, "dersdostu.db"),
+		ConfigPath: filepath.Join(baseDir, "config.json"),
+		ModelsDir:  filepath.Join(baseDir, "models"),
+		VoicesDir:  filepath.Join(baseDir, "voices"),
+
+		VoiceCommandsPath: filepath.Join(baseDir, "voice-commands.json"),
+		DictationPath:     filepath.Join(baseDir, "dictation.json"),
+		AutosaveDir:       filepath.Join(baseDir, "autosave"),
+		FontsDir:          filepath.Join(baseDir, "fonts"),
+		WorkDir:           filepath.Join(baseDir, "work"),
 	}
 
 	if err := sm.ensureDirs(); err != nil {
@@ -50,7 +79,7 @@ func NewStorageManager() (*StorageManager, error) {
 
 // ensureDirs creates the necessary directories if they don't exist
 func (sm *StorageManager) ensureDirs() error {
-	dirs := []string{sm.BaseDir, sm.PublicDir, sm.LogDir}
+	dirs := []string{sm.BaseDir, sm.PublicDir, sm.LogDir, sm.LessonsDir, sm.ModelsDir, sm.VoicesDir, sm.AutosaveDir, sm.FontsDir, sm.WorkDir}
 
 	for _, dir := range dirs {
 		if err := os.MkdirAll(dir, 0755); err != nil {
@@ -66,3 +95,32 @@ func (sm *StorageManager) ensureDirs() error {
 func (sm *StorageManager) GetPublicPath(filename string) string {
 	return filepath.Join(sm.PublicDir, filename)
 }
+
+// GetLessonDir returns the private folder of a lesson, creating it if needed.
+// Lesson folders live outside PublicDir so student uploads are not served to the LAN.
+func (sm *StorageManager) GetLessonDir(lessonID string) (string, error) {
+	dir := filepath.Join(sm.LessonsDir, lessonID)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create lesson directory %s: %v", dir, err)
+	}
+	return dir, nil
+}
+
+// SanitizeName turns user supplied text (student names, lesson titles, file names)
+// into a single safe path component. Letters are kept as-is so Turkish names stay readable.
+func SanitizeName(name string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.TrimSpace(name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+			dash = false
+			continue
+		}
+		if !dash && b.Len() > 0 {
+			b.WriteRune('-')
+			dash = true
+		}
+	}
+	return strings.TrimSuffix(b.String(), "-")
+}
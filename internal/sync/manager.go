@@ -2,8 +2,11 @@ package sync
 
 import (
 	"fmt"
+	"log"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 )
 
 // SyncManager handles the upload of lesson files
@@ -12,10 +15,15 @@ import (
 type SyncManager struct {
 	IsUploading bool
 	PublicDir   string
+
+	mu      sync.Mutex
+	uploads map[string]*uploadSession // chunked uploads in progress, by ID
 }
 
 // NewSyncManager creates a new instance
 func NewSyncManager(publicDir string) *SyncManager {
+	// Chunked uploads live in memory; parts left by a previous run can't be resumed
+	os.RemoveAll(filepath.Join(publicDir, uploadTempDir))
 	return &SyncManager{
 		IsUploading: false,
 		PublicDir:   publicDir,
@@ -25,24 +33,43 @@ func NewSyncManager(publicDir string) *SyncManager {
 // UploadFile simulates a file upload process (Dual Layer)
 // Layer 1: Save locally to "public" for LAN access (Immediate)
 // Layer 2: Upload to Cloud/FTP (Background)
+// The name is sanitized and the file appears atomically, as with a chunked upload.
 func (s *SyncManager) UploadFile(filename string, data []byte) (string, string, error) {
 	s.IsUploading = true
 	defer func() { s.IsUploading = false }()
 
 	// 1. Save Locally (Layer A)
-	localPath := filepath.Join(s.PublicDir, filename)
-	if err := os.WriteFile(localPath, data, 0644); err != nil {
-		return "", "", fmt.Errorf("failed to save local file: %v", err)
+	id, err := s.BeginUpload(filename, int64(len(data)), "")
+	if err != nil {
+		return "", "", err
+	}
+	for offset := 0; offset < len(data); offset += MaxChunkSize {
+		end := min(offset+MaxChunkSize, len(data))
+		if _, err := s.AppendChunk(id, int64(offset), data[offset:end]); err != nil {
+			s.AbortUpload(id)
+			return "", "", err
+		}
 	}
 
 	// 2. Return Local URL immediately
-	// Assumes Fiber is running on :8080
-	// In production, get real IP. For now, localhost is fine for the board itself,
-	// but for students scanning QR, we need the LAN IP.
 	// For MVP, we return a placeholder that Frontend can replace with window.location.hostname
-	url := fmt.Sprintf("http://<BOARD_IP>:8080/%s", filename)
-	return url, localPath, nil
+	// 3. Trigger Background Upload (Layer B) - TODO, see SyncFile
+	return s.CommitUpload(id)
+}
+
+// SyncFile hands a file that is already in PublicDir (e.g. a recording) to the
+// sync layer and returns its LAN URL. The cloud upload (Layer B) is still a mock.
+func (s *SyncManager) SyncFile(localPath string) (string, error) {
+	rel, err := filepath.Rel(s.PublicDir, localPath)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("file is outside the public folder: %s", localPath)
+	}
+	if _, err := os.Stat(localPath); err != nil {
+		return "", fmt.Errorf("file not found: %v", err)
+	}
+
+	// Layer B - TODO: go s.uploadToCloud(localPath)
+	log.Printf("[Sync] %s queued for cloud upload (mock)", rel)
 
-	// 3. Trigger Background Upload (Layer B) - TODO
-	// go s.uploadToCloud(localPath)
+	return fmt.Sprintf("http://<BOARD_IP>:8080/%s", filepath.ToSlash(rel)), nil
 }
@@ -0,0 +1,259 @@
+package sync
+
+import (
+	"DersDostu/internal/storage"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	// MaxUploadSize is the largest lesson file accepted (a long lesson PDF with photos)
+	MaxUploadSize = 512 * 1024 * 1024
+	// MaxChunkSize keeps every chunk small enough to decode on a 4 GB board
+	MaxChunkSize = 4 * 1024 * 1024
+	// maxUploadSessions limits how many uploads may be open at once
+	maxUploadSessions = 4
+	// uploadIdleTimeout: an upload without a chunk for this long was abandoned
+	uploadIdleTimeout = 30 * time.Minute
+	// uploadTempDir holds the partial files inside PublicDir, so the final
+	// rename stays on one disk. Their names are random and folders aren't listed.
+	uploadTempDir = ".uploads"
+)
+
+// allowedUploadTypes maps file extensions to the content sniffed from the file
+var allowedUploadTypes = map[string]string{
+	".pdf":  "application/pdf",
+	".png":  "image/png",
+	".jpg":  "image/jpeg",
+	".jpeg": "image/jpeg",
+	".webm": "video/webm",
+	".mp4":  "video/mp4",
+}
+
+// uploadSession is a file being received in chunks
+type uploadSession struct {
+	filename string // sanitized name in PublicDir
+	size     int64  // announced size
+	expected string // announced SHA-256 in hex, empty to skip the check
+	received int64
+	file     *os.File
+	hash     hash.Hash
+	lastUsed time.Time
+}
+
+// SanitizeFilename reduces a client supplied name to a safe file name in
+// PublicDir: no folders, no "..", letters and digits kept, an allowed extension.
+func SanitizeFilename(name string) (string, error) {
+	base := filepath.Base(strings.ReplaceAll(name, `\`, "/"))
+	ext := strings.ToLower(filepath.Ext(base))
+	if _, ok := allowedUploadTypes[ext]; !ok {
+		return "", fmt.Errorf("file type %q is not allowed", ext)
+	}
+	stem := []rune(storage.SanitizeName(strings.TrimSuffix(base, filepath.Ext(base))))
+	if len(stem) == 0 {
+		return "", fmt.Errorf("invalid file name %q", name)
+	}
+	if len(stem) > 100 {
+		stem = stem[:100]
+	}
+	return string(stem) + ext, nil
+}
+
+// BeginUpload opens an upload of size bytes and returns its ID. sha256 is
+// the hex digest of the whole file; if given, CommitUpload checks it.
+func (s *SyncManager) BeginUpload(filename string, size int64, sha256Hex string) (string, error) {
+	name, err := SanitizeFilename(filename)
+	if err != nil {
+		return "", err
+	}
+	if size <= 0 || size > MaxUploadSize {
+		return "", fmt.Errorf("file size must be between 1 byte and %d MB", MaxUploadSize/(1024*1024))
+	}
+	sha256Hex = strings.ToLower(strings.TrimSpace(sha256Hex))
+	if sha256Hex != "" {
+		if b, err := hex.DecodeString(sha256Hex); err != nil || len(b) != sha256.Size {
+			return "", fmt.Errorf("invalid SHA-256 digest")
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.expireUploadsLocked()
+	if len(s.uploads) >= maxUploadSessions {
+		return "", fmt.Errorf("too many uploads in progress")
+	}
+
+	dir := filepath.Join(s.PublicDir, uploadTempDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create upload folder: %v", err)
+	}
+	id := newUploadID()
+	file, err := os.OpenFile(filepath.Join(dir, id+".part"), os.O_CREATE|os.O_EXCL|os.O_RDWR, 0644)
+	if err != nil {
+		return "", fmt.Errorf("failed to start upload: %v", err)
+	}
+
+	if s.uploads == nil {
+		s.uploads = map[string]*uploadSession{}
+	}
+	s.uploads[id] = &uploadSession{
+		filename: name,
+		size:     size,
+		expected: sha256Hex,
+		file:     file,
+		hash:     sha256.New(),
+		lastUsed: time.Now(),
+	}
+	return id, nil
+}
+
+// AppendChunk writes the next chunk of an upload. offset must be the number
+// of bytes received so far, so a chunk sent twice is refused instead of
+// corrupting the file; the client resumes from the returned count.
+func (s *SyncManager) AppendChunk(id string, offset int64, data []byte) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u, ok := s.uploads[id]
+	if !ok {
+		return 0, fmt.Errorf("upload %s not found", id)
+	}
+	if offset != u.received {
+		return u.received, fmt.Errorf("chunk at byte %d, expected %d", offset, u.received)
+	}
+	if len(data) > MaxChunkSize {
+		return u.received, fmt.Errorf("chunk larger than %d MB", MaxChunkSize/(1024*1024))
+	}
+	if u.received+int64(len(data)) > u.size {
+		return u.received, fmt.Errorf("upload is larger than announced (%d bytes)", u.size)
+	}
+
+	if _, err := u.file.Write(data); err != nil {
+		s.abortLocked(id)
+		return 0, fmt.Errorf("failed to save chunk: %v", err)
+	}
+	u.hash.Write(data)
+	u.received += int64(len(data))
+	u.lastUsed = time.Now()
+	return u.received, nil
+}
+
+// CommitUpload verifies a complete upload and moves it into PublicDir in one
+// rename, so the LAN never sees half a file. An existing file of the same name
+// is kept and the upload numbered. Returns the URL and local path.
+func (s *SyncManager) CommitUpload(id string) (string, string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u, ok := s.uploads[id]
+	if !ok {
+		return "", "", fmt.Errorf("upload %s not found", id)
+	}
+	if u.received != u.size {
+		return "", "", fmt.Errorf("upload incomplete: %d of %d bytes", u.received, u.size)
+	}
+	// A bad upload can't be fixed by sending more; the client starts over
+	defer s.abortLocked(id)
+
+	digest := hex.EncodeToString(u.hash.Sum(nil))
+	if u.expected != "" && digest != u.expected {
+		return "", "", fmt.Errorf("upload damaged: checksum mismatch")
+	}
+	if err := checkUploadType(u.file, u.filename); err != nil {
+		return "", "", err
+	}
+	if err := u.file.Sync(); err != nil {
+		return "", "", fmt.Errorf("failed to save upload: %v", err)
+	}
+	if err := u.file.Close(); err != nil {
+		return "", "", fmt.Errorf("failed to save upload: %v", err)
+	}
+
+	localPath := freeName(s.PublicDir, u.filename)
+	if err := os.Rename(u.file.Name(), localPath); err != nil {
+		return "", "", fmt.Errorf("failed to save local file: %v", err)
+	}
+	log.Printf("[Sync] %s received (%d bytes, sha256 %s)", filepath.Base(localPath), u.size, digest[:12])
+
+	url, err := s.SyncFile(localPath)
+	if err != nil {
+		return "", "", err
+	}
+	return url, localPath, nil
+}
+
+// AbortUpload cancels an upload and deletes what was received
+func (s *SyncManager) AbortUpload(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.uploads[id]; !ok {
+		return fmt.Errorf("upload %s not found", id)
+	}
+	s.abortLocked(id)
+	return nil
+}
+
+// abortLocked closes and removes an upload's partial file. A committed
+// upload was already renamed, so the remove does nothing. s.mu must be held.
+func (s *SyncManager) abortLocked(id string) {
+	u := s.uploads[id]
+	delete(s.uploads, id)
+	u.file.Close()
+	os.Remove(u.file.Name())
+}
+
+// expireUploadsLocked drops uploads the client gave up on. s.mu must be held.
+func (s *SyncManager) expireUploadsLocked() {
+	for id, u := range s.uploads {
+		if time.Since(u.lastUsed) > uploadIdleTimeout {
+			log.Printf("[Sync] Abandoned upload of %s removed", u.filename)
+			s.abortLocked(id)
+		}
+	}
+}
+
+// freeName returns a path for filename in dir that doesn't exist yet, adding
+// "-2", "-3", ... like submissions do, so an upload never replaces a
+// recording or an earlier upload
+func freeName(dir, filename string) string {
+	ext := filepath.Ext(filename)
+	base := strings.TrimSuffix(filename, ext)
+	path := filepath.Join(dir, filename)
+	for i := 2; exists(path); i++ {
+		path = filepath.Join(dir, fmt.Sprintf("%s-%d%s", base, i, ext))
+	}
+	return path
+}
+
+func exists(path string) bool {
+	_, err := os.Lstat(path)
+	return err == nil
+}
+
+// checkUploadType trusts the content, not the file name
+func checkUploadType(f *os.File, filename string) error {
+	head := make([]byte, 512)
+	n, err := f.ReadAt(head, 0)
+	if err != nil && err != io.EOF {
+		return fmt.Errorf("failed to read upload: %v", err)
+	}
+	mimeType := strings.Split(http.DetectContentType(head[:n]), ";")[0]
+	if want := allowedUploadTypes[filepath.Ext(filename)]; mimeType != want {
+		return fmt.Errorf("file content (%s) doesn't match its name %s", mimeType, filename)
+	}
+	return nil
+}
+
+func newUploadID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,93 @@
+package sync
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSanitizeFilenameStaysInPublicDir(t *testing.T) {
+	for name, want := range map[string]string{
+		"../../Windows/evil.pdf":    "evil.pdf",
+		`..\..\boot.PDF`:            "boot.pdf",
+		"ders 9-A: Türev.pdf":       "ders-9-A-Türev.pdf",
+		"ders-2026-10-18T10-00.pdf": "ders-2026-10-18T10-00.pdf",
+	} {
+		if got, err := SanitizeFilename(name); err != nil || got != want {
+			t.Errorf("SanitizeFilename(%q) = %q, %v; want %q", name, got, err, want)
+		}
+	}
+	for _, name := range []string{"..", "../.pdf", "notes.html", "run.exe", ""} {
+		if got, err := SanitizeFilename(name); err == nil {
+			t.Errorf("SanitizeFilename(%q) = %q, want an error", name, got)
+		}
+	}
+}
+
+func TestChunkedUploadCommitsAtomically(t *testing.T) {
+	dir := t.TempDir()
+	s := NewSyncManager(dir)
+	data := append([]byte("%PDF-1.7\n"), bytes.Repeat([]byte("ders notları "), 1000)...)
+	sum := sha256.Sum256(data)
+
+	id, err := s.BeginUpload("../ders.pdf", int64(len(data)), hex.EncodeToString(sum[:]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	half := len(data) / 2
+	if _, err := s.AppendChunk(id, 0, data[:half]); err != nil {
+		t.Fatal(err)
+	}
+	// A retried chunk is refused and tells the client where to resume
+	if received, err := s.AppendChunk(id, 0, data[:half]); err == nil || received != int64(half) {
+		t.Errorf("duplicate chunk: received %d, err %v", received, err)
+	}
+	if _, _, err := s.CommitUpload(id); err == nil {
+		t.Error("incomplete upload committed")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "ders.pdf")); !os.IsNotExist(err) {
+		t.Error("partial file visible in the public folder")
+	}
+	if _, err := s.AppendChunk(id, int64(half), data[half:]); err != nil {
+		t.Fatal(err)
+	}
+
+	_, path, err := s.CommitUpload(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path != filepath.Join(dir, "ders.pdf") {
+		t.Errorf("stored at %s", path)
+	}
+	if got, _ := os.ReadFile(path); !bytes.Equal(got, data) {
+		t.Error("stored file differs from the upload")
+	}
+	if parts, _ := os.ReadDir(filepath.Join(dir, uploadTempDir)); len(parts) != 0 {
+		t.Errorf("%d partial files left", len(parts))
+	}
+
+	// The same name again gets a number instead of replacing the first file
+	id, _ = s.BeginUpload("ders.pdf", int64(len(data)), "")
+	s.AppendChunk(id, 0, data)
+	if _, second, err := s.CommitUpload(id); err != nil || second != filepath.Join(dir, "ders-2.pdf") {
+		t.Errorf("second upload stored at %s, %v", second, err)
+	}
+	if got, _ := os.ReadFile(path); !bytes.Equal(got, data) {
+		t.Error("first upload was overwritten")
+	}
+
+	// A damaged upload is rejected and cleaned up
+	id, _ = s.BeginUpload("bozuk.pdf", int64(len(data)), hex.EncodeToString(sum[:]))
+	damaged := append([]byte(nil), data...)
+	damaged[len(damaged)-1] ^= 1
+	s.AppendChunk(id, 0, damaged)
+	if _, _, err := s.CommitUpload(id); err == nil {
+		t.Error("checksum mismatch accepted")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "bozuk.pdf")); !os.IsNotExist(err) {
+		t.Error("damaged upload published")
+	}
+}
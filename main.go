@@ -4,12 +4,16 @@ import (
 	"context"
 	"embed"
 	"log"
+	"time"
 
 	//"os"
 
 	"DersDostu/internal/ai"
+	"DersDostu/internal/config"
 	"DersDostu/internal/db"
+	"DersDostu/internal/lessons"
 	"DersDostu/internal/mailer"
+	"DersDostu/internal/postprocess"
 	"DersDostu/internal/recorder"
 	"DersDostu/internal/server"
 	"DersDostu/internal/speech"
@@ -31,13 +35,39 @@ func main() {
 		log.Fatal("Failed to initialize storage:", err)
 	}
 
+	cfgManager, err := config.NewConfigManager(storageMgr.ConfigPath)
+	if err != nil {
+		log.Fatal("Failed to load config:", err)
+	}
+
 	// 1. Initialize Services
-	// 1. Initialize Services
-	recService := recorder.NewRecorderService(storageMgr.PublicDir)
+	recService := recorder.NewRecorderService(storageMgr.PublicDir, storageMgr.WorkDir)
+	recCfg := cfgManager.Get().Recording
+	recService.SetSegmentDuration(time.Duration(recCfg.SegmentMinutes) * time.Minute)
+	recService.SetProfile(recCfg.ActiveProfile())
+	recService.SetMicrophone(recCfg.Microphone)
+	recService.SetCapture(recCfg.Capture)
+	previewService := recorder.NewPreviewService(storageMgr.PublicDir, storageMgr.WorkDir)
+	previewService.EnqueueMissing()
 	syncManager := sync.NewSyncManager(storageMgr.PublicDir)
 	aiService := ai.NewShapeService()
 	mailerService := mailer.NewMailerService()
 	speechService := speech.NewSpeechService()
+	if err := speech.LoadCommands(speechService, storageMgr.VoiceCommandsPath); err != nil {
+		log.Printf("Warning: Failed to load voice commands: %v", err)
+	}
+	if err := speech.LoadDictation(speechService, storageMgr.DictationPath); err != nil {
+		log.Printf("Warning: Failed to load dictation settings: %v", err)
+	}
+	// Speech models live in the data directory, not relative to the working directory
+	modelManager := speech.NewModelManager(speechService, storageMgr.ModelsDir)
+	speechCfg := cfgManager.Get().Speech
+	speech.SetModelPath(speechService, modelManager.Resolve(speechCfg.Model))
+	speechService.SetInputDevice(speechCfg.Microphone)
+	speechService.SetAutoStopSilence(speechCfg.AutoStopSeconds)
+	// Read-aloud plays through the PortAudio instance SpeechService initializes
+	ttsService := speech.NewTTSService(storageMgr.VoicesDir)
+	ttsService.SetVolume(speechCfg.ReadAloudVolume)
 
 	// DB: Use path from storage manager
 	dbService, err := db.NewDBService(storageMgr.DBPath)
@@ -47,10 +77,33 @@ func main() {
 
 	// 2. Start Local File Server (bg)
 	// Serve the 'public' folder from our data directory
-	go server.Start(storageMgr.PublicDir)
+	fileServer := server.NewServerService(storageMgr.PublicDir)
+	fileServer.SetCaptionsEnabled(speechCfg.ShareCaptions)
+	go fileServer.Start()
+
+	// Announce the board as sinif-<class>.local so students can type a name instead of an IP
+	board := cfgManager.Get().Board
+	if err := fileServer.Advertise(server.BoardInfo{School: board.School, ClassName: board.ClassName, BoardName: board.BoardName}); err != nil {
+		log.Printf("Warning: mDNS advertisement failed: %v", err)
+	}
+
+	// Finished recordings: metadata, thumbnails, lesson linking, upload
+	postProcessor := postprocess.NewPostProcessor(storageMgr.PublicDir, dbService, syncManager, previewService)
+
+	// Subtitles and searchable transcripts from the dictation model
+	transcriptionService := speech.NewTranscriptionService(speechService, storageMgr.PublicDir, dbService)
+
+	// Microphone-only recording for boards without ffmpeg, sharing the dictation stream
+	audioRecorder := speech.NewAudioRecorder(speechService, storageMgr.PublicDir)
+	audioRecorder.SetFormat(recCfg.AudioFormat)
+
+	// Lesson documents: pages in SQLite, layer images in the lessons folder
+	lessonManager := lessons.NewLessonManager(dbService, storageMgr)
+	// Journal of the board; an unfinished lesson from a power cut is offered for restore
+	autosaver := lessons.NewAutosaver(storageMgr.AutosaveDir)
 
 	// Create an instance of the app structure
-	app := NewApp(recService, syncManager, aiService, dbService, mailerService)
+	app := NewApp(recService, syncManager, aiService, dbService, mailerService, fileServer, storageMgr, cfgManager, postProcessor, transcriptionService, audioRecorder, modelManager, speechService, ttsService, lessonManager, autosaver)
 
 	// Create application with options
 	err = wails.Run(&options.App{
@@ -64,13 +117,18 @@ func main() {
 		OnStartup: func(ctx context.Context) {
 			app.startup(ctx)
 			speechService.Startup(ctx)
+			speech.StartTTS(ctx, ttsService)
 		},
 		OnShutdown: func(ctx context.Context) {
+			app.shutdown(ctx)
+			fileServer.StopAdvertising()
+			speech.ShutdownTTS(ttsService)
 			speechService.Shutdown()
 		},
 		Bind: []interface{}{
 			app,
 			speechService,
+			ttsService,
 		},
 	})
 